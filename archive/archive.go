@@ -0,0 +1,73 @@
+// Package archive implements the safe extraction and creation of tar and zip
+// archives.
+//
+// Extract extracts an archive of either format, detected from its content,
+// into a directory; a tar archive may also be compressed. Extraction defends
+// against entries which would be written outside the directory, whether by
+// their names or through symbolic links, and against archives which expand to
+// excessive sizes:
+//
+//	err := archive.ExtractFile("release.tar.gz", "out", &archive.ExtractOptions{
+//		MaxSize: 100 << 20,
+//	})
+//
+// An entry which cannot be extracted does not stop the extraction of others;
+// the error returned lists every failed entry.
+//
+// Create archives a directory tree, selecting files by glob patterns:
+//
+//	err := archive.CreateFile("src.zip", "src", &archive.CreateOptions{
+//		Exclude: []string{".git", "*.tmp"},
+//	})
+package archive
+
+import (
+	"bytes"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Format is an archive format.
+type Format int
+
+const (
+	// Tar is an uncompressed tar archive.
+	Tar Format = iota
+	// TarGzip is a tar archive compressed by gzip.
+	TarGzip
+	// Zip is a zip archive.
+	Zip
+)
+
+// String returns the name of f, such as "tar.gz".
+func (f Format) String() string {
+	switch f {
+	case Tar:
+		return "tar"
+	case TarGzip:
+		return "tar.gz"
+	case Zip:
+		return "zip"
+	}
+	return "unknown"
+}
+
+// isZip reports whether an archive beginning with head is a zip archive.
+func isZip(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("PK\x03\x04")) || bytes.HasPrefix(head, []byte("PK\x05\x06"))
+}
+
+// formatOf returns the format of an archive named name, by its extension.
+func formatOf(name string) (Format, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return Zip, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return TarGzip, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return Tar, nil
+	}
+	return 0, errors.New(nil, "unknown archive format of %s", name)
+}
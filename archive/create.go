@@ -0,0 +1,191 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/fsx"
+)
+
+// CreateOptions configures Create and CreateFile. A nil *CreateOptions is
+// equivalent to a zero CreateOptions, which archives every entry of the tree.
+type CreateOptions struct {
+	// Include, if not empty, limits the entries archived to those matching
+	// at least one pattern, as described for fsx.WalkOptions.
+	Include []string
+
+	// Exclude skips entries matching any pattern, as described for
+	// fsx.WalkOptions.
+	Exclude []string
+}
+
+// Create writes an archive of the given format to w, holding the tree rooted
+// at the directory dir. Entries are named by their slash-separated paths
+// relative to dir, and record the permission bits and modification times of
+// the files. Symbolic links are archived as links.
+//
+// Returns error if a pattern is malformed, or if the tree cannot be read or
+// the archive cannot be written.
+func Create(w io.Writer, dir string, format Format, opts *CreateOptions) error {
+	var a archiver
+	switch format {
+	case Tar:
+		a = &tarArchiver{tw: tar.NewWriter(w)}
+	case TarGzip:
+		zw := gzip.NewWriter(w)
+		a = &tarArchiver{tw: tar.NewWriter(zw), zw: zw}
+	case Zip:
+		a = &zipArchiver{zw: zip.NewWriter(w)}
+	default:
+		return errors.New(nil, "cannot create archive: unknown format %d", int(format))
+	}
+	var wopts fsx.WalkOptions
+	if opts != nil {
+		wopts.Include, wopts.Exclude = opts.Include, opts.Exclude
+	}
+	err := fsx.Walk(dir, &wopts, func(path string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := add(a, path, filepath.ToSlash(rel), info); err != nil {
+			return errors.New(err, "cannot archive %s", path)
+		}
+		return nil
+	})
+	if cerr := a.close(); err == nil && cerr != nil {
+		err = errors.New(cerr, "cannot write archive")
+	}
+	if err != nil {
+		return errors.New(err, "cannot create archive of %s", dir)
+	}
+	return nil
+}
+
+// CreateFile creates a file at path holding an archive of the tree rooted at
+// dir, as by Create. The format is given by the extension of path: ".zip",
+// ".tar.gz" or ".tgz", or ".tar". Returns error if the extension is not one
+// of these. The file is removed if the archive cannot be created.
+func CreateFile(path, dir string, opts *CreateOptions) error {
+	format, err := formatOf(path)
+	if err != nil {
+		return errors.New(err, "cannot create %s", path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.New(err, "cannot create %s", path)
+	}
+	err = Create(f, dir, format, opts)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = errors.New(cerr, "cannot create %s", path)
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// archiver writes entries to an archive.
+type archiver interface {
+	// header begins an entry described by info, returning a writer for its
+	// content.
+	header(name, link string, info fs.FileInfo) (io.Writer, error)
+	close() error
+}
+
+// add adds the file at path, described by info, to a as name.
+func add(a archiver, path, name string, info fs.FileInfo) error {
+	var link string
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		link = target
+	case info.IsDir():
+		name += "/"
+	case !info.Mode().IsRegular():
+		// Devices, sockets and the like cannot be extracted portably.
+		return nil
+	}
+	w, err := a.header(name, link, info)
+	if err != nil || !info.Mode().IsRegular() {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+type tarArchiver struct {
+	tw *tar.Writer
+	zw *gzip.Writer // nil if uncompressed
+}
+
+func (a *tarArchiver) header(name, link string, info fs.FileInfo) (io.Writer, error) {
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return nil, err
+	}
+	hdr.Name = name
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	return a.tw, nil
+}
+
+func (a *tarArchiver) close() error {
+	err := a.tw.Close()
+	if a.zw != nil {
+		if zerr := a.zw.Close(); err == nil {
+			err = zerr
+		}
+	}
+	return err
+}
+
+type zipArchiver struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchiver) header(name, link string, info fs.FileInfo) (io.Writer, error) {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, err
+	}
+	hdr.Name = name
+	if info.Mode().IsRegular() {
+		hdr.Method = zip.Deflate
+	}
+	w, err := a.zw.CreateHeader(hdr)
+	if err != nil {
+		return nil, err
+	}
+	if link != "" {
+		// A zip archive holds the target of a symbolic link as its content.
+		if _, err := io.WriteString(w, link); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (a *zipArchiver) close() error {
+	return a.zw.Close()
+}
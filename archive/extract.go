@@ -0,0 +1,476 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/compress"
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/pathx"
+)
+
+// ExtractOptions configures Extract and ExtractFile. A nil *ExtractOptions is
+// equivalent to a zero ExtractOptions, in which every field takes its default.
+type ExtractOptions struct {
+	// MaxSize limits the total size in bytes of the files extracted, so that
+	// a small archive cannot fill a disk. Extraction stops once the limit is
+	// exceeded. It defaults to 1 GiB; a negative size sets no limit.
+	MaxSize int64
+
+	// MaxEntries limits the number of entries extracted. Extraction stops
+	// once the limit is exceeded. It defaults to 100000; a negative number
+	// sets no limit.
+	MaxEntries int
+
+	// Overwrite allows existing files to be replaced. Existing directories
+	// are always reused.
+	Overwrite bool
+}
+
+// Extract extracts the tar or zip archive read from src into the directory
+// dst, creating it if needed. The format is detected from the content of the
+// archive, and a tar archive may be compressed by any codec of package
+// compress, such as gzip or zstd. A zip archive is first copied to a temporary file,
+// as its directory lies at its end; ExtractFile avoids the copy.
+//
+// Files, directories, symbolic links and hard links are extracted, with the
+// permission bits and modification times recorded in the archive. Each entry
+// is written below dst: an entry whose name holds enough ".." elements to
+// leave dst, a link whose target lies outside dst, and an entry which would be
+// written through a symbolic link are rejected. Absolute names are taken
+// relative to dst. Other types of entry, such as devices, are rejected.
+//
+// Returns error listing every entry which could not be extracted, or if the
+// archive is malformed or exceeds the limits of opts, in which case extraction
+// stops.
+func Extract(src io.Reader, dst string, opts *ExtractOptions) error {
+	x := newExtractor(dst, opts)
+	br := bufio.NewReader(src)
+	head, _ := br.Peek(4)
+	if isZip(head) {
+		tmp, err := os.CreateTemp("", "archive-*.zip")
+		if err != nil {
+			return errors.New(err, "cannot extract archive")
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		var r io.Reader = br
+		if x.maxSize >= 0 {
+			r = io.LimitReader(br, x.maxSize+1)
+		}
+		n, err := io.Copy(tmp, r)
+		if err != nil {
+			return errors.New(err, "cannot extract archive")
+		}
+		if x.maxSize >= 0 && n > x.maxSize {
+			return errors.New(nil, "cannot extract archive: archive exceeds %d bytes", x.maxSize)
+		}
+		return x.zip(tmp, n)
+	}
+	r, err := compress.NewReader(br)
+	if err != nil {
+		return errors.New(err, "cannot extract archive")
+	}
+	defer r.Close()
+	return x.tar(r)
+}
+
+// ExtractFile extracts the archive in the file at path into dst, as by
+// Extract.
+func ExtractFile(path, dst string, opts *ExtractOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.New(err, "cannot extract %s", path)
+	}
+	defer f.Close()
+	head := make([]byte, 4)
+	n, _ := f.ReadAt(head, 0)
+	if isZip(head[:n]) {
+		info, err := f.Stat()
+		if err != nil {
+			return errors.New(err, "cannot extract %s", path)
+		}
+		if err := newExtractor(dst, opts).zip(f, info.Size()); err != nil {
+			return errors.New(err, "cannot extract %s", path)
+		}
+		return nil
+	}
+	if err := Extract(f, dst, opts); err != nil {
+		return errors.New(err, "cannot extract %s", path)
+	}
+	return nil
+}
+
+// extractor holds the state of a single extraction.
+type extractor struct {
+	dst        string
+	maxSize    int64
+	maxEntries int
+	overwrite  bool
+	size       int64
+	entries    int
+	errs       []error
+	dirs       []dirMode
+	links      []symlink
+}
+
+// symlink records an extracted symbolic link, whose target is checked again
+// once extraction is complete.
+type symlink struct {
+	name string
+	path string
+	link string
+}
+
+// dirMode records the mode of an extracted directory, which is set once its
+// contents are extracted, in case it forbids writing.
+type dirMode struct {
+	path    string
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// entry describes an entry of an archive.
+type entry struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+	link    string // target of a symbolic link, or name linked by a hard link
+	hard    bool
+	open    func() (io.ReadCloser, error)
+}
+
+func newExtractor(dst string, opts *ExtractOptions) *extractor {
+	x := &extractor{dst: dst, maxSize: 1 << 30, maxEntries: 100000}
+	if opts != nil {
+		if opts.MaxSize != 0 {
+			x.maxSize = opts.MaxSize
+		}
+		if opts.MaxEntries != 0 {
+			x.maxEntries = opts.MaxEntries
+		}
+		x.overwrite = opts.Overwrite
+	}
+	return x
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return x.finish(nil)
+		} else if err != nil {
+			return x.finish(errors.New(err, "malformed archive"))
+		}
+		e := entry{
+			name:    hdr.Name,
+			mode:    hdr.FileInfo().Mode(),
+			modTime: hdr.ModTime,
+			link:    hdr.Linkname,
+			open: func() (io.ReadCloser, error) {
+				return io.NopCloser(tr), nil
+			},
+		}
+		switch hdr.Typeflag {
+		case tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeLink:
+			e.hard = true
+		}
+		if err := x.extract(e); err != nil {
+			return x.finish(err)
+		}
+	}
+}
+
+func (x *extractor) zip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return x.finish(errors.New(err, "malformed archive"))
+	}
+	for _, f := range zr.File {
+		f := f
+		e := entry{
+			name:    f.Name,
+			mode:    f.Mode(),
+			modTime: f.Modified,
+			open:    f.Open,
+		}
+		if e.mode&fs.ModeSymlink != 0 {
+			target, err := readLink(f)
+			if err != nil {
+				x.errs = append(x.errs, errors.New(nil, "%s: %s", f.Name, err))
+				continue
+			}
+			e.link = target
+		}
+		if err := x.extract(e); err != nil {
+			return x.finish(err)
+		}
+	}
+	return x.finish(nil)
+}
+
+// readLink returns the target of the symbolic link f, held as its content.
+func readLink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(io.LimitReader(rc, 4096))
+	return string(target), err
+}
+
+// finish removes the symbolic links extracted whose targets lie outside dst,
+// sets the modes of the directories extracted, and returns error
+// listing the failed entries and err, which stopped extraction, if any.
+func (x *extractor) finish(err error) error {
+	// A link extracted later may redirect a link extracted earlier, so every
+	// link is checked again once all are in place.
+	for _, l := range x.links {
+		if link, err := os.Readlink(l.path); err != nil || link != l.link {
+			continue // replaced by a later entry
+		}
+		if lerr := x.checkLink(l.path, l.link); lerr != nil {
+			os.Remove(l.path)
+			x.errs = append(x.errs, errors.New(nil, "%s: %s", l.name, lerr))
+		}
+	}
+	for i := len(x.dirs) - 1; i >= 0; i-- {
+		d := x.dirs[i]
+		os.Chmod(d.path, d.mode)
+		os.Chtimes(d.path, d.modTime, d.modTime)
+	}
+	errs := x.errs
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errors.New(errors.Join(errs...), "cannot extract archive")
+	}
+	return nil
+}
+
+// extract extracts e, recording the failure of e, or returning error if
+// extraction must stop.
+func (x *extractor) extract(e entry) error {
+	x.entries++
+	if x.maxEntries >= 0 && x.entries > x.maxEntries {
+		return errors.New(nil, "archive exceeds %d entries", x.maxEntries)
+	}
+	err := x.write(e)
+	if err == errTooLarge {
+		return errors.New(nil, "archive exceeds %d bytes", x.maxSize)
+	} else if err != nil {
+		x.errs = append(x.errs, errors.New(nil, "%s: %s", e.name, err))
+	}
+	return nil
+}
+
+// errTooLarge reports that the size limit is exceeded.
+var errTooLarge = errors.New(nil, "size limit exceeded")
+
+func (x *extractor) write(e entry) error {
+	path, err := pathx.Join(x.dst, e.name)
+	if err != nil {
+		return err
+	}
+	if err := x.checkParents(path); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	perm := e.mode.Perm()
+	switch {
+	case e.mode.IsDir():
+		if err := os.Mkdir(path, 0o700|perm); err != nil && !isDir(path) {
+			return err
+		}
+		x.dirs = append(x.dirs, dirMode{path, perm, e.modTime})
+		return nil
+	case e.hard:
+		target, err := pathx.Join(x.dst, e.link)
+		if err != nil {
+			return errors.New(err, "invalid hard link")
+		}
+		if err := x.checkParents(target); err != nil {
+			return err
+		}
+		if err := x.clear(path); err != nil {
+			return err
+		}
+		return os.Link(target, path)
+	case e.mode&fs.ModeSymlink != 0:
+		if filepath.IsAbs(e.link) || strings.HasPrefix(e.link, "/") {
+			return errors.New(nil, "symbolic link to %s escapes %s", e.link, x.dst)
+		}
+		if err := x.checkLink(path, e.link); err != nil {
+			return err
+		}
+		if err := x.clear(path); err != nil {
+			return err
+		}
+		if err := os.Symlink(e.link, path); err != nil {
+			return err
+		}
+		x.links = append(x.links, symlink{e.name, path, e.link})
+		return nil
+	case e.mode.IsRegular():
+		return x.writeFile(path, e)
+	}
+	return errors.New(nil, "unsupported entry type %s", e.mode.Type())
+}
+
+func (x *extractor) writeFile(path string, e entry) error {
+	if err := x.clear(path); err != nil {
+		return err
+	}
+	rc, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	perm := e.mode.Perm()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = rc
+	if x.maxSize >= 0 {
+		r = io.LimitReader(rc, x.maxSize-x.size+1)
+	}
+	n, err := io.Copy(f, r)
+	x.size += n
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if x.maxSize >= 0 && x.size > x.maxSize {
+		os.Remove(path)
+		return errTooLarge
+	}
+	// The mode given to OpenFile is reduced by the umask.
+	os.Chmod(path, perm)
+	os.Chtimes(path, e.modTime, e.modTime)
+	return nil
+}
+
+// checkParents returns error if a directory between dst and path is a
+// symbolic link, through which path might lie outside dst.
+func (x *extractor) checkParents(path string) error {
+	rel, err := filepath.Rel(x.dst, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return err
+	}
+	dir := x.dst
+	for _, elem := range strings.Split(rel, string(filepath.Separator)) {
+		dir = filepath.Join(dir, elem)
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return errors.New(nil, "%s is a symbolic link", dir)
+		}
+	}
+	return nil
+}
+
+// checkLink returns error if the target of a symbolic link at path, as the
+// system would resolve it through the links already extracted, lies outside
+// dst.
+func (x *extractor) checkLink(path, link string) error {
+	root, err := filepath.EvalSymlinks(x.dst)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	target, err := resolve(dir, link, 0)
+	if err != nil {
+		return err
+	}
+	if !pathx.WithinRoot(root, target) {
+		return errors.New(nil, "symbolic link to %s escapes %s", link, x.dst)
+	}
+	return nil
+}
+
+// resolve returns the real path of target, relative to the real directory dir,
+// following symbolic links element by element, as the system would, so that
+// ".." after a link leaves the directory to which the link leads. Elements
+// which do not exist are joined as they are.
+func resolve(dir, target string, depth int) (string, error) {
+	if depth > 40 {
+		return "", errors.New(nil, "too many levels of symbolic links")
+	}
+	cur := dir
+	if filepath.IsAbs(target) {
+		cur = string(filepath.Separator)
+	}
+	elems := strings.Split(filepath.ToSlash(target), "/")
+	for i, elem := range elems {
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			cur = filepath.Dir(cur)
+			continue
+		}
+		next := filepath.Join(cur, elem)
+		info, err := os.Lstat(next)
+		if os.IsNotExist(err) {
+			return filepath.Join(append([]string{cur}, elems[i:]...)...), nil
+		} else if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			cur = next
+			continue
+		}
+		link, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if cur, err = resolve(cur, link, depth+1); err != nil {
+			return "", err
+		}
+	}
+	return cur, nil
+}
+
+// clear removes the file at path, if any and if allowed, so that a new file
+// may be created in its place without following a symbolic link.
+func (x *extractor) clear(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !x.overwrite || info.IsDir() {
+		return errors.New(nil, "%s already exists", path)
+	}
+	return os.Remove(path)
+}
+
+func isDir(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,43 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestExtractChainedSymlink checks that a symbolic link cannot escape dst by
+// way of another link extracted before or after it.
+func TestExtractChainedSymlink(t *testing.T) {
+	tests := [][][2]string{
+		{{"d", "."}, {"e", "d/../secret"}},
+		{{"e", "d/../secret"}, {"d", "."}},
+		{{"a/d", ".."}, {"a/e", "d/../secret"}},
+	}
+	for _, links := range tests {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, l := range links {
+			hdr := &tar.Header{Typeflag: tar.TypeSymlink, Name: l[0], Linkname: l[1], Mode: 0o777}
+			if err := tw.WriteHeader(hdr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		dst := filepath.Join(t.TempDir(), "dst")
+		if err := Extract(&buf, dst, nil); err == nil {
+			t.Errorf("%v: extracted without error", links)
+		}
+		for _, l := range links {
+			if l[1] == "d/../secret" {
+				if _, err := os.Lstat(filepath.Join(dst, l[0])); err == nil {
+					t.Errorf("%v: escaping link %s extracted", links, l[0])
+				}
+			}
+		}
+	}
+}
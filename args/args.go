@@ -0,0 +1,471 @@
+// Package args implements command-line argument parsing.
+//
+// A program's command line is described declaratively by a tree of Commands.
+// Each Command accepts flags, positional arguments and, optionally, further
+// subcommands, and binds the values given on the command line to variables
+// through the Value interface:
+//
+//	var verbose bool
+//	var timeout time.Duration
+//	var files []string
+//	cmd := &args.Command{
+//		Name:    "fetch",
+//		Summary: "download files",
+//		Flags: []*args.Flag{
+//			{Long: "verbose", Short: 'v', Usage: "log progress", Value: args.Bool(&verbose)},
+//			{Long: "timeout", Usage: "request timeout", Value: args.Duration(&timeout),
+//				Default: "30s", Env: "FETCH_TIMEOUT"},
+//		},
+//		Args: []*args.Arg{
+//			{Name: "url", Usage: "files to fetch", Value: args.Strings(&files)},
+//		},
+//		Run: func(cmd *args.Command, rest []string) error {
+//			...
+//		},
+//	}
+//	if err := cmd.Execute(os.Args[1:]); err != nil {
+//		...
+//	}
+//
+// Flags are given in long form, as --name value or --name=value, or in short
+// form, as -n value or -nvalue. Short boolean flags may be combined, as in
+// -abc. Flags and positional arguments may be freely interleaved, and the
+// argument -- ends flag parsing. Flags of a command are also accepted by all
+// of its subcommands.
+//
+// A flag not given on the command line takes its value from the environment
+// variable named by its Env field, if set, and otherwise from its Default.
+//
+// Every command accepts -h and --help, which cause usage text generated from
+// the command's description to be written to its output.
+package args
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/width"
+)
+
+// ErrHelp is returned by Parse and Execute when help is requested with -h or
+// --help.
+var ErrHelp = errors.New(nil, "help requested")
+
+// Command describes a command or subcommand.
+type Command struct {
+	// Name is the name of the command, as typed on the command line.
+	Name string
+
+	// Summary is a one-line description of the command, shown in the usage
+	// text of the command and its parent.
+	Summary string
+
+	// Description is a longer description of the command, shown in its usage
+	// text.
+	Description string
+
+	// Flags are the flags accepted by the command and its subcommands.
+	Flags []*Flag
+
+	// Args are the positional arguments accepted by the command. Only the
+	// last argument may be repeatable.
+	Args []*Arg
+
+	// Commands are the subcommands of the command. If a command has
+	// subcommands, its first positional argument must name one of them.
+	Commands []*Command
+
+	// Run is called by Execute if the command is selected. It is given the
+	// selected command, and any positional arguments not bound to Args. If
+	// Args is not empty, rest is always empty.
+	Run func(cmd *Command, rest []string) error
+
+	// Output is where usage text is written. If Output is nil, the output of
+	// the parent command is used, or os.Stdout for a top-level command.
+	Output io.Writer
+
+	parent *Command
+}
+
+// Flag describes a flag.
+type Flag struct {
+	// Long is the long name of the flag, used as --name. Either Long or Short
+	// must be set.
+	Long string
+
+	// Short is the single-character short name of the flag, used as -n.
+	Short rune
+
+	// Usage is a short description of the flag.
+	Usage string
+
+	// Value receives the value of the flag.
+	Value Value
+
+	// Default, if not empty, is passed to Value.Set if the flag is not given
+	// on the command line or by the environment.
+	Default string
+
+	// Env, if not empty, names an environment variable whose value is passed
+	// to Value.Set if the flag is not given on the command line.
+	Env string
+
+	// Required causes parsing to fail if the flag is not given on the
+	// command line, by the environment, or by Default.
+	Required bool
+
+	set bool
+}
+
+// Arg describes a positional argument.
+type Arg struct {
+	// Name is the name of the argument, as shown in usage text.
+	Name string
+
+	// Usage is a short description of the argument.
+	Usage string
+
+	// Value receives the value of the argument. If Value is repeatable, the
+	// argument consumes all remaining positional arguments.
+	Value Value
+
+	// Optional allows the argument to be omitted.
+	Optional bool
+}
+
+// Execute parses args, which should not include the program name, and calls
+// the Run function of the selected command. Returns error if args are invalid,
+// if the selected command has no Run function, or if Run returns error. If help
+// is requested, the usage text of the selected command is written to its output
+// and ErrHelp is returned.
+func (c *Command) Execute(args []string) error {
+	cmd, rest, err := c.Parse(args)
+	if err != nil {
+		return err
+	}
+	if cmd.Run == nil {
+		return errors.New(nil, "%s: missing command", cmd.Path())
+	}
+	return cmd.Run(cmd, rest)
+}
+
+// Parse parses args, which should not include the program name, and sets the
+// values of the flags and positional arguments given. It returns the selected
+// command, which is c or one of its descendants, and any positional arguments
+// not bound to its Args.
+func (c *Command) Parse(args []string) (*Command, []string, error) {
+	c.parent = nil
+	cmd, rest, err := c.parse(args)
+	if err != nil {
+		if errors.Is(err, ErrHelp) {
+			cmd.PrintUsage()
+			return cmd, nil, errors.Raise(ErrHelp)
+		}
+		return cmd, nil, errors.New(err, "%s", cmd.Path())
+	}
+	return cmd, rest, nil
+}
+
+// Path returns the names of c and its ancestors, separated by spaces, as they
+// would be typed on the command line. The ancestors of a command are only
+// known once it has been selected by Parse.
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// PrintUsage writes the usage text of c to its output.
+func (c *Command) PrintUsage() {
+	io.WriteString(c.output(), c.Usage())
+}
+
+// Usage returns the usage text of c.
+func (c *Command) Usage() string {
+	var sb strings.Builder
+	sb.WriteString("Usage: " + c.Path())
+	if len(c.flags()) > 0 {
+		sb.WriteString(" [flags]")
+	}
+	if len(c.Commands) > 0 {
+		sb.WriteString(" <command>")
+	}
+	for _, a := range c.Args {
+		name := "<" + a.Name + ">"
+		if isRepeated(a.Value) {
+			name += "..."
+		}
+		if a.Optional {
+			name = "[" + name + "]"
+		}
+		sb.WriteString(" " + name)
+	}
+	sb.WriteString("\n")
+	if c.Summary != "" {
+		sb.WriteString("\n" + c.Summary + "\n")
+	}
+	if c.Description != "" {
+		sb.WriteString("\n" + strings.TrimSpace(c.Description) + "\n")
+	}
+	if len(c.Commands) > 0 {
+		var rows [][2]string
+		for _, sub := range c.Commands {
+			rows = append(rows, [2]string{sub.Name, sub.Summary})
+		}
+		sb.WriteString("\nCommands:\n" + columns(rows))
+	}
+	if len(c.Args) > 0 {
+		var rows [][2]string
+		for _, a := range c.Args {
+			rows = append(rows, [2]string{a.Name, a.Usage})
+		}
+		sb.WriteString("\nArguments:\n" + columns(rows))
+	}
+	var rows [][2]string
+	for _, f := range c.flags() {
+		rows = append(rows, [2]string{f.synopsis(), f.description()})
+	}
+	rows = append(rows, [2]string{"-h, --help", "show this help"})
+	sb.WriteString("\nFlags:\n" + columns(rows))
+	return sb.String()
+}
+
+// flags returns the flags accepted by c, including those of its ancestors.
+func (c *Command) flags() []*Flag {
+	var flags []*Flag
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		flags = append(flags, cmd.Flags...)
+	}
+	return flags
+}
+
+func (c *Command) lookup(match func(*Flag) bool) *Flag {
+	for _, f := range c.flags() {
+		if match(f) {
+			return f
+		}
+	}
+	return nil
+}
+
+func (c *Command) output() io.Writer {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.Output != nil {
+			return cmd.Output
+		}
+	}
+	return os.Stdout
+}
+
+func (c *Command) parse(args []string) (*Command, []string, error) {
+	for _, f := range c.Flags {
+		f.set = false
+	}
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "--"):
+			name, val, hasVal := strings.Cut(arg[2:], "=")
+			f := c.lookup(func(f *Flag) bool {
+				return f.Long != "" && f.Long == name
+			})
+			if f == nil && name == "help" {
+				return c, nil, ErrHelp
+			}
+			if f == nil {
+				return c, nil, errors.New(nil, "unknown flag --%s", name)
+			}
+			if !hasVal && isBool(f.Value) {
+				val, hasVal = "true", true
+			}
+			if !hasVal {
+				if i+1 == len(args) {
+					return c, nil, errors.New(nil, "flag --%s requires a value", name)
+				}
+				i++
+				val = args[i]
+			}
+			if err := f.setValue(val); err != nil {
+				return c, nil, err
+			}
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			shorts := []rune(arg[1:])
+			for j, r := range shorts {
+				f := c.lookup(func(f *Flag) bool {
+					return f.Short != 0 && f.Short == r
+				})
+				if f == nil && r == 'h' {
+					return c, nil, ErrHelp
+				}
+				if f == nil {
+					return c, nil, errors.New(nil, "unknown flag -%c", r)
+				}
+				if isBool(f.Value) {
+					if err := f.setValue("true"); err != nil {
+						return c, nil, err
+					}
+					continue
+				}
+				val := string(shorts[j+1:])
+				if val == "" {
+					if i+1 == len(args) {
+						return c, nil, errors.New(nil, "flag -%c requires a value", r)
+					}
+					i++
+					val = args[i]
+				}
+				if err := f.setValue(val); err != nil {
+					return c, nil, err
+				}
+				break
+			}
+		case len(positional) == 0 && len(c.Commands) > 0:
+			for _, sub := range c.Commands {
+				if sub.Name == arg {
+					sub.parent = c
+					return sub.parse(args[i+1:])
+				}
+			}
+			return c, nil, errors.New(nil, "unknown command %q", arg)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(c.Commands) > 0 && c.Run == nil {
+		return c, nil, errors.New(nil, "missing command")
+	}
+	if err := c.resolve(); err != nil {
+		return c, nil, err
+	}
+	for i, a := range c.Args {
+		switch {
+		case isRepeated(a.Value) && i == len(c.Args)-1:
+			if len(positional) == 0 && !a.Optional {
+				return c, nil, errors.New(nil, "missing argument <%s>", a.Name)
+			}
+			for _, p := range positional {
+				if err := setArg(a, p); err != nil {
+					return c, nil, err
+				}
+			}
+			positional = nil
+		case len(positional) > 0:
+			if err := setArg(a, positional[0]); err != nil {
+				return c, nil, err
+			}
+			positional = positional[1:]
+		case !a.Optional:
+			return c, nil, errors.New(nil, "missing argument <%s>", a.Name)
+		}
+	}
+	if len(c.Args) > 0 && len(positional) > 0 {
+		return c, nil, errors.New(nil, "unexpected argument %q", positional[0])
+	}
+	return c, positional, nil
+}
+
+// resolve sets flags not given on the command line from the environment or
+// their defaults, and checks that every required flag has been set.
+func (c *Command) resolve() error {
+	for _, f := range c.flags() {
+		if f.set {
+			continue
+		}
+		if f.Env != "" {
+			if val, ok := os.LookupEnv(f.Env); ok {
+				if err := f.setValue(val); err != nil {
+					return errors.New(err, "from $%s", f.Env)
+				}
+				continue
+			}
+		}
+		if f.Default != "" {
+			if err := f.Value.Set(f.Default); err != nil {
+				return errors.New(err, "invalid default %q for flag %s", f.Default, f.name())
+			}
+			continue
+		}
+		if f.Required {
+			return errors.New(nil, "missing required flag %s", f.name())
+		}
+	}
+	return nil
+}
+
+func setArg(a *Arg, val string) error {
+	if err := a.Value.Set(val); err != nil {
+		return errors.New(err, "invalid value %q for argument <%s>", val, a.Name)
+	}
+	return nil
+}
+
+func (f *Flag) description() string {
+	desc := f.Usage
+	if f.Default != "" {
+		desc += fmt.Sprintf(" (default %s)", f.Default)
+	}
+	if f.Env != "" {
+		desc += fmt.Sprintf(" [$%s]", f.Env)
+	}
+	if f.Required {
+		desc += " (required)"
+	}
+	return strings.TrimSpace(desc)
+}
+
+func (f *Flag) name() string {
+	if f.Long != "" {
+		return "--" + f.Long
+	}
+	return "-" + string(f.Short)
+}
+
+func (f *Flag) setValue(val string) error {
+	if err := f.Value.Set(val); err != nil {
+		return errors.New(err, "invalid value %q for flag %s", val, f.name())
+	}
+	f.set = true
+	return nil
+}
+
+func (f *Flag) synopsis() string {
+	var s string
+	switch {
+	case f.Short != 0 && f.Long != "":
+		s = fmt.Sprintf("-%c, --%s", f.Short, f.Long)
+	case f.Short != 0:
+		s = fmt.Sprintf("-%c", f.Short)
+	default:
+		s = "    --" + f.Long
+	}
+	if t := typeName(f.Value); t != "" {
+		s += " " + t
+	}
+	return s
+}
+
+func columns(rows [][2]string) string {
+	w := 0
+	for _, row := range rows {
+		if n := width.String(row[0]); n > w {
+			w = n
+		}
+	}
+	var sb strings.Builder
+	for _, row := range rows {
+		line := "  " + row[0]
+		if row[1] != "" {
+			line += strings.Repeat(" ", w-width.String(row[0])+2) + row[1]
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
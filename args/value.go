@@ -0,0 +1,210 @@
+package args
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/glob"
+)
+
+// Value is the interface to the value bound to a flag or positional argument.
+// Set is called with each textual value given on the command line, and String
+// returns the current value in textual form.
+//
+// A Value may also implement the following methods to change how it is parsed
+// and described:
+//
+//	IsBool() bool      // the flag takes no value, and is set to "true"
+//	IsRepeated() bool  // the flag may be given more than once
+//	Type() string      // the name of the value's type in usage text
+type Value interface {
+	Set(string) error
+	String() string
+}
+
+func isBool(v Value) bool {
+	b, ok := v.(interface{ IsBool() bool })
+	return ok && b.IsBool()
+}
+
+func isRepeated(v Value) bool {
+	r, ok := v.(interface{ IsRepeated() bool })
+	return ok && r.IsRepeated()
+}
+
+func typeName(v Value) string {
+	if t, ok := v.(interface{ Type() string }); ok {
+		return t.Type()
+	}
+	return "value"
+}
+
+// Bool returns a Value which stores a boolean in p. A boolean flag takes no
+// value on the command line, but may be given one explicitly, as in
+// --flag=false.
+func Bool(p *bool) Value {
+	return &boolValue{p}
+}
+
+type boolValue struct{ p *bool }
+
+func (v *boolValue) IsBool() bool   { return true }
+func (v *boolValue) String() string { return strconv.FormatBool(*v.p) }
+func (v *boolValue) Type() string   { return "" }
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New(nil, "expected boolean")
+	}
+	*v.p = b
+	return nil
+}
+
+// Duration returns a Value which stores a duration in p, parsed using
+// time.ParseDuration.
+func Duration(p *time.Duration) Value {
+	return &durationValue{p}
+}
+
+type durationValue struct{ p *time.Duration }
+
+func (v *durationValue) String() string { return v.p.String() }
+func (v *durationValue) Type() string   { return "duration" }
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New(nil, "expected duration")
+	}
+	*v.p = d
+	return nil
+}
+
+// Enum returns a Value which stores a string in p, and accepts only the given
+// choices.
+func Enum(p *string, choices ...string) Value {
+	return &enumValue{p, choices}
+}
+
+type enumValue struct {
+	p       *string
+	choices []string
+}
+
+func (v *enumValue) String() string { return *v.p }
+func (v *enumValue) Type() string   { return strings.Join(v.choices, "|") }
+
+func (v *enumValue) Set(s string) error {
+	for _, c := range v.choices {
+		if s == c {
+			*v.p = s
+			return nil
+		}
+	}
+	return errors.New(nil, "expected one of %s", strings.Join(v.choices, ", "))
+}
+
+// Files returns a repeatable Value which appends to the slice pointed to by p
+// the names of the files matching each value given, as selected by glob.Files,
+// so that patterns such as "src/**/*.go" may be given even where the shell
+// does not expand them. A value which is not a pattern is appended as given,
+// whether or not the file exists. Returns error if a pattern is malformed or
+// matches no files.
+func Files(p *[]string) Value {
+	return &filesValue{p}
+}
+
+type filesValue struct{ p *[]string }
+
+func (v *filesValue) IsRepeated() bool { return true }
+func (v *filesValue) String() string   { return fmt.Sprint(*v.p) }
+func (v *filesValue) Type() string     { return "file" }
+
+func (v *filesValue) Set(s string) error {
+	if !glob.HasMeta(filepath.ToSlash(s)) {
+		*v.p = append(*v.p, s)
+		return nil
+	}
+	names, err := glob.Files(s)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return errors.New(nil, "no files match %q", s)
+	}
+	*v.p = append(*v.p, names...)
+	return nil
+}
+
+// Float returns a Value which stores a floating-point number in p.
+func Float(p *float64) Value {
+	return &floatValue{p}
+}
+
+type floatValue struct{ p *float64 }
+
+func (v *floatValue) String() string { return strconv.FormatFloat(*v.p, 'g', -1, 64) }
+func (v *floatValue) Type() string   { return "float" }
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return errors.New(nil, "expected number")
+	}
+	*v.p = f
+	return nil
+}
+
+// Int returns a Value which stores an integer in p. Integers may be given in
+// decimal, or in binary, octal or hexadecimal with a 0b, 0o or 0x prefix.
+func Int(p *int) Value {
+	return &intValue{p}
+}
+
+type intValue struct{ p *int }
+
+func (v *intValue) String() string { return strconv.Itoa(*v.p) }
+func (v *intValue) Type() string   { return "int" }
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	if err != nil {
+		return errors.New(nil, "expected integer")
+	}
+	*v.p = int(n)
+	return nil
+}
+
+// String returns a Value which stores a string in p.
+func String(p *string) Value {
+	return &stringValue{p}
+}
+
+type stringValue struct{ p *string }
+
+func (v *stringValue) Set(s string) error { *v.p = s; return nil }
+func (v *stringValue) String() string     { return *v.p }
+func (v *stringValue) Type() string       { return "string" }
+
+// Strings returns a repeatable Value which appends each value given to the
+// slice pointed to by p. As a positional argument, a repeatable Value consumes
+// all remaining arguments, and so must be the last argument of a command.
+func Strings(p *[]string) Value {
+	return &stringsValue{p}
+}
+
+type stringsValue struct{ p *[]string }
+
+func (v *stringsValue) IsRepeated() bool { return true }
+func (v *stringsValue) String() string   { return fmt.Sprint(*v.p) }
+func (v *stringsValue) Type() string     { return "string" }
+
+func (v *stringsValue) Set(s string) error {
+	*v.p = append(*v.p, s)
+	return nil
+}
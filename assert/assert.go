@@ -0,0 +1,170 @@
+// Package assert implements assertion helpers for tests.
+//
+// Each assertion reports a failure with t.Errorf and returns whether it held,
+// so that a test can continue past a failed assertion, or guard later checks:
+//
+//	func TestParse(t *testing.T) {
+//		got, err := Parse("1 + 2")
+//		if !assert.Nil(t, err) {
+//			return
+//		}
+//		assert.Equal(t, got, &Expr{Op: '+', Args: []int{1, 2}})
+//	}
+//
+// Package require provides the same assertions, but ends the test at the first
+// failure.
+//
+// When Equal fails, the compared values are formatted with one field or element
+// per line, and the failure shows a unified diff from the wanted value to the
+// one received. Multi-line strings are compared line by line.
+//
+// When an assertion fails on an error created by package errors, the failure
+// includes the error's trace, pointing to where the error was raised.
+//
+// Every assertion accepts an optional message. If the first argument of the
+// message is a string, it is used as a format for the remaining arguments.
+package assert
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~kvo/go-std/diff"
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Equal asserts that got and want are deeply equal, as by reflect.DeepEqual.
+func Equal[T any](t testing.TB, got, want T, msg ...any) bool {
+	t.Helper()
+	if reflect.DeepEqual(got, want) {
+		return true
+	}
+	fail(t, "not equal"+difference(got, want), msg)
+	return false
+}
+
+// ErrorHas asserts that err or any of its parents matches target, as by
+// errors.Has. If target is nil, ErrorHas asserts that err is nil.
+func ErrorHas(t testing.TB, err, target error, msg ...any) bool {
+	t.Helper()
+	if target == nil {
+		if err == nil {
+			return true
+		}
+		fail(t, fmt.Sprintf("unexpected error: %s", err)+trace(err), msg)
+		return false
+	}
+	if err != nil && errors.Has(err, target) {
+		return true
+	}
+	if err == nil {
+		fail(t, fmt.Sprintf("error is nil, want %q", target.Error()), msg)
+	} else {
+		fail(t, fmt.Sprintf("error %q does not have %q", err, target)+trace(err), msg)
+	}
+	return false
+}
+
+// Nil asserts that v is nil, or is a nil pointer, slice, map, channel,
+// function, or interface.
+func Nil(t testing.TB, v any, msg ...any) bool {
+	t.Helper()
+	if isNil(v) {
+		return true
+	}
+	text := "not nil: " + format(v)
+	if err, ok := v.(error); ok {
+		text = fmt.Sprintf("unexpected error: %s", err) + trace(err)
+	}
+	fail(t, text, msg)
+	return false
+}
+
+// NotEqual asserts that got and want are not deeply equal, as by
+// reflect.DeepEqual.
+func NotEqual[T any](t testing.TB, got, want T, msg ...any) bool {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		return true
+	}
+	fail(t, "unexpectedly equal: "+format(got), msg)
+	return false
+}
+
+// NotNil asserts that v is neither nil nor a nil pointer, slice, map, channel,
+// function, or interface.
+func NotNil(t testing.TB, v any, msg ...any) bool {
+	t.Helper()
+	if !isNil(v) {
+		return true
+	}
+	fail(t, "unexpectedly nil", msg)
+	return false
+}
+
+// Panics asserts that f panics when called.
+func Panics(t testing.TB, f func(), msg ...any) (ok bool) {
+	t.Helper()
+	defer func() {
+		if recover() != nil {
+			ok = true
+		}
+	}()
+	f()
+	fail(t, "function did not panic", msg)
+	return false
+}
+
+// difference returns the difference between got and want, beginning with a
+// newline.
+func difference(got, want any) string {
+	gs, gok := got.(string)
+	ws, wok := want.(string)
+	if !gok || !wok || !strings.Contains(gs, "\n") && !strings.Contains(ws, "\n") {
+		gf, wf := format(got), format(want)
+		if !strings.Contains(gf, "\n") && !strings.Contains(wf, "\n") || gf == wf {
+			return fmt.Sprintf("\n got: %s\nwant: %s", gf, wf)
+		}
+		gs, ws = gf+"\n", wf+"\n"
+	}
+	return "\n" + strings.TrimSuffix(diff.Unified("want", "got", ws, gs, 3), "\n")
+}
+
+func fail(t testing.TB, text string, msg []any) {
+	t.Helper()
+	if len(msg) > 0 {
+		var m string
+		if format, ok := msg[0].(string); ok {
+			m = fmt.Sprintf(format, msg[1:]...)
+		} else {
+			m = fmt.Sprint(msg...)
+		}
+		text = m + ": " + text
+	}
+	t.Errorf("%s", text)
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// trace returns the trace of err, beginning with a newline, if err was created
+// by package errors, and otherwise returns the empty string.
+func trace(err error) string {
+	if _, ok := err.(errors.Error); !ok {
+		return ""
+	}
+	var sb strings.Builder
+	errors.Trace(&sb, err)
+	return "\n" + strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,123 @@
+package assert
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxDepth limits the nesting of formatted values, guarding against cycles.
+const maxDepth = 16
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// format returns a representation of v resembling Go syntax, with one element
+// or field per line, so that values can be compared line by line.
+func format(v any) string {
+	var sb strings.Builder
+	formatValue(&sb, reflect.ValueOf(v), 0)
+	return sb.String()
+}
+
+func formatValue(sb *strings.Builder, v reflect.Value, depth int) {
+	if !v.IsValid() {
+		sb.WriteString("nil")
+		return
+	}
+	if depth > maxDepth {
+		sb.WriteString("...")
+		return
+	}
+	indent := strings.Repeat("\t", depth+1)
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			fmt.Fprintf(sb, "(%s)(nil)", v.Type())
+			return
+		}
+		sb.WriteByte('&')
+		formatValue(sb, v.Elem(), depth)
+	case reflect.Interface:
+		formatValue(sb, v.Elem(), depth)
+	case reflect.Struct:
+		if v.Type().Implements(stringerType) && v.CanInterface() {
+			fmt.Fprintf(sb, "%s(%q)", v.Type(), v.Interface().(fmt.Stringer).String())
+			return
+		}
+		if v.NumField() == 0 {
+			fmt.Fprintf(sb, "%s{}", v.Type())
+			return
+		}
+		fmt.Fprintf(sb, "%s{\n", v.Type())
+		for i := 0; i < v.NumField(); i++ {
+			fmt.Fprintf(sb, "%s%s: ", indent, v.Type().Field(i).Name)
+			formatValue(sb, v.Field(i), depth+1)
+			sb.WriteString(",\n")
+		}
+		sb.WriteString(indent[1:] + "}")
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			fmt.Fprintf(sb, "%s(nil)", v.Type())
+			return
+		}
+		if v.Len() == 0 {
+			fmt.Fprintf(sb, "%s{}", v.Type())
+			return
+		}
+		fmt.Fprintf(sb, "%s{\n", v.Type())
+		for i := 0; i < v.Len(); i++ {
+			sb.WriteString(indent)
+			formatValue(sb, v.Index(i), depth+1)
+			sb.WriteString(",\n")
+		}
+		sb.WriteString(indent[1:] + "}")
+	case reflect.Map:
+		if v.IsNil() {
+			fmt.Fprintf(sb, "%s(nil)", v.Type())
+			return
+		}
+		if v.Len() == 0 {
+			fmt.Fprintf(sb, "%s{}", v.Type())
+			return
+		}
+		type entry struct{ key, value string }
+		var entries []entry
+		iter := v.MapRange()
+		for iter.Next() {
+			var k, e strings.Builder
+			formatValue(&k, iter.Key(), depth+1)
+			formatValue(&e, iter.Value(), depth+1)
+			entries = append(entries, entry{k.String(), e.String()})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+		fmt.Fprintf(sb, "%s{\n", v.Type())
+		for _, e := range entries {
+			fmt.Fprintf(sb, "%s%s: %s,\n", indent, e.key, e.value)
+		}
+		sb.WriteString(indent[1:] + "}")
+	case reflect.String:
+		sb.WriteString(strconv.Quote(v.String()))
+	case reflect.Bool:
+		sb.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sb.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		sb.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		sb.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		sb.WriteString(strconv.FormatComplex(v.Complex(), 'g', -1, v.Type().Bits()))
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if v.IsNil() {
+			fmt.Fprintf(sb, "(%s)(nil)", v.Type())
+		} else {
+			fmt.Fprintf(sb, "(%s)(%#x)", v.Type(), v.Pointer())
+		}
+	default:
+		fmt.Fprintf(sb, "<%s>", v.Type())
+	}
+}
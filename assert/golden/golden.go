@@ -0,0 +1,90 @@
+// Package golden implements golden file testing.
+//
+// A golden file holds the expected output of a test. Equal compares output
+// against the golden file of the given name, stored as testdata/name.golden
+// relative to the package being tested, and reports a unified diff on
+// mismatch:
+//
+//	func TestHelp(t *testing.T) {
+//		var buf bytes.Buffer
+//		cmd.Output = &buf
+//		cmd.PrintUsage()
+//		golden.Equal(t, "help", buf.Bytes())
+//	}
+//
+// Running the tests with the -update flag writes the output to the golden
+// files instead of comparing against them:
+//
+//	go test ./... -update
+//
+// Line endings are normalised before comparison, so that golden files checked
+// out with CRLF line endings still match output using LF line endings.
+//
+// Importing this package defines the -update flag for the test binary, so a
+// test package importing it must not define a flag of the same name.
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~kvo/go-std/diff"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// Equal asserts that got matches the contents of the golden file called name,
+// reporting a failure with t.Errorf if not. If the -update flag is set, Equal
+// instead writes got to the golden file, creating it if necessary. Returns
+// whether got matched, or was written successfully.
+func Equal(t testing.TB, name string, got []byte) bool {
+	t.Helper()
+	path := Path(name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Errorf("cannot update golden file: %s", err)
+			return false
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Errorf("cannot update golden file: %s", err)
+			return false
+		}
+		t.Logf("updated golden file %s", path)
+		return true
+	}
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Errorf("golden file %s does not exist; run with -update to create it", path)
+		return false
+	} else if err != nil {
+		t.Errorf("cannot read golden file: %s", err)
+		return false
+	}
+	g, w := normalize(got), normalize(want)
+	if g == w {
+		return true
+	}
+	t.Errorf("output does not match golden file; run with -update to update it\n%s",
+		strings.TrimSuffix(diff.Unified(path, "got", w, g, 3), "\n"),
+	)
+	return false
+}
+
+// EqualString is like Equal, but takes the output as a string.
+func EqualString(t testing.TB, name string, got string) bool {
+	t.Helper()
+	return Equal(t, name, []byte(got))
+}
+
+// Path returns the path of the golden file called name.
+func Path(name string) string {
+	return filepath.Join("testdata", filepath.FromSlash(name)+".golden")
+}
+
+func normalize(b []byte) string {
+	return string(bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n")))
+}
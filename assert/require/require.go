@@ -0,0 +1,63 @@
+// Package require implements assertion helpers for tests which end the test at
+// the first failure.
+//
+// Each function performs the assertion of the same name in package assert, and
+// if it fails, calls t.FailNow. As with t.FailNow, they must be called from the
+// goroutine running the test.
+package require
+
+import (
+	"testing"
+
+	"git.sr.ht/~kvo/go-std/assert"
+)
+
+// Equal is like assert.Equal, but ends the test if got and want are not equal.
+func Equal[T any](t testing.TB, got, want T, msg ...any) {
+	t.Helper()
+	if !assert.Equal(t, got, want, msg...) {
+		t.FailNow()
+	}
+}
+
+// ErrorHas is like assert.ErrorHas, but ends the test if err does not have
+// target.
+func ErrorHas(t testing.TB, err, target error, msg ...any) {
+	t.Helper()
+	if !assert.ErrorHas(t, err, target, msg...) {
+		t.FailNow()
+	}
+}
+
+// Nil is like assert.Nil, but ends the test if v is not nil.
+func Nil(t testing.TB, v any, msg ...any) {
+	t.Helper()
+	if !assert.Nil(t, v, msg...) {
+		t.FailNow()
+	}
+}
+
+// NotEqual is like assert.NotEqual, but ends the test if got and want are
+// equal.
+func NotEqual[T any](t testing.TB, got, want T, msg ...any) {
+	t.Helper()
+	if !assert.NotEqual(t, got, want, msg...) {
+		t.FailNow()
+	}
+}
+
+// NotNil is like assert.NotNil, but ends the test if v is nil.
+func NotNil(t testing.TB, v any, msg ...any) {
+	t.Helper()
+	if !assert.NotNil(t, v, msg...) {
+		t.FailNow()
+	}
+}
+
+// Panics is like assert.Panics, but ends the test if f does not panic.
+func Panics(t testing.TB, f func(), msg ...any) {
+	t.Helper()
+	if !assert.Panics(t, f, msg...) {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,194 @@
+// Package atomicx implements typed atomic values, counters and gauges.
+//
+// Value holds a value of any type, and Atomic holds a value of a comparable
+// type, adding CompareAndSwap. Both are lock-free, storing each value behind a
+// pointer which is swapped atomically:
+//
+//	var cfg atomicx.Value[Config]
+//	cfg.Store(load())
+//	...
+//	timeout := cfg.Load().Timeout
+//
+// Counter counts events, and Max and Min track the extremes of observed
+// values:
+//
+//	var requests atomicx.Counter
+//	var latency atomicx.Max[time.Duration]
+//
+//	requests.Inc()
+//	latency.Observe(time.Since(start))
+//
+// The zero value of each type is ready to use. Values must not be copied after
+// first use.
+package atomicx
+
+import (
+	"sync/atomic"
+
+	"git.sr.ht/~kvo/go-std"
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Value holds a value of type T which is loaded and stored atomically.
+type Value[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// Load returns the value of v, or the zero value of T if none was stored.
+func (v *Value[T]) Load() T {
+	if p := v.p.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Store sets the value of v to val.
+func (v *Value[T]) Store(val T) {
+	v.p.Store(&val)
+}
+
+// Swap sets the value of v to val, and returns the previous value.
+func (v *Value[T]) Swap(val T) T {
+	if p := v.p.Swap(&val); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Update sets the value of v to the result of calling f with its current
+// value, and returns the new value. If v is changed concurrently, f is called
+// again with the newer value, so f should have no side effects.
+func (v *Value[T]) Update(f func(T) T) T {
+	for {
+		p := v.p.Load()
+		var old T
+		if p != nil {
+			old = *p
+		}
+		val := f(old)
+		if v.p.CompareAndSwap(p, &val) {
+			return val
+		}
+	}
+}
+
+// Atomic holds a value of comparable type T which is loaded, stored, and
+// compared and swapped atomically.
+type Atomic[T comparable] struct {
+	Value[T]
+}
+
+// CompareAndSwap sets the value of a to new if it is equal to old, and reports
+// whether it did so.
+func (a *Atomic[T]) CompareAndSwap(old, new T) bool {
+	for {
+		p := a.p.Load()
+		var cur T
+		if p != nil {
+			cur = *p
+		}
+		if cur != old {
+			return false
+		}
+		if a.p.CompareAndSwap(p, &new) {
+			return true
+		}
+	}
+}
+
+// Counter is a counter which is incremented and decremented atomically.
+type Counter struct {
+	n atomic.Int64
+}
+
+// Add adds delta to c, and returns the new count.
+func (c *Counter) Add(delta int64) int64 {
+	return c.n.Add(delta)
+}
+
+// Dec decrements c, and returns the new count.
+func (c *Counter) Dec() int64 {
+	return c.n.Add(-1)
+}
+
+// Inc increments c, and returns the new count.
+func (c *Counter) Inc() int64 {
+	return c.n.Add(1)
+}
+
+// Load returns the count of c.
+func (c *Counter) Load() int64 {
+	return c.n.Load()
+}
+
+// Reset sets the count of c to zero, and returns the previous count.
+func (c *Counter) Reset() int64 {
+	return c.n.Swap(0)
+}
+
+// Max tracks the greatest value observed.
+type Max[T std.Ordered] struct {
+	v Value[T]
+}
+
+// Load returns the greatest value observed. Returns error if no value has been
+// observed since m was created or reset.
+func (m *Max[T]) Load() (T, error) {
+	return load(&m.v)
+}
+
+// Observe records val, and reports whether it is the greatest value observed.
+func (m *Max[T]) Observe(val T) bool {
+	return observe(&m.v, val, func(a, b T) bool { return a > b })
+}
+
+// Reset discards every value observed.
+func (m *Max[T]) Reset() {
+	m.v.p.Store(nil)
+}
+
+// Min tracks the least value observed.
+type Min[T std.Ordered] struct {
+	v Value[T]
+}
+
+// Load returns the least value observed. Returns error if no value has been
+// observed since m was created or reset.
+func (m *Min[T]) Load() (T, error) {
+	return load(&m.v)
+}
+
+// Observe records val, and reports whether it is the least value observed.
+func (m *Min[T]) Observe(val T) bool {
+	return observe(&m.v, val, func(a, b T) bool { return a < b })
+}
+
+// Reset discards every value observed.
+func (m *Min[T]) Reset() {
+	m.v.p.Store(nil)
+}
+
+func load[T any](v *Value[T]) (T, error) {
+	p := v.p.Load()
+	if p == nil {
+		var zero T
+		return zero, errors.New(nil, "no values observed")
+	}
+	return *p, nil
+}
+
+// observe stores val in v if v holds no value or val is better than the value
+// held, and reports whether it did so.
+func observe[T any](v *Value[T], val T, better func(a, b T) bool) bool {
+	for {
+		p := v.p.Load()
+		if p != nil && !better(val, *p) {
+			return false
+		}
+		if v.p.CompareAndSwap(p, &val) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package bag implements a multiset.
+//
+// A bag is like a set, except that each element may be present more than once.
+// The number of times an element is present in a bag is known as its
+// multiplicity, or count. Bags are useful for frequency analysis, and for any
+// inventory-style logic where the number of identical items matters but their
+// order does not:
+//
+//	words := bag.FromSlice(strings.Fields(text))
+//	fmt.Println(words.Count("the"))
+package bag
+
+import (
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Bag represents a multiset of elements of type T. The zero value of a Bag is
+// an empty bag ready to use.
+//
+// A Bag is not safe for concurrent use by multiple goroutines.
+type Bag[T comparable] struct {
+	counts map[T]int
+	len    int
+}
+
+// FromSlice returns a bag containing each element of s, with each element
+// counted as many times as it occurs in s.
+func FromSlice[T comparable](s []T) *Bag[T] {
+	b := new(Bag[T])
+	for _, elem := range s {
+		b.Add(elem)
+	}
+	return b
+}
+
+// Add adds a single occurrence of elem to b.
+func (b *Bag[T]) Add(elem T) {
+	b.set(elem, b.Count(elem)+1)
+}
+
+// AddN adds n occurrences of elem to b. Returns error if n is negative.
+func (b *Bag[T]) AddN(elem T, n int) error {
+	if n < 0 {
+		return errors.New(nil, "negative count %d", n)
+	}
+	b.set(elem, b.Count(elem)+n)
+	return nil
+}
+
+// Count returns the number of occurrences of elem in b.
+func (b *Bag[T]) Count(elem T) int {
+	return b.counts[elem]
+}
+
+// Distinct returns each element present in b exactly once, in an unspecified
+// order.
+func (b *Bag[T]) Distinct() []T {
+	elems := make([]T, 0, len(b.counts))
+	for elem := range b.counts {
+		elems = append(elems, elem)
+	}
+	return elems
+}
+
+// Len returns the total number of elements in b, counting each occurrence of an
+// element separately.
+func (b *Bag[T]) Len() int {
+	return b.len
+}
+
+// Remove removes a single occurrence of elem from b, and reports whether elem
+// was present.
+func (b *Bag[T]) Remove(elem T) bool {
+	n := b.Count(elem)
+	if n == 0 {
+		return false
+	}
+	b.set(elem, n-1)
+	return true
+}
+
+// RemoveAll removes every occurrence of elem from b, and returns the number of
+// occurrences removed.
+func (b *Bag[T]) RemoveAll(elem T) int {
+	n := b.Count(elem)
+	b.set(elem, 0)
+	return n
+}
+
+// RemoveN removes n occurrences of elem from b. Returns error if n is negative
+// or if b contains fewer than n occurrences of elem, in which case b is left
+// unchanged.
+func (b *Bag[T]) RemoveN(elem T, n int) error {
+	if n < 0 {
+		return errors.New(nil, "negative count %d", n)
+	}
+	count := b.Count(elem)
+	if count < n {
+		return errors.New(nil,
+			"cannot remove %d occurrences of %v with count %d", n, elem, count,
+		)
+	}
+	b.set(elem, count-n)
+	return nil
+}
+
+// Slice returns the elements of b as a slice, with each element repeated as
+// many times as it occurs in b. Occurrences of the same element are adjacent,
+// but the order of distinct elements is unspecified.
+func (b *Bag[T]) Slice() []T {
+	s := make([]T, 0, b.len)
+	for elem, n := range b.counts {
+		for i := 0; i < n; i++ {
+			s = append(s, elem)
+		}
+	}
+	return s
+}
+
+func (b *Bag[T]) set(elem T, n int) {
+	if b.counts == nil {
+		b.counts = make(map[T]int)
+	}
+	b.len += n - b.counts[elem]
+	if n == 0 {
+		delete(b.counts, elem)
+	} else {
+		b.counts[elem] = n
+	}
+}
+
+// Difference returns a new bag in which the count of each element is its count
+// in a minus its count in b, or zero if that would be negative.
+func Difference[T comparable](a, b *Bag[T]) *Bag[T] {
+	diff := new(Bag[T])
+	for elem, n := range a.counts {
+		if n > b.Count(elem) {
+			diff.set(elem, n-b.Count(elem))
+		}
+	}
+	return diff
+}
+
+// Intersect returns a new bag in which the count of each element is the lesser
+// of its counts in a and b.
+func Intersect[T comparable](a, b *Bag[T]) *Bag[T] {
+	inter := new(Bag[T])
+	for elem, n := range a.counts {
+		if m := b.Count(elem); m < n {
+			n = m
+		}
+		if n > 0 {
+			inter.set(elem, n)
+		}
+	}
+	return inter
+}
+
+// Sum returns a new bag in which the count of each element is the sum of its
+// counts in a and b.
+func Sum[T comparable](a, b *Bag[T]) *Bag[T] {
+	sum := new(Bag[T])
+	for _, x := range []*Bag[T]{a, b} {
+		for elem, n := range x.counts {
+			sum.set(elem, sum.Count(elem)+n)
+		}
+	}
+	return sum
+}
+
+// Union returns a new bag in which the count of each element is the greater of
+// its counts in a and b.
+func Union[T comparable](a, b *Bag[T]) *Bag[T] {
+	union := new(Bag[T])
+	for _, x := range []*Bag[T]{a, b} {
+		for elem, n := range x.counts {
+			if n > union.Count(elem) {
+				union.set(elem, n)
+			}
+		}
+	}
+	return union
+}
@@ -0,0 +1,144 @@
+package std
+
+import (
+	"reflect"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// DeepClone returns a deep copy of v. Pointers, slices, maps, arrays, structs
+// and interfaces held by v are copied recursively, so that modifying the copy
+// never affects v and vice versa.
+//
+// References which form cycles or point to shared memory within v are
+// preserved in the copy: if two pointers in v point to the same value, the
+// corresponding pointers in the copy point to the same copied value. This
+// allows DeepClone to copy cyclic data structures.
+//
+// Unexported struct fields cannot be set using reflection, and are therefore
+// copied shallowly. Function values are copied as-is. Returns error if v
+// contains a channel or an unsafe.Pointer, as these cannot be meaningfully
+// copied.
+func DeepClone[T any](v T) (T, error) {
+	var clone T
+	c := cloner{seen: make(map[cloneKey]reflect.Value)}
+	cv, err := c.clone(reflect.ValueOf(&v).Elem())
+	if err != nil {
+		return clone, errors.Wrap(err)
+	}
+	reflect.ValueOf(&clone).Elem().Set(cv)
+	return clone, nil
+}
+
+// cloneKey identifies a reference-typed value which has already been cloned.
+type cloneKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+type cloner struct {
+	seen map[cloneKey]reflect.Value
+}
+
+func (c cloner) clone(v reflect.Value) (reflect.Value, error) {
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return reflect.Zero(t), nil
+		}
+		key := cloneKey{v.Pointer(), 0, t}
+		if clone, ok := c.seen[key]; ok {
+			return clone, nil
+		}
+		clone := reflect.New(t.Elem())
+		c.seen[key] = clone
+		elem, err := c.clone(v.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		clone.Elem().Set(elem)
+		return clone, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(t), nil
+		}
+		key := cloneKey{v.Pointer(), 0, t}
+		if clone, ok := c.seen[key]; ok {
+			return clone, nil
+		}
+		clone := reflect.MakeMapWithSize(t, v.Len())
+		c.seen[key] = clone
+		iter := v.MapRange()
+		for iter.Next() {
+			k, err := c.clone(iter.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			e, err := c.clone(iter.Value())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			clone.SetMapIndex(k, e)
+		}
+		return clone, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(t), nil
+		}
+		key := cloneKey{v.Pointer(), v.Len(), t}
+		if clone, ok := c.seen[key]; ok {
+			return clone, nil
+		}
+		clone := reflect.MakeSlice(t, v.Len(), v.Cap())
+		c.seen[key] = clone
+		for i := 0; i < v.Len(); i++ {
+			e, err := c.clone(v.Index(i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			clone.Index(i).Set(e)
+		}
+		return clone, nil
+	case reflect.Array:
+		clone := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			e, err := c.clone(v.Index(i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			clone.Index(i).Set(e)
+		}
+		return clone, nil
+	case reflect.Struct:
+		clone := reflect.New(t).Elem()
+		clone.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			f, err := c.clone(v.Field(i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			clone.Field(i).Set(f)
+		}
+		return clone, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(t), nil
+		}
+		elem, err := c.clone(v.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		clone := reflect.New(t).Elem()
+		clone.Set(elem)
+		return clone, nil
+	case reflect.Chan, reflect.UnsafePointer:
+		return reflect.Value{}, errors.New(nil, "cannot clone value of type %s", t)
+	default:
+		return v, nil
+	}
+}
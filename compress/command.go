@@ -0,0 +1,150 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Command returns a codec for the format name, with the file name extension
+// ext and data beginning with magic, implemented by running external programs:
+// compress, which compresses its standard input to its standard output, and
+// decompress, which does the reverse. Each is given as a program followed by
+// its arguments. A level other than DefaultLevel is passed to compress as an
+// argument -level, such as -9, as accepted by most compression programs.
+func Command(name, ext string, magic []byte, compress, decompress []string) Codec {
+	return &command{name, ext, magic, compress, decompress}
+}
+
+type command struct {
+	name, ext  string
+	magic      []byte
+	compress   []string
+	decompress []string
+}
+
+func (c *command) Name() string { return c.name }
+
+func (c *command) Ext() string { return c.ext }
+
+func (c *command) Match(head []byte) bool {
+	return len(c.magic) > 0 && bytes.HasPrefix(head, c.magic)
+}
+
+func (c *command) NewReader(r io.Reader) (io.ReadCloser, error) {
+	cmd := exec.Command(c.decompress[0], c.decompress[1:]...)
+	cmd.Stdin = r
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.New(err, "cannot run %s", c.decompress[0])
+	}
+	return &commandReader{cmd: cmd, out: out, stderr: &stderr}, nil
+}
+
+func (c *command) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	args := c.compress[1:]
+	if level != DefaultLevel {
+		args = append(append([]string(nil), args...), "-"+strconv.Itoa(level))
+	}
+	cmd := exec.Command(c.compress[0], args...)
+	cmd.Stdout = w
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.New(err, "cannot run %s", c.compress[0])
+	}
+	return &commandWriter{cmd: cmd, in: in, stderr: &stderr}, nil
+}
+
+// commandReader reads the output of a decompressing program.
+type commandReader struct {
+	cmd    *exec.Cmd
+	out    io.ReadCloser
+	stderr *bytes.Buffer
+	done   bool
+	err    error
+}
+
+// Read reads the output of the program, returning the error of the program,
+// if it fails, in place of io.EOF, so that malformed data is reported.
+func (r *commandReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, r.result()
+	}
+	n, err := r.out.Read(p)
+	if err == io.EOF {
+		r.wait()
+		return n, r.result()
+	}
+	return n, err
+}
+
+func (r *commandReader) Close() error {
+	if !r.done {
+		// The program may be blocked writing output which is no longer
+		// wanted.
+		r.cmd.Process.Kill()
+		r.wait()
+		return nil
+	}
+	return r.err
+}
+
+func (r *commandReader) wait() {
+	r.done = true
+	if err := r.cmd.Wait(); err != nil {
+		r.err = commandError(r.cmd, err, r.stderr)
+	}
+}
+
+func (r *commandReader) result() error {
+	if r.err != nil {
+		return r.err
+	}
+	return io.EOF
+}
+
+// commandWriter writes to the input of a compressing program.
+type commandWriter struct {
+	cmd    *exec.Cmd
+	in     io.WriteCloser
+	stderr *bytes.Buffer
+}
+
+func (w *commandWriter) Write(p []byte) (int, error) {
+	return w.in.Write(p)
+}
+
+func (w *commandWriter) Close() error {
+	w.in.Close()
+	if err := w.cmd.Wait(); err != nil {
+		return commandError(w.cmd, err, w.stderr)
+	}
+	return nil
+}
+
+// commandError returns error describing the failure err of cmd, including the
+// last line of its standard error, if any.
+func commandError(cmd *exec.Cmd, err error, stderr *bytes.Buffer) error {
+	msg := strings.TrimSpace(stderr.String())
+	if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+		msg = msg[i+1:]
+	}
+	if msg != "" {
+		return errors.New(nil, "%s failed: %s: %s", cmd.Args[0], err, msg)
+	}
+	return errors.New(err, "%s failed", cmd.Args[0])
+}
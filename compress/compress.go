@@ -0,0 +1,274 @@
+// Package compress implements a common interface to compression formats, and
+// the transparent compression and decompression of streams and files.
+//
+// A Codec compresses and decompresses a format. The gzip and zlib formats are
+// implemented in Go, while zstd and xz are implemented by running the zstd and
+// xz programs, if installed. Further codecs may be registered:
+//
+//	compress.Register(compress.Command("lz4", ".lz4", []byte{0x04, 0x22, 0x4d, 0x18},
+//		[]string{"lz4", "-c"}, []string{"lz4", "-d", "-c"}))
+//
+// NewReader detects the format of a stream from its first bytes, and Open and
+// Create decompress and compress files by their content and their names:
+//
+//	r, err := compress.Open("access.log.gz") // or access.log, access.log.zst
+//	defer r.Close()
+//
+//	w, err := compress.Create("dump.json.zst", compress.DefaultLevel)
+//	defer w.Close()
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// DefaultLevel selects the default compression level of a codec.
+const DefaultLevel = -1
+
+// Codec compresses and decompresses a format.
+type Codec interface {
+	// Name returns the name of the format, such as "gzip".
+	Name() string
+
+	// Ext returns the file name extension of the format, such as ".gz".
+	Ext() string
+
+	// Match reports whether head, the first bytes of a stream, begins data
+	// in the format. Up to 16 bytes are given, or fewer if the stream is
+	// shorter.
+	Match(head []byte) bool
+
+	// NewReader returns a reader decompressing the data read from r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+
+	// NewWriter returns a writer compressing the data written to it, at the
+	// given level, to w. The data is complete only once the writer is
+	// closed, which does not close w.
+	NewWriter(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+// Gzip is the gzip format, whose levels range from 1, for the fastest
+// compression, to 9, for the smallest.
+var Gzip Codec = gzipCodec{}
+
+// Zlib is the zlib format, whose levels are those of Gzip.
+var Zlib Codec = zlibCodec{}
+
+// Zstd is the zstd format, implemented by the zstd program, whose levels range
+// from 1 to 19.
+var Zstd = Command("zstd", ".zst", []byte{0x28, 0xb5, 0x2f, 0xfd},
+	[]string{"zstd", "-q", "-c"}, []string{"zstd", "-q", "-d", "-c"})
+
+// Xz is the xz format, implemented by the xz program, whose levels range from
+// 0 to 9.
+var Xz = Command("xz", ".xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+	[]string{"xz", "-q", "-c"}, []string{"xz", "-q", "-d", "-c"})
+
+var (
+	mu     sync.RWMutex
+	codecs = []Codec{Gzip, Zlib, Zstd, Xz}
+)
+
+// Codecs returns the registered codecs.
+func Codecs() []Codec {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Codec(nil), codecs...)
+}
+
+// Create creates the file at path, returning a writer compressing the data
+// written to it by the codec whose extension path has, such as Gzip for
+// "dump.json.gz", at the given level, or writing the data as it is if path has
+// no such extension. Closing the writer completes the data and closes the
+// file. Returns error if the file cannot be created.
+func Create(path string, level int) (io.WriteCloser, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.New(err, "cannot create %s", path)
+	}
+	c := ForExt(path)
+	if c == nil {
+		return f, nil
+	}
+	w, err := c.NewWriter(f, level)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, errors.New(err, "cannot create %s", path)
+	}
+	return &stackWriter{w, f}, nil
+}
+
+// Detect returns the codec of the data read from r, detected from its first
+// bytes, or nil if it matches no registered codec, such as if it is not
+// compressed. The reader returned yields the data of r in full, including the
+// bytes examined. Returns error if r cannot be read.
+func Detect(r io.Reader) (Codec, io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(16)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, br, errors.New(err, "cannot detect compression")
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for i := len(codecs) - 1; i >= 0; i-- {
+		if codecs[i].Match(head) {
+			return codecs[i], br, nil
+		}
+	}
+	return nil, br, nil
+}
+
+// ForExt returns the codec whose extension path has, or nil if there is none.
+// Extensions are matched ignoring case.
+func ForExt(path string) Codec {
+	ext := filepath.Ext(path)
+	mu.RLock()
+	defer mu.RUnlock()
+	for i := len(codecs) - 1; i >= 0; i-- {
+		if strings.EqualFold(codecs[i].Ext(), ext) {
+			return codecs[i]
+		}
+	}
+	return nil
+}
+
+// Lookup returns the codec named name. Returns error if there is none.
+func Lookup(name string) (Codec, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for i := len(codecs) - 1; i >= 0; i-- {
+		if codecs[i].Name() == name {
+			return codecs[i], nil
+		}
+	}
+	return nil, errors.New(nil, "unknown compression format %s", name)
+}
+
+// NewReader returns a reader decompressing the data read from r by the codec
+// detected by Detect, or yielding the data as it is if no codec is detected.
+// Closing the reader does not close r. Returns error if r cannot be read, or
+// if its data is malformed.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	c, br, err := Detect(r)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return io.NopCloser(br), nil
+	}
+	rc, err := c.NewReader(br)
+	if err != nil {
+		return nil, errors.New(err, "cannot decompress %s", c.Name())
+	}
+	return rc, nil
+}
+
+// Open opens the file at path, returning a reader decompressing its data as by
+// NewReader, whatever its name. Closing the reader closes the file. Returns
+// error if the file cannot be opened, or its data is malformed.
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New(err, "cannot open %s", path)
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, errors.New(err, "cannot open %s", path)
+	}
+	return &stackReader{r, f}, nil
+}
+
+// Register registers c, so that it is found by the functions of the package.
+// A codec registered later takes precedence over one registered earlier, or
+// built in, with the same name, extension or matching data.
+func Register(c Codec) {
+	mu.Lock()
+	defer mu.Unlock()
+	codecs = append(codecs, c)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return "gzip" }
+
+func (gzipCodec) Ext() string { return ".gz" }
+
+func (gzipCodec) Match(head []byte) bool {
+	return bytes.HasPrefix(head, []byte{0x1f, 0x8b})
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func (gzipCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, level)
+}
+
+type zlibCodec struct{}
+
+func (zlibCodec) Name() string { return "zlib" }
+
+func (zlibCodec) Ext() string { return ".zz" }
+
+func (zlibCodec) Match(head []byte) bool {
+	// A zlib header is too short to be told reliably from text, so only
+	// the headers written by common implementations at their usual levels
+	// are matched.
+	if len(head) < 2 || head[0] != 0x78 {
+		return false
+	}
+	switch head[1] {
+	case 0x01, 0x5e, 0x9c, 0xda:
+		return true
+	}
+	return false
+}
+
+func (zlibCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+func (zlibCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return zlib.NewWriterLevel(w, level)
+}
+
+// stackReader closes a decompressing reader and the file beneath it.
+type stackReader struct {
+	io.ReadCloser
+	f io.Closer
+}
+
+func (r *stackReader) Close() error {
+	err := r.ReadCloser.Close()
+	if ferr := r.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// stackWriter closes a compressing writer and the file beneath it.
+type stackWriter struct {
+	io.WriteCloser
+	f io.Closer
+}
+
+func (w *stackWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if ferr := w.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
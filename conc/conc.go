@@ -0,0 +1,62 @@
+// Package conc implements primitives for structured concurrency.
+//
+// A Pool runs a task over many items using a bounded number of goroutines:
+//
+//	p := conc.NewPool(ctx, fetch, conc.PoolOptions{Workers: 8, Ordered: true})
+//	for _, url := range urls {
+//		if err := p.Submit(url); err != nil {
+//			break
+//		}
+//	}
+//	pages, err := p.Wait()
+//
+// A Semaphore bounds the total weight of concurrent work, and Limit bounds
+// the number of concurrent calls to a function:
+//
+//	query := conc.Limit(10, func(ctx context.Context) error {
+//		return db.Ping(ctx)
+//	})
+//
+// A Future holds the result of an asynchronous computation:
+//
+//	user := conc.Go(func() (*User, error) {
+//		return lookup(ctx, id)
+//	})
+//	name := conc.Then(user, func(u *User) (string, error) {
+//		return u.Name, nil
+//	})
+//	s, err := name.Await(ctx)
+//
+// A Limiter limits the rate of events using a token bucket, and a
+// KeyedLimiter limits each of many clients separately:
+//
+//	limits := conc.NewKeyedLimiter[string](conc.Every(time.Second), 5)
+//	if !limits.Allow(r.RemoteAddr) {
+//		http.Error(w, "too many requests", http.StatusTooManyRequests)
+//		return
+//	}
+//
+// A Topic broadcasts values from publishers to many subscribers:
+//
+//	events := conc.NewTopic[Event](conc.TopicOptions{Buffer: 64, Policy: conc.DropOldest})
+//	ch, cancel := events.Subscribe()
+//	defer cancel()
+//	for e := range ch {
+//		handle(e)
+//	}
+//
+// A Group runs related goroutines, cancelling its context at the first
+// failure, and reports which goroutine failed:
+//
+//	g, ctx := conc.NewGroup(ctx)
+//	g.SetLimit(4)
+//	for _, f := range files {
+//		f := f
+//		g.Go(func() error {
+//			return process(ctx, f)
+//		})
+//	}
+//	if err := g.Wait(); err != nil {
+//		errors.Trace(os.Stderr, err)
+//	}
+package conc
@@ -0,0 +1,148 @@
+package conc
+
+import (
+	"context"
+	"sync"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Future represents a result of type T which becomes available once an
+// asynchronous computation completes. A Future is created by Go, Then, or a
+// Promise.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Go calls f in a new goroutine, and returns a future holding its result. If f
+// panics, the future holds an error describing the panic.
+func Go[T any](f func() (T, error)) *Future[T] {
+	fut := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(fut.done)
+		defer func() {
+			if r := recover(); r != nil {
+				fut.err = errors.New(nil, "future panicked: %v", r)
+			}
+		}()
+		fut.value, fut.err = f()
+	}()
+	return fut
+}
+
+// Await waits for the result of fut, and returns it. Returns error if the
+// computation failed, or if ctx is done before the result is available.
+func (fut *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-fut.done:
+		return fut.value, fut.err
+	case <-ctx.Done():
+		var zero T
+		return zero, errors.New(context.Cause(ctx), "cannot await future")
+	}
+}
+
+// Done returns a channel which is closed when the result of fut is available.
+func (fut *Future[T]) Done() <-chan struct{} {
+	return fut.done
+}
+
+// Then returns a future holding the result of calling f with the value of fut
+// once it is available. If fut fails, f is not called, and the returned future
+// holds the same error.
+func Then[T, U any](fut *Future[T], f func(T) (U, error)) *Future[U] {
+	return Go(func() (U, error) {
+		<-fut.done
+		if fut.err != nil {
+			var zero U
+			return zero, fut.err
+		}
+		return f(fut.value)
+	})
+}
+
+// AwaitAll waits for the results of every future in futs, and returns their
+// values in the same order. Returns the first error found, in the order of
+// futs, or an error if ctx is done before every result is available.
+func AwaitAll[T any](ctx context.Context, futs ...*Future[T]) ([]T, error) {
+	values := make([]T, len(futs))
+	for i, fut := range futs {
+		v, err := fut.Await(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// AwaitAny waits for the first of futs to succeed, and returns its value.
+// Returns error if every future fails, joining their errors, or if ctx is done
+// before any future succeeds.
+func AwaitAny[T any](ctx context.Context, futs ...*Future[T]) (T, error) {
+	var zero T
+	if len(futs) == 0 {
+		return zero, errors.New(nil, "no futures to await")
+	}
+	type result struct {
+		value T
+		err   error
+	}
+	results := make(chan result, len(futs))
+	for _, fut := range futs {
+		go func(fut *Future[T]) {
+			select {
+			case <-fut.done:
+				results <- result{fut.value, fut.err}
+			case <-ctx.Done():
+			}
+		}(fut)
+	}
+	var errs []error
+	for range futs {
+		select {
+		case r := <-results:
+			if r.err == nil {
+				return r.value, nil
+			}
+			errs = append(errs, r.err)
+		case <-ctx.Done():
+			return zero, errors.New(context.Cause(ctx), "cannot await future")
+		}
+	}
+	return zero, errors.New(errors.Join(errs...), "every future failed")
+}
+
+// Promise is the writing side of a Future, whose result is given explicitly
+// by a call to Resolve. A Promise must be created with NewPromise.
+type Promise[T any] struct {
+	fut  *Future[T]
+	once sync.Once
+}
+
+// NewPromise returns a promise whose future is not yet resolved.
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{fut: &Future[T]{done: make(chan struct{})}}
+}
+
+// Future returns the future resolved by p.
+func (p *Promise[T]) Future() *Future[T] {
+	return p.fut
+}
+
+// Resolve sets the result of the future of p to value and err. Returns error
+// if p has already been resolved, in which case its result is unchanged.
+func (p *Promise[T]) Resolve(value T, err error) error {
+	resolved := false
+	p.once.Do(func() {
+		p.fut.value, p.fut.err = value, err
+		close(p.fut.done)
+		resolved = true
+	})
+	if !resolved {
+		return errors.New(nil, "promise already resolved")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package conc
+
+import (
+	"context"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Group runs a collection of goroutines working on parts of a common task,
+// and waits for them to finish. The zero value of a Group has no concurrency
+// limit and cancels no context on failure.
+type Group struct {
+	cancel context.CancelCauseFunc
+	group  errors.Group
+	sem    chan struct{}
+}
+
+// NewGroup returns a group, and a context derived from ctx which is cancelled
+// when a goroutine of the group first fails, or when Wait returns.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Go calls f in a new goroutine. If the group has a concurrency limit, Go
+// blocks until the number of running goroutines is below it.
+//
+// An error returned by f, or a panic, is recorded for Wait as by errors.Group,
+// identifying the goroutine by the order in which it was started and by the
+// call site of Go. If the group was created by NewGroup, the first error also
+// cancels its context.
+func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.group.GoSkip(1, func() error {
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+		}()
+		err := errors.Catch(f)
+		if err != nil && g.cancel != nil {
+			g.cancel(err)
+		}
+		return err
+	})
+}
+
+// SetLimit limits the number of goroutines of the group running at once to n.
+// A negative n removes the limit. Returns error if any goroutine of the group
+// is running.
+func (g *Group) SetLimit(n int) error {
+	if g.sem != nil && len(g.sem) > 0 {
+		return errors.New(nil, "cannot set limit with %d goroutines running", len(g.sem))
+	}
+	if n < 0 {
+		g.sem = nil
+		return nil
+	}
+	g.sem = make(chan struct{}, n)
+	return nil
+}
+
+// Wait waits for every goroutine started by Go to return. Returns nil if none
+// failed, and otherwise an error combining the errors recorded by Go in the
+// order in which the goroutines were started, as by errors.Group.
+func (g *Group) Wait() error {
+	err := g.group.Wait()
+	if g.cancel != nil {
+		g.cancel(err)
+	}
+	return err
+}
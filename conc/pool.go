@@ -0,0 +1,164 @@
+package conc
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// PoolOptions configures a Pool. The zero value of a PoolOptions describes a
+// pool with one worker per available CPU, which returns results in the order
+// in which they complete and stops at the first error.
+type PoolOptions struct {
+	// Workers is the number of goroutines running tasks. If Workers is zero
+	// or negative, runtime.GOMAXPROCS(0) goroutines are used.
+	Workers int
+
+	// Ordered causes results to be returned in the order in which their items
+	// were submitted, rather than the order in which they complete.
+	Ordered bool
+
+	// CollectErrors causes every task to run even if some fail, with the
+	// errors of all failed tasks returned together by Wait. Otherwise, the
+	// first error cancels the context of the remaining tasks.
+	CollectErrors bool
+}
+
+// Pool runs a task function over submitted items using a bounded number of
+// goroutines. A Pool must be created with NewPool.
+type Pool[T, R any] struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	parent  context.Context
+	task    func(context.Context, T) (R, error)
+	opts    PoolOptions
+	jobs    chan poolJob[T]
+	wg      sync.WaitGroup
+	closeMu sync.RWMutex
+	closed  bool
+	next    int
+
+	mu      sync.Mutex
+	results []poolResult[R]
+	errs    []error
+	skipped bool
+}
+
+type poolJob[T any] struct {
+	index int
+	item  T
+}
+
+type poolResult[R any] struct {
+	index int
+	value R
+}
+
+// NewPool returns a pool which calls task with each submitted item, and starts
+// its workers. The context passed to task is derived from ctx, and is
+// cancelled by the first error unless opts.CollectErrors is set.
+func NewPool[T, R any](ctx context.Context, task func(ctx context.Context, item T) (R, error), opts PoolOptions) *Pool[T, R] {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	p := &Pool[T, R]{
+		parent: ctx,
+		task:   task,
+		opts:   opts,
+		jobs:   make(chan poolJob[T]),
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues item to be run, blocking until a worker is free to run it.
+// Returns error if Wait has been called, or if the context of the pool is
+// cancelled before a worker is free.
+func (p *Pool[T, R]) Submit(item T) error {
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+	if p.closed {
+		return errors.New(nil, "pool is closed")
+	}
+	p.mu.Lock()
+	j := poolJob[T]{p.next, item}
+	p.next++
+	p.mu.Unlock()
+	select {
+	case p.jobs <- j:
+		return nil
+	case <-p.ctx.Done():
+		p.mu.Lock()
+		p.skipped = true
+		p.mu.Unlock()
+		return errors.New(context.Cause(p.ctx), "cannot submit item")
+	}
+}
+
+// Wait stops the pool from accepting items, waits for every submitted item to
+// be run, and returns the results of the tasks which succeeded. If a task
+// failed, Wait returns the first error, or with PoolOptions.CollectErrors, the
+// errors of all failed tasks joined by errors.Join. If the context given to
+// NewPool was cancelled before every item was run, Wait returns its error.
+func (p *Pool[T, R]) Wait() ([]R, error) {
+	p.closeMu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.closeMu.Unlock()
+	p.wg.Wait()
+	p.cancel()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.opts.Ordered {
+		sort.Slice(p.results, func(i, j int) bool {
+			return p.results[i].index < p.results[j].index
+		})
+	}
+	values := make([]R, len(p.results))
+	for i, r := range p.results {
+		values[i] = r.value
+	}
+	switch {
+	case len(p.errs) > 0 && p.opts.CollectErrors:
+		return values, errors.Join(p.errs...)
+	case len(p.errs) > 0:
+		return values, p.errs[0]
+	case p.skipped && p.parent.Err() != nil:
+		return values, p.parent.Err()
+	}
+	return values, nil
+}
+
+func (p *Pool[T, R]) work() {
+	defer p.wg.Done()
+	for j := range p.jobs {
+		if p.ctx.Err() != nil {
+			p.mu.Lock()
+			p.skipped = true
+			p.mu.Unlock()
+			continue
+		}
+		v, err := p.task(p.ctx, j.item)
+		p.mu.Lock()
+		if err != nil {
+			if p.opts.CollectErrors || len(p.errs) == 0 {
+				p.errs = append(p.errs, err)
+			}
+			if !p.opts.CollectErrors {
+				p.cancel()
+			}
+		} else {
+			p.results = append(p.results, poolResult[R]{j.index, v})
+		}
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,248 @@
+package conc
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Inf is a rate allowing any number of events.
+var Inf = math.Inf(1)
+
+// Every returns the rate of one event per interval d.
+func Every(d time.Duration) float64 {
+	if d <= 0 {
+		return Inf
+	}
+	return float64(time.Second) / float64(d)
+}
+
+// Limiter is a token-bucket rate limiter. The bucket holds up to burst tokens
+// and is refilled at rate tokens per second, with each event consuming one
+// token. A Limiter must be created with NewLimiter, and is safe for concurrent
+// use.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a limiter allowing events at rate per second, with bursts
+// of up to burst events. The bucket starts full.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow is equivalent to AllowN(1).
+func (l *Limiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now, consuming their tokens if
+// so.
+func (l *Limiter) AllowN(n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate == Inf {
+		return true
+	}
+	l.advance(time.Now())
+	if l.tokens < float64(n) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
+
+// Reserve is equivalent to ReserveN(1).
+func (l *Limiter) Reserve() (*Reservation, error) {
+	return l.ReserveN(1)
+}
+
+// ReserveN reserves tokens for n events, which may happen after the delay of
+// the returned reservation. Returns error if n exceeds the burst of l, so that
+// the events can never happen.
+func (l *Limiter) ReserveN(n int) (*Reservation, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.rate == Inf {
+		return &Reservation{l, 0, now}, nil
+	}
+	if n > l.burst {
+		return nil, errors.New(nil, "cannot reserve %d events with burst %d", n, l.burst)
+	}
+	l.advance(now)
+	l.tokens -= float64(n)
+	at := now
+	if l.tokens < 0 {
+		if l.rate <= 0 {
+			l.tokens += float64(n)
+			return nil, errors.New(nil, "cannot reserve %d events with rate 0", n)
+		}
+		at = now.Add(time.Duration(-l.tokens / l.rate * float64(time.Second)))
+	}
+	return &Reservation{l, n, at}, nil
+}
+
+// Tokens returns the number of tokens currently available. The number is
+// negative if reservations are pending.
+func (l *Limiter) Tokens() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(time.Now())
+	return l.tokens
+}
+
+// Wait is equivalent to WaitN(ctx, 1).
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen. Returns error if n exceeds the
+// burst of l, or if ctx is done, or would pass its deadline, before the events
+// may happen. No tokens are consumed if WaitN returns an error.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return errors.New(context.Cause(ctx), "cannot wait for limiter")
+	}
+	r, err := l.ReserveN(n)
+	if err != nil {
+		return err
+	}
+	delay := r.Delay()
+	if delay <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(r.at) {
+		r.Cancel()
+		return errors.New(nil, "cannot wait for limiter: delay of %s exceeds context deadline", delay)
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return errors.New(context.Cause(ctx), "cannot wait for limiter")
+	}
+}
+
+// advance refills the bucket up to now. The caller must hold l.mu.
+func (l *Limiter) advance(now time.Time) {
+	if now.Before(l.last) {
+		return
+	}
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+	l.last = now
+}
+
+// Reservation holds tokens reserved from a Limiter by Reserve.
+type Reservation struct {
+	l  *Limiter
+	n  int
+	at time.Time
+}
+
+// Cancel returns the reserved tokens to the limiter, if the reserved events
+// were not yet due to happen.
+func (r *Reservation) Cancel() {
+	if r.n == 0 || !time.Now().Before(r.at) {
+		return
+	}
+	r.l.mu.Lock()
+	defer r.l.mu.Unlock()
+	r.l.advance(time.Now())
+	r.l.tokens += float64(r.n)
+	if r.l.tokens > float64(r.l.burst) {
+		r.l.tokens = float64(r.l.burst)
+	}
+	r.n = 0
+}
+
+// Delay returns the duration to wait before the reserved events may happen.
+func (r *Reservation) Delay() time.Duration {
+	d := time.Until(r.at)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// KeyedLimiter holds a separate Limiter for each key, such as a client
+// address, with every limiter sharing the same rate and burst. Limiters which
+// have been idle long enough to refill completely are discarded. A
+// KeyedLimiter must be created with NewKeyedLimiter, and is safe for
+// concurrent use.
+type KeyedLimiter[K comparable] struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    int
+	limiters map[K]*Limiter
+	swept    time.Time
+}
+
+// NewKeyedLimiter returns a keyed limiter whose limiters allow events at rate
+// per second, with bursts of up to burst events.
+func NewKeyedLimiter[K comparable](rate float64, burst int) *KeyedLimiter[K] {
+	return &KeyedLimiter[K]{
+		rate:     rate,
+		burst:    burst,
+		limiters: make(map[K]*Limiter),
+		swept:    time.Now(),
+	}
+}
+
+// Allow is equivalent to Get(key).Allow().
+func (k *KeyedLimiter[K]) Allow(key K) bool {
+	return k.Get(key).Allow()
+}
+
+// Get returns the limiter for key, creating it if necessary.
+func (k *KeyedLimiter[K]) Get(key K) *Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	now := time.Now()
+	if k.rate > 0 && now.Sub(k.swept).Seconds() > float64(k.burst)/k.rate {
+		k.sweep(now)
+	}
+	l, ok := k.limiters[key]
+	if !ok {
+		l = NewLimiter(k.rate, k.burst)
+		k.limiters[key] = l
+	}
+	return l
+}
+
+// Wait is equivalent to Get(key).Wait(ctx).
+func (k *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// sweep discards limiters whose buckets are full. The caller must hold k.mu.
+func (k *KeyedLimiter[K]) sweep(now time.Time) {
+	for key, l := range k.limiters {
+		l.mu.Lock()
+		l.advance(now)
+		full := l.tokens >= float64(l.burst)
+		l.mu.Unlock()
+		if full {
+			delete(k.limiters, key)
+		}
+	}
+	k.swept = now
+}
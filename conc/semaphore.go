@@ -0,0 +1,124 @@
+package conc
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Semaphore is a weighted semaphore, bounding the total weight of concurrent
+// holders. Waiters acquire the semaphore in the order in which they began
+// waiting, so that a large request is not starved by a stream of smaller ones.
+// A Semaphore must be created with NewSemaphore.
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int64
+	cur     int64
+	waiters list.List
+}
+
+type semWaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// NewSemaphore returns a semaphore with total weight size.
+func NewSemaphore(size int64) *Semaphore {
+	return &Semaphore{size: size}
+}
+
+// Acquire acquires weight n, blocking until it is available or ctx is done.
+// Returns error if ctx is done first, in which case no weight is acquired, or
+// if n exceeds the total weight of the semaphore.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	s.mu.Lock()
+	if n > s.size {
+		s.mu.Unlock()
+		return errors.New(nil, "cannot acquire %d of semaphore with size %d", n, s.size)
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(semWaiter{n, ready})
+	s.mu.Unlock()
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// Acquired after ctx was done; give the weight back.
+			s.cur -= n
+			s.notify()
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			if front {
+				s.notify()
+			}
+		}
+		s.mu.Unlock()
+		return errors.New(context.Cause(ctx), "cannot acquire semaphore")
+	}
+}
+
+// Release releases weight n. Returns error if n exceeds the weight held.
+func (s *Semaphore) Release(n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n > s.cur {
+		return errors.New(nil, "cannot release %d of semaphore holding %d", n, s.cur)
+	}
+	s.cur -= n
+	s.notify()
+	return nil
+}
+
+// TryAcquire acquires weight n without blocking, and reports whether it
+// succeeded.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// notify wakes waiters in order for as long as their weight is available.
+func (s *Semaphore) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(semWaiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
+
+// Limit returns a function which calls f, allowing at most n calls to run
+// concurrently. Further calls wait for a running call to return, or for their
+// context to be done, in which case they return an error without calling f.
+func Limit(n int, f func(ctx context.Context) error) func(ctx context.Context) error {
+	s := NewSemaphore(int64(n))
+	return func(ctx context.Context) error {
+		if err := s.Acquire(ctx, 1); err != nil {
+			return err
+		}
+		defer s.Release(1)
+		return f(ctx)
+	}
+}
@@ -0,0 +1,164 @@
+package conc
+
+import (
+	"sync"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Policy determines how a Topic treats a subscriber whose buffer is full.
+type Policy int
+
+const (
+	// Block waits for the subscriber to receive, delaying every other
+	// subscriber.
+	Block Policy = iota
+	// DropNewest discards the value being published.
+	DropNewest
+	// DropOldest discards the oldest buffered value to make room. A topic
+	// whose subscribers have unbuffered channels has no value to discard,
+	// and treats DropOldest as DropNewest.
+	DropOldest
+)
+
+// TopicOptions configures a Topic. The zero value of a TopicOptions describes
+// a topic whose subscribers have unbuffered channels, for which Publish blocks.
+type TopicOptions struct {
+	// Buffer is the capacity of each subscriber's channel. A negative
+	// capacity is taken as zero.
+	Buffer int
+
+	// Policy determines what Publish does when a subscriber's channel is
+	// full.
+	Policy Policy
+}
+
+// Topic broadcasts published values to every subscriber. A Topic must be
+// created with NewTopic, and is safe for concurrent use.
+type Topic[T any] struct {
+	opts   TopicOptions
+	pubMu  sync.Mutex
+	mu     sync.Mutex
+	subs   map[*subscriber[T]]struct{}
+	closed bool
+}
+
+type subscriber[T any] struct {
+	ch   chan T
+	done chan struct{}
+	once sync.Once
+}
+
+// NewTopic returns a topic with no subscribers.
+func NewTopic[T any](opts TopicOptions) *Topic[T] {
+	if opts.Buffer < 0 {
+		opts.Buffer = 0
+	}
+	if opts.Policy == DropOldest && opts.Buffer == 0 {
+		opts.Policy = DropNewest
+	}
+	return &Topic[T]{
+		opts: opts,
+		subs: make(map[*subscriber[T]]struct{}),
+	}
+}
+
+// Close closes the channel of every subscriber, after which Publish returns an
+// error and Subscribe returns closed channels.
+func (t *Topic[T]) Close() {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	t.closed = true
+	subs := t.subs
+	t.subs = nil
+	t.mu.Unlock()
+	for s := range subs {
+		s.once.Do(func() { close(s.done) })
+	}
+	t.pubMu.Lock()
+	defer t.pubMu.Unlock()
+	for s := range subs {
+		close(s.ch)
+	}
+}
+
+// Publish sends v to every current subscriber, treating subscribers whose
+// channels are full according to the policy of t. Values are received by each
+// subscriber in the order in which they were published. Returns error if t is
+// closed.
+func (t *Topic[T]) Publish(v T) error {
+	t.pubMu.Lock()
+	defer t.pubMu.Unlock()
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return errors.New(nil, "topic is closed")
+	}
+	subs := make([]*subscriber[T], 0, len(t.subs))
+	for s := range t.subs {
+		subs = append(subs, s)
+	}
+	t.mu.Unlock()
+	for _, s := range subs {
+		switch t.opts.Policy {
+		case Block:
+			select {
+			case s.ch <- v:
+			case <-s.done:
+			}
+		case DropNewest:
+			select {
+			case s.ch <- v:
+			default:
+			}
+		case DropOldest:
+			for sent := false; !sent; {
+				select {
+				case s.ch <- v:
+					sent = true
+				default:
+					select {
+					case <-s.ch:
+					default:
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving every value published after the call,
+// and a function which cancels the subscription and closes the channel. If t
+// is closed, the channel is already closed.
+func (t *Topic[T]) Subscribe() (<-chan T, func()) {
+	s := &subscriber[T]{
+		ch:   make(chan T, t.opts.Buffer),
+		done: make(chan struct{}),
+	}
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		close(s.ch)
+		return s.ch, func() {}
+	}
+	t.subs[s] = struct{}{}
+	t.mu.Unlock()
+	cancel := func() {
+		t.mu.Lock()
+		_, ok := t.subs[s]
+		delete(t.subs, s)
+		t.mu.Unlock()
+		if !ok {
+			return
+		}
+		s.once.Do(func() { close(s.done) })
+		t.pubMu.Lock()
+		close(s.ch)
+		t.pubMu.Unlock()
+	}
+	return s.ch, cancel
+}
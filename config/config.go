@@ -0,0 +1,253 @@
+// Package config implements layered configuration loading.
+//
+// A Config collects configuration values from several layers, and decodes
+// them into a typed struct. From lowest to highest precedence, the layers are:
+//
+//   - defaults given by the default tags of struct fields,
+//   - configuration files, with later files overriding earlier ones,
+//   - environment variables, if enabled with UseEnv, and
+//   - explicit overrides given with Set, such as command-line flags.
+//
+// Configuration files use a simple format resembling INI and a subset of TOML:
+//
+//	# Comments begin with # or ;
+//	name = example
+//	greeting = "Hello, \"world\"\n"
+//	pattern = 'literal ${not expanded}'
+//	hosts = [alpha, "beta", 'gamma']
+//
+//	[server]
+//	addr = :8080
+//	timeout = 30s
+//	root = ${name}/www
+//
+//	include "local.conf"
+//	include "conf.d/*.conf"
+//
+// Keys within a [section] are prefixed by the section name and a dot, so that
+// addr above has the full key server.addr. Double-quoted strings support the
+// escapes \n, \t, \r, \", \\ and \$. In double-quoted and bare values, a
+// reference ${key} expands to the value of a previously defined key, or failing
+// that, to the value of the environment variable key; $$ expands to a single
+// dollar sign. Single-quoted strings are taken literally. An include directive
+// loads another file at that point, expanding a leading ~ to a home directory,
+// as by pathx.ExpandUser, and resolving relative paths against the directory
+// of the including file. An included file starts in the section from which it
+// is included. The path of an include directive may be a glob pattern, as
+// accepted by glob.Compile, such as "conf.d/*.conf", to include every matching
+// file in lexical order; a pattern need not match any file.
+//
+// Values are decoded into struct fields of the same types supported by package
+// env. The key of each field is given by its config tag, or is its name in
+// lower case. Nested structs correspond to sections:
+//
+//	var cfg struct {
+//		Name   string
+//		Hosts  []string
+//		Server struct {
+//			Addr    string        `default:":80"`
+//			Timeout time.Duration `config:"timeout,required"`
+//		}
+//	}
+//	c := config.New()
+//	if err := c.LoadFile("app.conf"); err != nil {
+//		return err
+//	}
+//	c.UseEnv("APP")
+//	err := c.Decode(&cfg)
+//
+// Errors in configuration files, and values which cannot be decoded, are
+// reported together with the file and line on which they occur.
+package config
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/convert"
+)
+
+// Config holds configuration values collected from files, the environment, and
+// explicit overrides.
+type Config struct {
+	entries   map[string]entry
+	overrides map[string]string
+	envPrefix string
+	useEnv    bool
+}
+
+type entry struct {
+	values []string
+	list   bool
+	file   string
+	line   int
+}
+
+// New returns an empty Config.
+func New() *Config {
+	return &Config{
+		entries:   make(map[string]entry),
+		overrides: make(map[string]string),
+	}
+}
+
+// Get returns the value of key as found in the configuration files, or as
+// overridden by Set. Array values are joined by commas. Returns error if key
+// is not defined.
+func (c *Config) Get(key string) (string, error) {
+	if val, ok := c.overrides[key]; ok {
+		return val, nil
+	}
+	if e, ok := c.entries[key]; ok {
+		return strings.Join(e.values, ","), nil
+	}
+	return "", errors.New(nil, "undefined key %s", key)
+}
+
+// Keys returns the keys defined by configuration files and overrides, in
+// sorted order.
+func (c *Config) Keys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for k := range c.entries {
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	for k := range c.overrides {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Load parses configuration from r. The name of the source, used in error
+// messages and to resolve relative include paths, is given by name.
+func (c *Config) Load(r io.Reader, name string) error {
+	p := &parser{c: c, name: name, stack: []string{name}}
+	return p.parse(r)
+}
+
+// LoadFile parses the configuration file at path.
+func (c *Config) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.New(err, "cannot load configuration")
+	}
+	defer f.Close()
+	return c.Load(f, path)
+}
+
+// Set overrides the value of key. Overrides take precedence over all other
+// sources of configuration.
+func (c *Config) Set(key, value string) {
+	c.overrides[key] = value
+}
+
+// UseEnv enables environment variable overrides. The value of each key is
+// looked up in the environment variable named by prefix, an underscore, and
+// the key in upper case with dots and hyphens replaced by underscores. For
+// example, with prefix APP, the key server.addr is overridden by APP_SERVER_ADDR.
+// If prefix is empty, no prefix or underscore is added.
+func (c *Config) UseEnv(prefix string) {
+	c.useEnv = true
+	c.envPrefix = prefix
+}
+
+// Decode stores the configuration in the struct pointed to by v. Returns error
+// if v is not a pointer to a struct, if a required key is not defined, or if a
+// value cannot be converted to the type of its field. Decode reports every
+// problem found, not just the first.
+func (c *Config) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New(nil, "cannot decode configuration into %T", v)
+	}
+	var errs []error
+	c.decode(rv.Elem(), "", &errs)
+	if len(errs) > 0 {
+		return errors.New(errors.Join(errs...), "cannot decode configuration")
+	}
+	return nil
+}
+
+func (c *Config) decode(v reflect.Value, prefix string, errs *[]error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("config")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		key := prefix + name
+		fv := v.Field(i)
+		if f.Type.Kind() == reflect.Struct && !convert.Supported(f.Type) {
+			c.decode(fv, key+".", errs)
+			continue
+		}
+		if !convert.Supported(f.Type) {
+			*errs = append(*errs, errors.New(nil, "unsupported type %s for %s", f.Type, key))
+			continue
+		}
+		if err := c.decodeField(fv, key, f.Tag, opts); err != nil {
+			*errs = append(*errs, err)
+		}
+	}
+}
+
+func (c *Config) decodeField(v reflect.Value, key string, tag reflect.StructTag, opts string) error {
+	set := func(val, source string) error {
+		if err := convert.Set(v, val, ","); err != nil {
+			return errors.New(err, "%sinvalid value %q for %s", source, val, key)
+		}
+		return nil
+	}
+	if val, ok := c.overrides[key]; ok {
+		return set(val, "")
+	}
+	if c.useEnv {
+		name := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+		if c.envPrefix != "" {
+			name = c.envPrefix + "_" + name
+		}
+		if val, ok := os.LookupEnv(name); ok {
+			return set(val, "$"+name+": ")
+		}
+	}
+	if e, ok := c.entries[key]; ok {
+		source := e.file + ":" + strconv.Itoa(e.line) + ": "
+		if !e.list || v.Kind() != reflect.Slice {
+			return set(strings.Join(e.values, ","), source)
+		}
+		slice := reflect.MakeSlice(v.Type(), len(e.values), len(e.values))
+		for i, val := range e.values {
+			if err := convert.Set(slice.Index(i), val, ","); err != nil {
+				return errors.New(err, "%sinvalid value %q for %s", source, val, key)
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	if def, ok := tag.Lookup("default"); ok {
+		return set(def, "default: ")
+	}
+	for _, o := range strings.Split(opts, ",") {
+		if strings.TrimSpace(o) == "required" {
+			return errors.New(nil, "missing required key %s", key)
+		}
+	}
+	return nil
+}
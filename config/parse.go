@@ -0,0 +1,294 @@
+package config
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/glob"
+	"git.sr.ht/~kvo/go-std/pathx"
+)
+
+// parser holds the state of a single source being parsed.
+type parser struct {
+	c       *Config
+	name    string
+	line    int
+	section string
+	stack   []string
+}
+
+func (p *parser) errorf(format string, a ...any) error {
+	return errors.NewSkip(nil, 1, "%s:%d: "+format, append([]any{p.name, p.line}, a...)...)
+}
+
+func (p *parser) parse(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		p.line++
+		if err := p.parseLine(s.Text()); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return errors.New(err, "cannot read %s", p.name)
+	}
+	return nil
+}
+
+func (p *parser) parseLine(line string) error {
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "" || line[0] == '#' || line[0] == ';':
+		return nil
+	case line[0] == '[':
+		end := strings.IndexByte(line, ']')
+		if end < 0 {
+			return p.errorf("unterminated section header")
+		}
+		if rest := strings.TrimSpace(line[end+1:]); rest != "" && rest[0] != '#' && rest[0] != ';' {
+			return p.errorf("unexpected %q after section header", rest)
+		}
+		name := strings.TrimSpace(line[1:end])
+		if !validKey(name) {
+			return p.errorf("invalid section name %q", name)
+		}
+		p.section = name
+		return nil
+	case strings.HasPrefix(line, "include") && len(line) > 7 && unicode.IsSpace(rune(line[7])):
+		vals, _, err := p.parseValue(strings.TrimSpace(line[7:]))
+		if err != nil {
+			return err
+		}
+		if len(vals) != 1 {
+			return p.errorf("include expects a single path")
+		}
+		return p.include(vals[0])
+	}
+	key, val, ok := strings.Cut(line, "=")
+	if !ok {
+		return p.errorf("expected key = value")
+	}
+	key = strings.TrimSpace(key)
+	if !validKey(key) {
+		return p.errorf("invalid key %q", key)
+	}
+	if p.section != "" {
+		key = p.section + "." + key
+	}
+	vals, list, err := p.parseValue(strings.TrimSpace(val))
+	if err != nil {
+		return err
+	}
+	p.c.entries[key] = entry{vals, list, p.name, p.line}
+	return nil
+}
+
+func (p *parser) include(path string) error {
+	expanded, err := pathx.ExpandUser(path)
+	if err != nil {
+		return errors.New(err, "%s:%d: cannot include %s", p.name, p.line, path)
+	}
+	path = expanded
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(p.name), path)
+	}
+	if !glob.HasMeta(filepath.ToSlash(path)) {
+		return p.includeFile(path)
+	}
+	names, err := glob.Files(path)
+	if err != nil {
+		return errors.New(err, "%s:%d: cannot include %s", p.name, p.line, path)
+	}
+	for _, name := range names {
+		if err := p.includeFile(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) includeFile(path string) error {
+	for _, name := range p.stack {
+		if name == path {
+			return p.errorf("include cycle through %s", path)
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.New(err, "%s:%d: cannot include %s", p.name, p.line, path)
+	}
+	defer f.Close()
+	sub := &parser{
+		c:       p.c,
+		name:    path,
+		section: p.section,
+		stack:   append(p.stack, path),
+	}
+	return sub.parse(f)
+}
+
+// parseValue parses the value of a key, which is either a single value or an
+// array of values. It reports whether the value is an array.
+func (p *parser) parseValue(s string) ([]string, bool, error) {
+	if strings.HasPrefix(s, "[") {
+		var vals []string
+		rest := strings.TrimSpace(s[1:])
+		for {
+			if strings.HasPrefix(rest, "]") {
+				rest = rest[1:]
+				break
+			}
+			if rest == "" {
+				return nil, false, p.errorf("unterminated array")
+			}
+			val, tail, err := p.parseElem(rest, ",]")
+			if err != nil {
+				return nil, false, err
+			}
+			vals = append(vals, val)
+			rest = strings.TrimSpace(tail)
+			if rest == "" {
+				return nil, false, p.errorf("unterminated array")
+			} else if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, false, p.errorf("expected , or ] in array")
+			}
+		}
+		if err := p.checkTrailing(rest); err != nil {
+			return nil, false, err
+		}
+		return vals, true, nil
+	}
+	val, rest, err := p.parseElem(s, "")
+	if err != nil {
+		return nil, false, err
+	}
+	if err := p.checkTrailing(rest); err != nil {
+		return nil, false, err
+	}
+	return []string{val}, false, nil
+}
+
+// parseElem parses a single quoted or bare value from the start of s. A bare
+// value ends at a comment, or at any of the bytes in stop.
+func (p *parser) parseElem(s string, stop string) (val, rest string, err error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", p.errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case strings.HasPrefix(s, `"`):
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '"':
+				return b.String(), s[i+1:], nil
+			case '\\':
+				if i+1 == len(s) {
+					return "", "", p.errorf("unterminated string")
+				}
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				case '"', '\\', '$':
+					b.WriteByte(s[i])
+				default:
+					return "", "", p.errorf("invalid escape \\%c", s[i])
+				}
+			case '$':
+				n, err := p.expand(&b, s[i:])
+				if err != nil {
+					return "", "", err
+				}
+				i += n - 1
+			default:
+				b.WriteByte(s[i])
+			}
+		}
+		return "", "", p.errorf("unterminated string")
+	}
+	end := len(s)
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(stop, s[i]) >= 0 ||
+			((s[i] == '#' || s[i] == ';') && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t')) {
+			end = i
+			break
+		}
+	}
+	var b strings.Builder
+	raw := strings.TrimSpace(s[:end])
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '$' {
+			b.WriteByte(raw[i])
+			continue
+		}
+		n, err := p.expand(&b, raw[i:])
+		if err != nil {
+			return "", "", err
+		}
+		i += n - 1
+	}
+	return b.String(), s[end:], nil
+}
+
+// expand expands the reference at the start of s, which begins with a dollar
+// sign, writing the result to b and returning the number of bytes consumed.
+// A reference ${name} expands to the value of the key name, if defined, or
+// otherwise to the environment variable name. The sequence $$ expands to a
+// single dollar sign, and a dollar sign followed by anything else is kept.
+func (p *parser) expand(b *strings.Builder, s string) (int, error) {
+	switch {
+	case strings.HasPrefix(s, "$$"):
+		b.WriteByte('$')
+		return 2, nil
+	case !strings.HasPrefix(s, "${"):
+		b.WriteByte('$')
+		return 1, nil
+	}
+	end := strings.IndexByte(s, '}')
+	if end < 0 {
+		return 0, p.errorf("unterminated reference")
+	}
+	name := s[2:end]
+	if e, ok := p.c.entries[name]; ok {
+		b.WriteString(strings.Join(e.values, ","))
+	} else if val, ok := os.LookupEnv(name); ok {
+		b.WriteString(val)
+	} else {
+		return 0, p.errorf("undefined reference ${%s}", name)
+	}
+	return end + 1, nil
+}
+
+func (p *parser) checkTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && rest[0] != '#' && rest[0] != ';' {
+		return p.errorf("unexpected %q after value", rest)
+	}
+	return nil
+}
+
+func validKey(s string) bool {
+	if s == "" || s[0] == '.' || s[len(s)-1] == '.' || strings.Contains(s, "..") {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
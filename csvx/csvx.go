@@ -0,0 +1,165 @@
+// Package csvx implements the reading and writing of CSV and TSV files as
+// structs.
+//
+// Each column of a file corresponds to a field of a struct, named by the
+// field's csv tag, or by its Go name:
+//
+//	type Order struct {
+//		ID      int       `csv:"id,required"`
+//		Placed  time.Time `csv:"placed" layout:"2006-01-02"`
+//		Total   float64   `csv:"total"`
+//		Paid    bool      `csv:"paid"`
+//		Items   []string  `csv:"items" sep:";"`
+//		Comment string    `csv:"-"`
+//	}
+//	orders, err := csvx.ReadAll[Order](f, nil)
+//
+// Large files may be read a row at a time, by a Reader or by iterating over
+// Rows, and written a row at a time by a Writer:
+//
+//	rows, errf := csvx.Rows[Order](f, &csvx.Options{Comma: '\t'})
+//	rows(func(o Order) bool {
+//		...
+//		return true
+//	})
+//	if err := errf(); err != nil {
+//		return err
+//	}
+//
+// A row which cannot be decoded gives a *RowError, identifying the line and
+// column of the offending value.
+package csvx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/convert"
+)
+
+// Options configures the reading and writing of files. A nil *Options is
+// equivalent to a zero Options, which describes comma-separated values with a
+// header row.
+type Options struct {
+	// Comma is the field delimiter. It defaults to a comma; a tab gives
+	// tab-separated values.
+	Comma rune
+
+	// Comment, if not zero, starts lines which are ignored when reading.
+	Comment rune
+
+	// NoHeader indicates that the file has no header row, and that its
+	// columns correspond to the fields of the struct in order.
+	NoHeader bool
+}
+
+// RowError describes a row which cannot be decoded.
+type RowError struct {
+	// Line is the line of the file on which the row starts, counting from
+	// one.
+	Line int
+	// Column is the name of the column of the offending value, or is empty
+	// if the row as a whole is at fault.
+	Column string
+	// Err describes the problem.
+	Err error
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %s: %s", e.Line, e.Column, e.Err)
+}
+
+// field describes the column corresponding to a struct field.
+type field struct {
+	name     string
+	index    []int
+	required bool
+	layout   string // layout of times, if given
+	sep      string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// fields returns the columns corresponding to the fields of the struct type t,
+// in order.
+func fields(t reflect.Type) ([]field, error) {
+	var fs []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, _ := f.Tag.Lookup("csv")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && name == "" {
+			nested, err := fields(f.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range nested {
+				n.index = append([]int{i}, n.index...)
+				fs = append(fs, n)
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if !convert.Supported(f.Type) {
+			return nil, errors.New(nil, "unsupported type %s for column %s", f.Type, name)
+		}
+		sep := ","
+		if s, ok := f.Tag.Lookup("sep"); ok {
+			sep = s
+		}
+		fs = append(fs, field{
+			name:     name,
+			index:    []int{i},
+			required: opts == "required",
+			layout:   f.Tag.Get("layout"),
+			sep:      sep,
+		})
+	}
+	return fs, nil
+}
+
+// structType returns the struct type of v, which must be a struct or a pointer
+// to one.
+func structType(v reflect.Value) (reflect.Type, error) {
+	if !v.IsValid() {
+		return nil, errors.New(nil, "nil is not a struct")
+	}
+	t := v.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New(nil, "%s is not a struct", v.Type())
+	}
+	return t, nil
+}
+
+// isTime reports whether t is time.Time or a pointer to it.
+func isTime(t reflect.Type) bool {
+	return t == timeType || t.Kind() == reflect.Pointer && t.Elem() == timeType
+}
+
+func (o *Options) csvOptions() (comma, comment rune) {
+	comma = ','
+	if o != nil {
+		if o.Comma != 0 {
+			comma = o.Comma
+		}
+		comment = o.Comment
+	}
+	return comma, comment
+}
@@ -0,0 +1,211 @@
+package csvx
+
+import (
+	"encoding/csv"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"git.sr.ht/~kvo/go-std"
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/convert"
+)
+
+// Reader decodes the rows of a file into structs.
+type Reader struct {
+	cr       *csv.Reader
+	noHeader bool
+	header   []string // nil until read
+	typ      reflect.Type
+	// cols holds the field of each column, or nil for a column matching no
+	// field.
+	cols []*field
+}
+
+// NewReader returns a reader decoding rows from r.
+func NewReader(r io.Reader, opts *Options) *Reader {
+	cr := csv.NewReader(r)
+	cr.Comma, cr.Comment = opts.csvOptions()
+	cr.ReuseRecord = true
+	return &Reader{cr: cr, noHeader: opts != nil && opts.NoHeader}
+}
+
+// Header returns the names of the columns in the header row, reading the row
+// if no row has been read. Returns nil if the file has no header row. Returns
+// error if the header row cannot be read, or io.EOF if the file is empty.
+func (r *Reader) Header() ([]string, error) {
+	if r.header != nil || r.noHeader {
+		return r.header, nil
+	}
+	rec, err := r.cr.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, errors.New(err, "cannot read header")
+	}
+	r.header = make([]string, len(rec))
+	for i, name := range rec {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // byte order mark
+		}
+		r.header[i] = strings.TrimSpace(name)
+	}
+	return r.header, nil
+}
+
+// Read decodes the next row into the struct pointed to by v. Columns are
+// matched with fields by name, ignoring case, or by position if the file has
+// no header row. Columns matching no field are ignored, and fields matching no
+// column are left unchanged. An empty value sets a field other than a string
+// to its zero value.
+//
+// Times are parsed as RFC 3339, or by the layout given by a field's layout
+// tag, and slices are split on commas, or by the separator given by a field's
+// sep tag. Booleans and numbers are parsed as by package strconv, durations as
+// by time.ParseDuration, and types implementing encoding.TextUnmarshaler by
+// their UnmarshalText method.
+//
+// Returns io.EOF at the end of the file. Returns *RowError if the row cannot be
+// decoded, such as if it holds a malformed value, or lacks a value for a field
+// tagged as required; reading may continue with the next row. Returns other
+// errors if v is not a pointer to a struct, if the header row lacks a column
+// for a required field, or if the file cannot be read.
+func (r *Reader) Read(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New(nil, "cannot decode row into %T", v)
+	}
+	rv = rv.Elem()
+	if _, err := r.Header(); err != nil {
+		return err
+	}
+	if rv.Type() != r.typ {
+		if err := r.bind(rv.Type()); err != nil {
+			return err
+		}
+	}
+	rec, err := r.cr.Read()
+	if err == io.EOF {
+		return io.EOF
+	} else if perr, ok := err.(*csv.ParseError); ok {
+		return &RowError{Line: perr.StartLine, Err: perr.Err}
+	} else if err != nil {
+		return errors.New(err, "cannot read row")
+	}
+	line, _ := r.cr.FieldPos(0)
+	for i, f := range r.cols {
+		if f == nil {
+			continue
+		}
+		var s string
+		if i < len(rec) {
+			s = rec[i]
+		}
+		if err := decode(rv.FieldByIndex(f.index), s, f); err != nil {
+			return &RowError{Line: line, Column: f.name, Err: err}
+		}
+	}
+	return nil
+}
+
+// bind matches the columns of r with the fields of the struct type t.
+func (r *Reader) bind(t reflect.Type) error {
+	fs, err := fields(t)
+	if err != nil {
+		return errors.New(err, "cannot decode rows into %s", t)
+	}
+	if r.noHeader {
+		r.cols = make([]*field, len(fs))
+		for i := range fs {
+			r.cols[i] = &fs[i]
+		}
+		r.typ = t
+		return nil
+	}
+	r.cols = make([]*field, len(r.header))
+	for i := range fs {
+		f := &fs[i]
+		found := false
+		for j, name := range r.header {
+			if r.cols[j] == nil && strings.EqualFold(name, f.name) {
+				r.cols[j] = f
+				found = true
+				break
+			}
+		}
+		if !found && f.required {
+			return errors.New(nil, "cannot decode rows into %s: missing column %s", t, f.name)
+		}
+	}
+	r.typ = t
+	return nil
+}
+
+// ReadAll decodes every row of r into a struct of type T, as by Reader.Read.
+// Returns error if any row cannot be decoded.
+func ReadAll[T any](r io.Reader, opts *Options) ([]T, error) {
+	var rows []T
+	rd := NewReader(r, opts)
+	for {
+		var row T
+		err := rd.Read(&row)
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// Rows returns a sequence of the rows of r, each decoded into a struct of
+// type T as by Reader.Read, and a function returning the error which ended the
+// sequence, if any. Rows are decoded as the sequence is iterated, so that a
+// file of any size may be processed.
+func Rows[T any](r io.Reader, opts *Options) (std.Seq[T], func() error) {
+	var err error
+	seq := func(yield func(T) bool) {
+		rd := NewReader(r, opts)
+		for {
+			var row T
+			if rerr := rd.Read(&row); rerr == io.EOF {
+				return
+			} else if rerr != nil {
+				err = rerr
+				return
+			}
+			if !yield(row) {
+				return
+			}
+		}
+	}
+	return seq, func() error { return err }
+}
+
+// decode stores the value s in the field v, described by f.
+func decode(v reflect.Value, s string, f *field) error {
+	if strings.TrimSpace(s) == "" && v.Kind() != reflect.String {
+		if f.required {
+			return errors.New(nil, "missing value")
+		}
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if f.required && s == "" {
+		return errors.New(nil, "missing value")
+	}
+	if f.layout != "" && isTime(v.Type()) {
+		t, err := time.Parse(f.layout, strings.TrimSpace(s))
+		if err != nil {
+			return errors.New(nil, "expected time of the form %s", f.layout)
+		}
+		if v.Kind() == reflect.Pointer {
+			v.Set(reflect.ValueOf(&t))
+		} else {
+			v.Set(reflect.ValueOf(t))
+		}
+		return nil
+	}
+	return convert.Set(v, s, f.sep)
+}
@@ -0,0 +1,135 @@
+package csvx
+
+import (
+	"encoding/csv"
+	"io"
+	"reflect"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/convert"
+)
+
+// Writer encodes structs as the rows of a file. Rows are buffered, so Flush
+// must be called once writing is complete.
+type Writer struct {
+	cw       *csv.Writer
+	noHeader bool
+	typ      reflect.Type
+	fields   []field
+	record   []string
+}
+
+// NewWriter returns a writer encoding rows to w.
+func NewWriter(w io.Writer, opts *Options) *Writer {
+	cw := csv.NewWriter(w)
+	cw.Comma, _ = opts.csvOptions()
+	return &Writer{cw: cw, noHeader: opts != nil && opts.NoHeader}
+}
+
+// Flush writes any buffered rows to the underlying writer. Returns error if
+// a row could not be written.
+func (w *Writer) Flush() error {
+	w.cw.Flush()
+	if err := w.cw.Error(); err != nil {
+		return errors.New(err, "cannot write rows")
+	}
+	return nil
+}
+
+// Write encodes the struct v, or the struct to which v points, as a row, with
+// a column for each field as described for Reader.Read. Unless the file has
+// no header, the first call writes a header row naming the columns. A nil
+// pointer field gives an empty value, and times are formatted as RFC 3339, or
+// by the layout given by a field's layout tag.
+//
+// Returns error if v is not a struct or pointer to one, if v is of a different
+// type from the structs previously written, or if the row cannot be written.
+func (w *Writer) Write(v any) error {
+	rv := reflect.ValueOf(v)
+	t, err := structType(rv)
+	if err != nil {
+		return errors.New(err, "cannot encode row")
+	}
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return errors.New(nil, "cannot encode row from nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if w.typ == nil {
+		if err := w.begin(t); err != nil {
+			return err
+		}
+	} else if t != w.typ {
+		return errors.New(nil, "cannot encode row from %s after rows from %s", t, w.typ)
+	}
+	for i := range w.fields {
+		f := &w.fields[i]
+		s, err := encode(rv.FieldByIndex(f.index), f)
+		if err != nil {
+			return errors.New(err, "cannot encode column %s", f.name)
+		}
+		w.record[i] = s
+	}
+	if err := w.cw.Write(w.record); err != nil {
+		return errors.New(err, "cannot write row")
+	}
+	return nil
+}
+
+// begin prepares w to write rows of the struct type t, writing the header row.
+func (w *Writer) begin(t reflect.Type) error {
+	fs, err := fields(t)
+	if err != nil {
+		return errors.New(err, "cannot encode rows from %s", t)
+	}
+	w.typ, w.fields = t, fs
+	w.record = make([]string, len(fs))
+	if w.noHeader {
+		return nil
+	}
+	for i, f := range fs {
+		w.record[i] = f.name
+	}
+	if err := w.cw.Write(w.record); err != nil {
+		return errors.New(err, "cannot write header")
+	}
+	return nil
+}
+
+// WriteAll encodes rows to w, as by Writer.Write, and flushes them. If rows is
+// empty, only the header row is written.
+func WriteAll[T any](w io.Writer, rows []T, opts *Options) error {
+	wr := NewWriter(w, opts)
+	if len(rows) == 0 {
+		var zero T
+		t, err := structType(reflect.ValueOf(&zero).Elem())
+		if err != nil {
+			return errors.New(err, "cannot encode rows")
+		}
+		if err := wr.begin(t); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		if err := wr.Write(row); err != nil {
+			return err
+		}
+	}
+	return wr.Flush()
+}
+
+// encode returns the value of the field v, described by f.
+func encode(v reflect.Value, f *field) (string, error) {
+	if f.layout != "" && isTime(v.Type()) {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return "", nil
+			}
+			v = v.Elem()
+		}
+		return v.Interface().(time.Time).Format(f.layout), nil
+	}
+	return convert.Format(v, f.sep)
+}
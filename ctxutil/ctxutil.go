@@ -0,0 +1,146 @@
+// Package ctxutil implements utilities for working with contexts.
+//
+// Merge combines two contexts into one which ends when either does, such as a
+// request context and a server's shutdown context:
+//
+//	ctx, cancel := ctxutil.Merge(r.Context(), shutdown)
+//	defer cancel()
+//
+// Detach keeps the values of a context while dropping its cancellation, so that
+// work can outlive the request which started it:
+//
+//	go audit(ctxutil.Detach(r.Context()), event)
+//
+// A Key attaches values of a fixed type to contexts, without the type
+// assertions required by context.Value:
+//
+//	var userKey = ctxutil.NewKey[*User]("user")
+//
+//	ctx = userKey.With(ctx, u)
+//	u, err := userKey.Get(ctx)
+package ctxutil
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Detach returns a context holding the values of parent, but which is never
+// cancelled and has no deadline, whatever becomes of parent.
+func Detach(parent context.Context) context.Context {
+	return detached{parent}
+}
+
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+func (d detached) String() string {
+	return "ctxutil.Detach"
+}
+
+func (d detached) Value(key any) any {
+	return d.parent.Value(key)
+}
+
+// Merge returns a context which is done when either a or b is done, or when
+// the returned cancel function is called. Its deadline is the earlier of the
+// deadlines of a and b. Values are looked up in a, and then in b. Err and
+// context.Cause report the error of whichever context ended first.
+//
+// Calling cancel releases the resources associated with the merged context, so
+// code should call cancel as soon as operations running in it complete.
+func Merge(a, b context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(a)
+	m := &merged{Context: ctx, b: b}
+	go func() {
+		select {
+		case <-b.Done():
+			if ctx.Err() == nil {
+				m.fromB.Store(true)
+				cancel(context.Cause(b))
+			}
+		case <-ctx.Done():
+		}
+	}()
+	return m, func() { cancel(context.Canceled) }
+}
+
+type merged struct {
+	context.Context
+	b     context.Context
+	fromB atomic.Bool
+}
+
+func (m *merged) Deadline() (time.Time, bool) {
+	d, ok := m.Context.Deadline()
+	if e, eok := m.b.Deadline(); eok && (!ok || e.Before(d)) {
+		return e, true
+	}
+	return d, ok
+}
+
+func (m *merged) Err() error {
+	err := m.Context.Err()
+	if err != nil && m.fromB.Load() {
+		return m.b.Err()
+	}
+	return err
+}
+
+func (m *merged) String() string {
+	return "ctxutil.Merge"
+}
+
+func (m *merged) Value(key any) any {
+	if v := m.Context.Value(key); v != nil {
+		return v
+	}
+	return m.b.Value(key)
+}
+
+// Key is a context key for values of type T. Each Key returned by NewKey is
+// distinct, even if names are equal.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a new key. The name is used only in error messages.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name}
+}
+
+// Get returns the value of k in ctx. Returns error if ctx holds no value for k.
+func (k *Key[T]) Get(ctx context.Context) (T, error) {
+	v, ok := ctx.Value(k).(T)
+	if !ok {
+		var zero T
+		return zero, errors.New(nil, "context has no value for key %s", k.name)
+	}
+	return v, nil
+}
+
+// String returns the name of k.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// With returns a context derived from ctx in which k has value v.
+func (k *Key[T]) With(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
@@ -0,0 +1,307 @@
+// Package decimal implements exact decimal arithmetic, such as for amounts of
+// money, which cannot be represented exactly by binary floating-point numbers.
+//
+// A Decimal holds an integer coefficient of any size and a scale, the number
+// of digits after the decimal point. Addition, subtraction and multiplication
+// are exact, while division and rounding give a result of a chosen scale,
+// rounded by a chosen mode:
+//
+//	price, err := decimal.Parse("19.99")
+//	qty := decimal.FromInt(3)
+//	total := price.Mul(qty) // 59.97
+//	share, err := total.Div(decimal.FromInt(7), 2, decimal.RoundHalfEven) // 8.57
+//
+// Decimals are encoded as text, and in JSON as strings, so that no precision
+// is lost; JSON numbers are also accepted when decoding.
+package decimal
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Decimal is a decimal number, of value coef × 10^-scale. Decimals are
+// immutable; their methods return new values. The zero value of a Decimal is
+// 0.
+type Decimal struct {
+	coef  *big.Int // nil for 0
+	scale int
+}
+
+// maxExp is the greatest magnitude of the exponent accepted by Parse, which
+// bounds the memory used by the value parsed.
+const maxExp = 10000
+
+var bigTen = big.NewInt(10)
+
+// Compare returns -1 if a is less than b, 0 if they are equal, and +1 if a is
+// greater than b. Decimals of different scales may be equal, such as 1.5 and
+// 1.50.
+func Compare(a, b Decimal) int {
+	x, y := align(a, b)
+	return x.Cmp(y)
+}
+
+// FromFloat returns the decimal with the shortest representation which is
+// converted back to f exactly, such as 0.1 for the float64 nearest 0.1.
+// Returns error if f is NaN or infinite.
+func FromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, errors.New(nil, "cannot represent %v as decimal", f)
+	}
+	return Parse(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// FromInt returns the integer n as a decimal of scale 0.
+func FromInt(n int64) Decimal {
+	return New(n, 0)
+}
+
+// New returns the decimal coef × 10^-scale, such as 1999 × 10^-2 for 19.99. A
+// negative scale multiplies coef by a power of ten, giving a decimal of scale
+// 0.
+func New(coef int64, scale int) Decimal {
+	return normal(big.NewInt(coef), scale)
+}
+
+// Parse parses s as a decimal, of the form "-123.45", optionally followed by an
+// exponent, as in "1.2345e2". The scale of the result is the number of digits
+// given after the decimal point, less the exponent, or 0 if that is negative.
+// Returns error if s is malformed, or its exponent exceeds 10000 in magnitude.
+func Parse(s string) (Decimal, error) {
+	d, err := parse(s)
+	if err != nil {
+		return Decimal{}, errors.New(err, "invalid decimal %q", s)
+	}
+	return d, nil
+}
+
+func parse(s string) (Decimal, error) {
+	mant, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mant = s[:i]
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e > maxExp || e < -maxExp {
+			return Decimal{}, errors.New(nil, "invalid exponent")
+		}
+		exp = e
+	}
+	neg := false
+	if mant != "" && (mant[0] == '-' || mant[0] == '+') {
+		neg = mant[0] == '-'
+		mant = mant[1:]
+	}
+	whole, frac, _ := strings.Cut(mant, ".")
+	digits := whole + frac
+	if digits == "" {
+		return Decimal{}, errors.New(nil, "no digits")
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return Decimal{}, errors.New(nil, "invalid character %q", digits[i])
+		}
+	}
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		coef.Neg(coef)
+	}
+	return normal(coef, len(frac)-exp), nil
+}
+
+// normal returns the decimal coef × 10^-scale, with a scale of at least 0.
+func normal(coef *big.Int, scale int) Decimal {
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	if coef.Sign() == 0 {
+		coef = nil
+	}
+	return Decimal{coef, scale}
+}
+
+// Abs returns the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	if d.Sign() >= 0 {
+		return d
+	}
+	return d.Neg()
+}
+
+// Add returns d + e, of the greater of their scales.
+func (d Decimal) Add(e Decimal) Decimal {
+	x, y := align(d, e)
+	return normal(x.Add(x, y), maxInt(d.scale, e.scale))
+}
+
+// Equal reports whether d and e are equal, as by Compare.
+func (d Decimal) Equal(e Decimal) bool {
+	return Compare(d, e) == 0
+}
+
+// Float64 returns the float64 nearest to d.
+func (d Decimal) Float64() float64 {
+	r := new(big.Rat).SetFrac(d.bigCoef(), pow10(d.scale))
+	f, _ := r.Float64()
+	return f
+}
+
+// Int64 returns d as an integer. Returns error if d has a fractional part, or
+// is out of the range of an int64.
+func (d Decimal) Int64() (int64, error) {
+	q, r := new(big.Int).QuoRem(d.bigCoef(), pow10(d.scale), new(big.Int))
+	if r.Sign() != 0 {
+		return 0, errors.New(nil, "%s is not an integer", d)
+	}
+	if !q.IsInt64() {
+		return 0, errors.New(nil, "%s overflows int64", d)
+	}
+	return q.Int64(), nil
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.coef == nil || d.coef.Sign() == 0
+}
+
+// Less reports whether d is less than e.
+func (d Decimal) Less(e Decimal) bool {
+	return Compare(d, e) < 0
+}
+
+// MarshalJSON encodes d as a JSON string, as by String.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// MarshalText encodes d as by String.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Mul returns d × e, of the sum of their scales.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return normal(new(big.Int).Mul(d.bigCoef(), e.bigCoef()), d.scale+e.scale)
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return normal(new(big.Int).Neg(d.bigCoef()), d.scale)
+}
+
+// Scale returns the number of digits of d after the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1 if d is negative, 0 if it is 0, and +1 if it is positive.
+func (d Decimal) Sign() int {
+	if d.coef == nil {
+		return 0
+	}
+	return d.coef.Sign()
+}
+
+// String returns d in decimal notation, with as many digits after the decimal
+// point as its scale, such as "-0.50".
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.bigCoef()).String()
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	s := digits
+	if d.scale > 0 {
+		point := len(digits) - d.scale
+		s = digits[:point] + "." + digits[point:]
+	}
+	if d.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Sub returns d - e, of the greater of their scales.
+func (d Decimal) Sub(e Decimal) Decimal {
+	x, y := align(d, e)
+	return normal(x.Sub(x, y), maxInt(d.scale, e.scale))
+}
+
+// UnmarshalJSON decodes a decimal from a JSON string or number, as by Parse.
+// A JSON null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return errors.New(err, "cannot decode decimal")
+		}
+		data = []byte(s)
+	}
+	return d.UnmarshalText(data)
+}
+
+// UnmarshalText decodes a decimal as by Parse.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	p, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = p
+	return nil
+}
+
+// align returns the coefficients of a and b scaled to the greater of their
+// scales. The coefficients returned may be modified.
+func align(a, b Decimal) (x, y *big.Int) {
+	x, y = new(big.Int).Set(a.bigCoef()), new(big.Int).Set(b.bigCoef())
+	switch {
+	case a.scale < b.scale:
+		x.Mul(x, pow10(b.scale-a.scale))
+	case a.scale > b.scale:
+		y.Mul(y, pow10(a.scale-b.scale))
+	}
+	return x, y
+}
+
+var zero = new(big.Int)
+
+// bigCoef returns the coefficient of d, which must not be modified.
+func (d Decimal) bigCoef() *big.Int {
+	if d.coef == nil {
+		return zero
+	}
+	return d.coef
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// pow10 returns 10^n, which must not be modified.
+func pow10(n int) *big.Int {
+	if n < len(powers) {
+		return powers[n]
+	}
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// powers caches small powers of ten.
+var powers = func() []*big.Int {
+	ps := make([]*big.Int, 40)
+	ps[0] = big.NewInt(1)
+	for i := 1; i < len(ps); i++ {
+		ps[i] = new(big.Int).Mul(ps[i-1], bigTen)
+	}
+	return ps
+}()
@@ -0,0 +1,103 @@
+package decimal
+
+import (
+	"math/big"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// RoundingMode selects how a value is rounded to a given scale.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest value, and halfway values to the
+	// value with an even last digit, as is customary in finance, so that
+	// rounding errors do not accumulate.
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest value, and halfway values away from
+	// zero, as is taught in school.
+	RoundHalfUp
+	// RoundDown rounds towards zero, truncating the digits dropped.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundFloor rounds towards negative infinity.
+	RoundFloor
+	// RoundCeiling rounds towards positive infinity.
+	RoundCeiling
+)
+
+// String returns the name of m, such as "half-even".
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundHalfEven:
+		return "half-even"
+	case RoundHalfUp:
+		return "half-up"
+	case RoundDown:
+		return "down"
+	case RoundUp:
+		return "up"
+	case RoundFloor:
+		return "floor"
+	case RoundCeiling:
+		return "ceiling"
+	}
+	return "unknown"
+}
+
+// Div returns d ÷ e, rounded by mode to the given scale. Returns error if e is
+// 0.
+func (d Decimal) Div(e Decimal, scale int, mode RoundingMode) (Decimal, error) {
+	if e.IsZero() {
+		return Decimal{}, errors.New(nil, "division by zero")
+	}
+	// d ÷ e = (d.coef × 10^(scale + e.scale - d.scale) ÷ e.coef) × 10^-scale.
+	num := new(big.Int).Set(d.bigCoef())
+	den := new(big.Int).Set(e.bigCoef())
+	if shift := scale + e.scale - d.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return normal(quo(num, den, mode), scale), nil
+}
+
+// Round returns d rounded by mode to the given scale, which may be negative to
+// round to a multiple of a power of ten. If scale is greater than the scale of
+// d, the result is d with trailing zeros added.
+func (d Decimal) Round(scale int, mode RoundingMode) Decimal {
+	if scale >= d.scale {
+		coef := new(big.Int).Mul(d.bigCoef(), pow10(scale-d.scale))
+		return normal(coef, scale)
+	}
+	coef := quo(new(big.Int).Set(d.bigCoef()), pow10(d.scale-scale), mode)
+	return normal(coef, scale)
+}
+
+// quo returns num ÷ den, rounded to an integer by mode. The value of num is
+// overwritten.
+func quo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := num.QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// sign is the direction away from zero of the exact quotient.
+	sign := int64(r.Sign() * den.Sign())
+	away := false
+	switch mode {
+	case RoundHalfEven, RoundHalfUp:
+		half := r.Abs(r).Lsh(r, 1).Cmp(new(big.Int).Abs(den))
+		away = half > 0 || half == 0 && (mode == RoundHalfUp || q.Bit(0) == 1)
+	case RoundUp:
+		away = true
+	case RoundFloor:
+		away = sign < 0
+	case RoundCeiling:
+		away = sign > 0
+	}
+	if away {
+		q.Add(q, big.NewInt(sign))
+	}
+	return q
+}
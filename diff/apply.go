@@ -0,0 +1,197 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// hunk is a hunk of a unified diff, replacing the lines old, starting at
+// line start of the original text, with the lines new.
+type hunk struct {
+	start int // 0-based index of the first line of old
+	old   []string
+	new   []string
+}
+
+// Apply applies the unified diff patch to text, and returns the patched text.
+// The patch must describe changes to a single file, as returned by Unified; any
+// lines before its first hunk, such as file names, are ignored. As with patch,
+// a hunk whose lines are not found at the position given may be applied at
+// the nearest position at which they are found, after the previous hunk.
+//
+// Returns error if the patch is malformed, or if a hunk cannot be applied
+// because its context or deleted lines are not found in text.
+func Apply(text, patch string) (string, error) {
+	hunks, err := parsePatch(patch)
+	if err != nil {
+		return "", errors.New(err, "invalid patch")
+	}
+	lines := splitLines(text)
+	var out []string
+	pos, offset := 0, 0
+	for i, h := range hunks {
+		at := find(lines, h.old, h.start+offset, pos)
+		if at < 0 {
+			return "", errors.New(nil, "cannot apply hunk %d at line %d: text does not match", i+1, h.start+1)
+		}
+		out = append(out, lines[pos:at]...)
+		out = append(out, h.new...)
+		pos = at + len(h.old)
+		offset = at - h.start
+	}
+	out = append(out, lines[pos:]...)
+	return strings.Join(out, ""), nil
+}
+
+// find returns the index in lines nearest to want, and not before from, at
+// which lines old are found, or -1 if they are not found.
+func find(lines, old []string, want, from int) int {
+	last := len(lines) - len(old)
+	if want > last {
+		want = last
+	}
+	if want < from {
+		want = from
+	}
+	for d := 0; want-d >= from || want+d <= last; d++ {
+		for _, at := range []int{want - d, want + d} {
+			if at >= from && at <= last && equal(lines[at:at+len(old)], old) {
+				return at
+			}
+		}
+	}
+	return -1
+}
+
+func equal(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePatch(patch string) ([]hunk, error) {
+	lines := splitLines(patch)
+	var hunks []hunk
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "--- ") && len(hunks) > 0 {
+			return nil, errors.New(nil, "line %d: patch changes more than one file", i+1)
+		}
+		if !strings.HasPrefix(line, "@@ ") {
+			continue
+		}
+		h, oldN, newN, err := parseHeader(line)
+		if err != nil {
+			return nil, errors.New(err, "line %d", i+1)
+		}
+		// both reports whether the last line read belongs to old and new.
+		both := false
+		for oldN > 0 || newN > 0 || i+1 < len(lines) && strings.HasPrefix(lines[i+1], `\`) {
+			i++
+			if i == len(lines) {
+				return nil, errors.New(nil, "line %d: hunk is truncated", i)
+			}
+			line := lines[i]
+			// Some tools strip the space from empty context lines.
+			if line == "\n" || line == "\r\n" {
+				line = " " + line
+			}
+			switch line[0] {
+			case ' ':
+				if oldN == 0 || newN == 0 {
+					return nil, errors.New(nil, "line %d: hunk is longer than its header states", i+1)
+				}
+				h.old = append(h.old, line[1:])
+				h.new = append(h.new, line[1:])
+				oldN--
+				newN--
+				both = true
+			case '-':
+				if oldN == 0 {
+					return nil, errors.New(nil, "line %d: hunk is longer than its header states", i+1)
+				}
+				h.old = append(h.old, line[1:])
+				oldN--
+				both = false
+			case '+':
+				if newN == 0 {
+					return nil, errors.New(nil, "line %d: hunk is longer than its header states", i+1)
+				}
+				h.new = append(h.new, line[1:])
+				newN--
+				both = false
+			case '\\':
+				// The previous line has no terminator.
+				prev := lines[i-1]
+				if prev[0] == '-' || both {
+					trimNewline(h.old)
+				}
+				if prev[0] == '+' || both {
+					trimNewline(h.new)
+				}
+			default:
+				return nil, errors.New(nil, "line %d: unexpected line in hunk", i+1)
+			}
+		}
+		if len(hunks) > 0 {
+			if prev := hunks[len(hunks)-1]; h.start < prev.start+len(prev.old) {
+				return nil, errors.New(nil, "line %d: hunks overlap or are out of order", i+1)
+			}
+		}
+		hunks = append(hunks, h)
+	}
+	return hunks, nil
+}
+
+// parseHeader parses a hunk header, such as "@@ -1,3 +1,4 @@", and returns
+// the hunk with its start set, along with the number of old and new lines.
+func parseHeader(line string) (h hunk, oldN, newN int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[3] != "@@" ||
+		!strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return h, 0, 0, errors.New(nil, "invalid hunk header %q", strings.TrimSpace(line))
+	}
+	start, oldN, err := parseRange(fields[1][1:])
+	if err != nil {
+		return h, 0, 0, errors.New(err, "invalid hunk header %q", strings.TrimSpace(line))
+	}
+	_, newN, err = parseRange(fields[2][1:])
+	if err != nil {
+		return h, 0, 0, errors.New(err, "invalid hunk header %q", strings.TrimSpace(line))
+	}
+	// The start of an empty range is the line after which it falls.
+	if oldN > 0 {
+		start--
+	}
+	if start < 0 {
+		return h, 0, 0, errors.New(nil, "invalid hunk header %q", strings.TrimSpace(line))
+	}
+	return hunk{start: start}, oldN, newN, nil
+}
+
+// parseRange parses a range "start,n", or "start" if n is 1.
+func parseRange(s string) (start, n int, err error) {
+	first, count, hasCount := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(first); err != nil || start < 0 {
+		return 0, 0, errors.New(nil, "invalid range %q", s)
+	}
+	n = 1
+	if hasCount {
+		if n, err = strconv.Atoi(count); err != nil || n < 0 {
+			return 0, 0, errors.New(nil, "invalid range %q", s)
+		}
+	}
+	return start, n, nil
+}
+
+func trimNewline(lines []string) {
+	if len(lines) > 0 {
+		last := &lines[len(lines)-1]
+		*last = strings.TrimSuffix(*last, "\n")
+	}
+}
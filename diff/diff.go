@@ -0,0 +1,178 @@
+// Package diff implements the comparison of texts by line and by word.
+//
+// Unified returns the differences between two texts as a unified diff, as
+// produced by diff -u and accepted by patch, and Apply applies such a diff:
+//
+//	patch := diff.Unified("a/config", "b/config", old, new, 3)
+//	fmt.Print(diff.Colorize(patch, ansi.DefaultProfile))
+//	...
+//	text, err := diff.Apply(old, patch)
+//
+// Lines and Words return the edits transforming one text into another, line
+// by line or word by word, which HTML and ANSI render with deleted and inserted
+// text marked:
+//
+//	fmt.Println(diff.ANSI(diff.Words("the quick fox", "the slow fox"), ansi.DefaultProfile))
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"git.sr.ht/~kvo/go-std/slices"
+)
+
+// Op is the kind of operation performed by an Edit.
+type Op = slices.Op
+
+// Edit is an operation in the transformation of one text into another, whose
+// Value is a line or word of text.
+type Edit = slices.Edit[string]
+
+// The operations performed by an Edit.
+const (
+	Keep   = slices.Keep
+	Insert = slices.Insert
+	Delete = slices.Delete
+)
+
+// Lines returns a minimal sequence of edits transforming text a into text b,
+// line by line. The Value of each edit is a line including its terminator, if
+// any, so that the values of the Keep and Insert edits concatenate to b, and
+// those of the Keep and Delete edits concatenate to a.
+func Lines(a, b string) []Edit {
+	return slices.Diff(splitLines(a), splitLines(b))
+}
+
+// Unified returns a unified diff transforming text a, named aName, into text
+// b, named bName, showing context unchanged lines around each change. A line
+// which lacks a terminator at the end of either text is followed by the line
+// "\ No newline at end of file". A negative context is taken as zero. Returns
+// the empty string if a and b are equal.
+func Unified(aName, bName, a, b string, context int) string {
+	if context < 0 {
+		context = 0
+	}
+	edits := Lines(a, b)
+	// oldPos and newPos hold the line index in a and b at each edit.
+	oldPos := make([]int, len(edits)+1)
+	newPos := make([]int, len(edits)+1)
+	var changes []int
+	for i, e := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if e.Op != Insert {
+			oldPos[i+1]++
+		}
+		if e.Op != Delete {
+			newPos[i+1]++
+		}
+		if e.Op != Keep {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	for i := 0; i < len(changes); {
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[i] + context + 1
+		for i++; i < len(changes) && changes[i]-context <= end; i++ {
+			end = changes[i] + context + 1
+		}
+		if end > len(edits) {
+			end = len(edits)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(oldPos[start], oldPos[end]-oldPos[start]),
+			hunkRange(newPos[start], newPos[end]-newPos[start]),
+		)
+		for _, e := range edits[start:end] {
+			switch e.Op {
+			case Keep:
+				sb.WriteByte(' ')
+			case Delete:
+				sb.WriteByte('-')
+			case Insert:
+				sb.WriteByte('+')
+			}
+			sb.WriteString(e.Value)
+			if !strings.HasSuffix(e.Value, "\n") {
+				sb.WriteString("\n" + noNewline + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Words returns a minimal sequence of edits transforming text a into text b,
+// word by word. The texts are split into words of letters, digits and
+// underscores, runs of white space, and single other characters, so that the
+// values of the Keep and Insert edits concatenate to b, and those of the Keep
+// and Delete edits concatenate to a.
+func Words(a, b string) []Edit {
+	return slices.Diff(splitWords(a), splitWords(b))
+}
+
+// noNewline marks a line without a terminator in a unified diff.
+const noNewline = `\ No newline at end of file`
+
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// splitLines splits s into lines, each including its terminator, if any.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func splitWords(s string) []string {
+	var words []string
+	for s != "" {
+		r, n := utf8.DecodeRuneInString(s)
+		class := wordClass(r)
+		if class != 0 {
+			for n < len(s) {
+				r, size := utf8.DecodeRuneInString(s[n:])
+				if wordClass(r) != class {
+					break
+				}
+				n += size
+			}
+		}
+		words = append(words, s[:n])
+		s = s[n:]
+	}
+	return words
+}
+
+// wordClass returns 1 for characters of words, 2 for white space, and 0 for
+// other characters, which stand alone.
+func wordClass(r rune) int {
+	switch {
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return 1
+	case unicode.IsSpace(r):
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+package diff
+
+import (
+	"html"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/term/ansi"
+)
+
+var (
+	deleteStyle = ansi.Style{FG: ansi.Red}
+	insertStyle = ansi.Style{FG: ansi.Green}
+	headerStyle = ansi.Style{Bold: true}
+	hunkStyle   = ansi.Style{FG: ansi.Cyan}
+)
+
+// ANSI renders edits as text in which deleted text is shown in red and
+// inserted text in green, with colors as supported by p. If p is NoColor,
+// deleted text is instead enclosed in [- and -], and inserted text in {+ and
+// +}, as by git diff --word-diff.
+func ANSI(edits []Edit, p ansi.Profile) string {
+	var sb strings.Builder
+	for _, run := range runs(edits) {
+		switch {
+		case run.op == Keep:
+			sb.WriteString(run.text)
+		case p == ansi.NoColor && run.op == Delete:
+			sb.WriteString("[-" + run.text + "-]")
+		case p == ansi.NoColor && run.op == Insert:
+			sb.WriteString("{+" + run.text + "+}")
+		case run.op == Delete:
+			sb.WriteString(styleLines(p, deleteStyle, run.text))
+		case run.op == Insert:
+			sb.WriteString(styleLines(p, insertStyle, run.text))
+		}
+	}
+	return sb.String()
+}
+
+// Colorize returns the unified diff patch with its lines colored as supported
+// by p: file names and other lines outside hunks in bold, hunk headers in cyan,
+// deleted lines in red and inserted lines in green. If p is NoColor, patch is
+// returned unchanged.
+func Colorize(patch string, p ansi.Profile) string {
+	if p == ansi.NoColor {
+		return patch
+	}
+	lines := splitLines(patch)
+	// oldN and newN count the old and new lines remaining in the hunk.
+	oldN, newN := 0, 0
+	for i, line := range lines {
+		var s ansi.Style
+		switch {
+		case oldN > 0 && newN > 0 && (line[0] == ' ' || line == "\n" || line == "\r\n"):
+			oldN--
+			newN--
+		case oldN > 0 && line[0] == '-':
+			s = deleteStyle
+			oldN--
+		case newN > 0 && line[0] == '+':
+			s = insertStyle
+			newN--
+		case line[0] == '\\':
+		case strings.HasPrefix(line, "@@ "):
+			s = hunkStyle
+			_, oldN, newN, _ = parseHeader(line)
+		default:
+			s = headerStyle
+			oldN, newN = 0, 0
+		}
+		lines[i] = styleLines(p, s, line)
+	}
+	return strings.Join(lines, "")
+}
+
+// HTML renders edits as HTML, in which deleted text is enclosed in a del
+// element and inserted text in an ins element. All text is escaped.
+func HTML(edits []Edit) string {
+	var sb strings.Builder
+	for _, run := range runs(edits) {
+		text := html.EscapeString(run.text)
+		switch run.op {
+		case Keep:
+			sb.WriteString(text)
+		case Delete:
+			sb.WriteString("<del>" + text + "</del>")
+		case Insert:
+			sb.WriteString("<ins>" + text + "</ins>")
+		}
+	}
+	return sb.String()
+}
+
+// run is the text of consecutive edits of the same operation.
+type run struct {
+	op   Op
+	text string
+}
+
+// runs joins consecutive edits of the same operation. Where deletions and
+// insertions alternate, as where words are replaced one for one, all the
+// deletions of a change are given before all its insertions.
+func runs(edits []Edit) []run {
+	var rs []run
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Keep {
+			j := i
+			var sb strings.Builder
+			for ; j < len(edits) && edits[j].Op == Keep; j++ {
+				sb.WriteString(edits[j].Value)
+			}
+			rs = append(rs, run{Keep, sb.String()})
+			i = j
+			continue
+		}
+		var del, ins strings.Builder
+		j := i
+		for ; j < len(edits) && edits[j].Op != Keep; j++ {
+			if edits[j].Op == Delete {
+				del.WriteString(edits[j].Value)
+			} else {
+				ins.WriteString(edits[j].Value)
+			}
+		}
+		if del.Len() > 0 {
+			rs = append(rs, run{Delete, del.String()})
+		}
+		if ins.Len() > 0 {
+			rs = append(rs, run{Insert, ins.String()})
+		}
+		i = j
+	}
+	return rs
+}
+
+// styleLines returns text in style s, styling each line separately so that
+// line terminators are left unstyled.
+func styleLines(p ansi.Profile, s ansi.Style, text string) string {
+	lines := splitLines(text)
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\r\n")
+		if body != "" {
+			lines[i] = p.Wrap(s, body) + line[len(body):]
+		}
+	}
+	return strings.Join(lines, "")
+}
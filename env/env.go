@@ -0,0 +1,135 @@
+// Package env implements the decoding of environment variables into structs.
+//
+// Decode populates the fields of a struct from environment variables named by
+// the fields' env tags. A field may also have a default value, used when its
+// variable is unset, and may be marked as required:
+//
+//	var cfg struct {
+//		Addr    string        `env:"ADDR" default:":8080"`
+//		Debug   bool          `env:"DEBUG"`
+//		Timeout time.Duration `env:"TIMEOUT" default:"30s"`
+//		Hosts   []string      `env:"HOSTS,required"`
+//		DB      struct {
+//			URL  string `env:"URL,required"`
+//			Pool int    `env:"POOL" default:"4"`
+//		} `env:"DB"`
+//	}
+//	err := env.Decode(&cfg)
+//
+// A nested struct whose field has an env tag gives a prefix to the names of
+// its variables, joined by an underscore, so that cfg.DB.URL above is read
+// from DB_URL. A nested struct without an env tag is decoded without a prefix.
+// Fields of other types without an env tag are ignored.
+//
+// Fields may be strings, booleans, integers, floating-point numbers, durations,
+// RFC 3339 times, types implementing encoding.TextUnmarshaler, pointers to any
+// of these, or slices of any of these. Slice elements are separated by commas,
+// unless another separator is given with a sep tag, as in `sep:";"`.
+//
+// Decode does not stop at the first problem, but returns a single error
+// describing every missing or invalid variable.
+package env
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/convert"
+)
+
+// Decode populates the struct pointed to by v from the environment of the
+// current process. Returns error if v is not a pointer to a struct, if a
+// required variable is unset, or if a variable's value cannot be converted to
+// the type of its field.
+func Decode(v any) error {
+	return DecodeFunc(v, os.LookupEnv)
+}
+
+// DecodeFunc is like Decode, but looks up the value of each variable using
+// lookup rather than the environment of the current process.
+func DecodeFunc(v any, lookup func(name string) (string, bool)) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New(nil, "cannot decode environment into %T", v)
+	}
+	d := decoder{lookup: lookup}
+	d.decode(rv.Elem(), "")
+	if len(d.errs) > 0 {
+		return errors.New(errors.Join(d.errs...), "cannot decode environment")
+	}
+	return nil
+}
+
+type decoder struct {
+	lookup func(string) (string, bool)
+	errs   []error
+}
+
+func (d *decoder) decode(v reflect.Value, prefix string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, hasTag := f.Tag.Lookup("env")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Type.Kind() == reflect.Struct && !convert.Supported(f.Type) {
+			nested := prefix
+			if name != "" {
+				nested += name + "_"
+			}
+			d.decode(fv, nested)
+			continue
+		}
+		if !hasTag || name == "" {
+			continue
+		}
+		name = prefix + name
+		if !convert.Supported(f.Type) {
+			d.errs = append(d.errs, errors.New(nil,
+				"unsupported type %s for %s", f.Type, name,
+			))
+			continue
+		}
+		sep := ","
+		if s, ok := f.Tag.Lookup("sep"); ok {
+			sep = s
+		}
+		val, ok := d.lookup(name)
+		if !ok {
+			def, hasDef := f.Tag.Lookup("default")
+			switch {
+			case hasDef:
+				val = def
+			case hasOpt(opts, "required"):
+				d.errs = append(d.errs, errors.New(nil,
+					"missing required variable %s", name,
+				))
+				continue
+			default:
+				continue
+			}
+		}
+		if err := convert.Set(fv, val, sep); err != nil {
+			d.errs = append(d.errs, errors.New(err,
+				"invalid value %q for %s", val, name,
+			))
+		}
+	}
+}
+
+func hasOpt(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if strings.TrimSpace(o) == opt {
+			return true
+		}
+	}
+	return false
+}
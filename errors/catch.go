@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// call is a single function call in the stack of a goroutine.
+type call struct {
+	addr uintptr
+	fn   string
+	file string
+	line int
+}
+
+// Catch calls fn and returns its error. If fn panics, Catch recovers, and
+// returns an error describing the panic as by Recover.
+//
+//	err := errors.Catch(func() error {
+//		return handle(req)
+//	})
+func Catch(fn func() error) (err error) {
+	defer Recover(&err)
+	return fn()
+}
+
+// Recover recovers from a panic, if any, and stores an error describing it in
+// *errp, replacing any error already there. It must be called directly by a
+// deferred statement, with a pointer to the named error result of the function:
+//
+//	func f() (err error) {
+//		defer errors.Recover(&err)
+//		...
+//	}
+//
+// The origin of the error is the site of the panic, and its text is "panic".
+// If the panic value is an error, it is the parent of the error; otherwise,
+// the panic value is included in the text. Trace writes the calls on the stack
+// below the site of the panic beneath the error.
+func Recover(errp *error) {
+	if r := recover(); r != nil {
+		*errp = panicError(r)
+	}
+}
+
+// panicError returns an error describing the panic value r, whose origin is
+// the site of the panic. It must be called by a function deferred during the
+// panic.
+func panicError(r any) error {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	// The panics of Must and Must2 are reported at the site of their call.
+	self, _, _, _ := runtime.Caller(0)
+	must := strings.TrimSuffix(runtime.FuncForPC(self).Name(), "panicError") + "Must"
+	var calls []call
+	panicking := false
+	for {
+		f, more := frames.Next()
+		switch {
+		case f.Function == "runtime.gopanic":
+			panicking = true
+		case len(calls) == 0 && strings.HasPrefix(f.Function, must):
+		case panicking && !strings.HasPrefix(f.Function, "runtime."):
+			calls = append(calls, call{f.PC, f.Function, f.File, f.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	e := Error{gid: goroutine(), text: "panic", time: time.Now()}
+	if err, ok := r.(error); ok {
+		e.parent = err
+	} else {
+		e.text = fmt.Sprintf("panic: %v", r)
+	}
+	if len(calls) > 0 {
+		c := calls[0]
+		e.addr, e.fn, e.file, e.line = c.addr, c.fn, c.file, c.line
+		stack := calls[1:]
+		e.stack = &stack
+	}
+	return e
+}
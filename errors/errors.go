@@ -4,7 +4,8 @@
 // description of the error and contextual information. This contextual
 // information can be used to trace back the source of the error. The Trace
 // function can be used to write traceback information to an io.Writer in a
-// human-friendly format.
+// human-friendly format, and the TraceJSON and TraceLogfmt functions write the
+// same information in machine-readable formats for log aggregators.
 //
 // Errors are frequently caused by other errors. To account for this, the New
 // function takes two sets of parameters: one representing a textual description
@@ -21,6 +22,10 @@
 //
 //	errors.Raise(EOF)
 //
+// Helper functions which create or raise errors on behalf of their callers can
+// use NewSkip and RaiseSkip to record the origin of a caller further up the
+// stack instead.
+//
 // A function which handles errors from multiple concurrently executing
 // processes should, in most cases, return the first error it receives:
 //
@@ -38,16 +43,45 @@
 //	}
 //
 // Alternatively, if the developer's intent is to return all received errors,
-// the Join function is provided to return all errors as one. However, this
-// removes the context behind the combined errors.
+// the Join function is provided to return all errors as one. The combined
+// errors are retained, along with their context, and are considered by Has, Is
+// and Trace.
+//
+// A Group runs goroutines and combines their errors in this way, recording the
+// site at which each failing goroutine was started.
+//
+// Machine-readable context, such as the ID of a request or the path of a file,
+// can be attached to an error as it propagates with Annotate, and retrieved
+// with the Fields method of Error:
+//
+//	err = errors.Annotate(err, "request", id)
+//
+// Error implements the Unwrap method, returning its parent error, so that
+// errors created by this package can be inspected by the standard library's
+// errors.Is and errors.As. Conversely, Has, Is and As in this package follow
+// Unwrap methods of errors created elsewhere, so that code using either
+// package can be migrated incrementally:
+//
+//	if perr, ok := errors.As[*fs.PathError](err); ok {
+//		fmt.Println(perr.Path)
+//	}
 package errors
 
 import (
+	"bytes"
+	stderrors "errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/internal/width"
 )
 
 // Error represents an error. The Error type holds a textual description of the
@@ -62,11 +96,23 @@ import (
 // functions in this package, never directly.
 type Error struct {
 	addr   uintptr
+	fields *field
 	file   string
 	fn     string
+	gid    uint64
 	line   int
 	parent error
+	stack  *[]call // calls below the site of a panic, as recovered by Recover
 	text   string
+	time   time.Time
+}
+
+// field is an annotation of an Error. Annotations form an immutable list, most
+// recent first, so that Error values remain comparable and cheap to copy.
+type field struct {
+	key   string
+	value any
+	next  *field
 }
 
 func (e Error) Addr() uintptr {
@@ -84,7 +130,9 @@ func (e Error) Error() string {
 				next = false
 				text += t.Text()
 			} else {
-				text += t.Text() + ": "
+				if t.Text() != "" {
+					text += t.Text() + ": "
+				}
 				err = t.Parent()
 			}
 		case error:
@@ -95,6 +143,31 @@ func (e Error) Error() string {
 	return text
 }
 
+// Fields returns the annotations added to e and to its parent errors by
+// Annotate. An annotation of e takes precedence over one with the same key on
+// a parent error, and a later annotation over an earlier one. Returns nil if
+// there are no annotations.
+func (e Error) Fields() map[string]any {
+	var m map[string]any
+	var err error = e
+	for err != nil {
+		t, ok := err.(Error)
+		if !ok {
+			break
+		}
+		for f := t.fields; f != nil; f = f.next {
+			if m == nil {
+				m = make(map[string]any)
+			}
+			if _, ok := m[f.key]; !ok {
+				m[f.key] = f.value
+			}
+		}
+		err = t.parent
+	}
+	return m
+}
+
 func (e Error) File() string {
 	return e.file
 }
@@ -103,18 +176,43 @@ func (e Error) Func() string {
 	return e.fn
 }
 
-func (e Error) raise() error {
+func (e Error) raise(skip int) error {
 	err := e
-	addr, file, line, _ := runtime.Caller(2)
+	addr, file, line, _ := runtime.Caller(skip + 3)
 	f := runtime.FuncForPC(addr)
 	fn := f.Name()
 	err.addr = addr
 	err.file = file
 	err.fn = fn
+	err.gid = goroutine()
 	err.line = line
+	err.time = time.Now()
 	return err
 }
 
+// annotations returns the annotations of e alone, latest value per key, in
+// order of key.
+func (e Error) annotations() []field {
+	var fs []field
+	seen := make(map[string]bool)
+	for f := e.fields; f != nil; f = f.next {
+		if !seen[f.key] {
+			seen[f.key] = true
+			fs = append(fs, *f)
+		}
+	}
+	sort.Slice(fs, func(i, j int) bool {
+		return fs[i].key < fs[j].key
+	})
+	return fs
+}
+
+// Goroutine returns the identifier of the goroutine on which e was created or
+// last raised, as shown in the stack traces of the runtime, or 0 if unknown.
+func (e Error) Goroutine() uint64 {
+	return e.gid
+}
+
 func (e Error) Line() int {
 	return e.line
 }
@@ -127,13 +225,84 @@ func (e Error) Text() string {
 	return e.text
 }
 
+// Time returns the time at which e was created or last raised, or the zero
+// time if unknown.
+func (e Error) Time() time.Time {
+	return e.time
+}
+
+// Unwrap returns the parent error of e, as expected by the standard library's
+// errors.Unwrap.
+func (e Error) Unwrap() error {
+	return e.parent
+}
+
+// Annotate returns err annotated with the given key and value, which are
+// reported by Fields and printed by Trace. If err is an Error, the result keeps
+// its origin; otherwise, err is wrapped as by Wrap. Annotating an error again
+// with the same key replaces the earlier value. Returns nil if err is nil.
+//
+//	return errors.Annotate(err, "user", id)
+func Annotate(err error, key string, value any) error {
+	if err == nil {
+		return nil
+	}
+	e, ok := err.(Error)
+	if !ok {
+		addr, file, line, _ := runtime.Caller(1)
+		f := runtime.FuncForPC(addr)
+		e = Error{
+			addr:   addr,
+			file:   file,
+			fn:     f.Name(),
+			gid:    goroutine(),
+			line:   line,
+			parent: err,
+			time:   time.Now(),
+		}
+	}
+	e.fields = &field{key, value, e.fields}
+	return e
+}
+
+// As finds the first error in the chain formed by err and its parent errors
+// which is of type T, and returns it. Errors created elsewhere are followed
+// through their Unwrap methods, as by the standard library's errors.As.
+func As[T error](err error) (T, bool) {
+	var t T
+	ok := stderrors.As(err, &t)
+	return t, ok
+}
+
+// Chain returns err followed by each of its parent errors, most recent first,
+// ending with the error returned by Root. Errors created elsewhere are followed
+// through their Unwrap methods. Returns nil if err is nil.
+//
+//	for _, e := range errors.Chain(err) {
+//		if t, ok := e.(errors.Error); ok {
+//			fmt.Println(t.Text(), t.File(), t.Line())
+//		}
+//	}
+func Chain(err error) []error {
+	var errs []error
+	for err != nil {
+		errs = append(errs, err)
+		err = parent(err)
+	}
+	return errs
+}
+
 // Has reports whether the textual error description of err or any of its parent
-// errors match the textual error description of target.
+// errors match the textual error description of target. Errors created
+// elsewhere are followed through their Unwrap methods, and every error combined
+// by Join is searched. Failing a textual match, Has reports whether target is
+// itself found among err and its parent errors, as by the standard library's
+// errors.Is.
 func Has(err, target error) bool {
 	text := ""
 	if err == nil && target == nil {
 		return true
-	} else if target == nil {
+	} else if err == nil || target == nil {
 		return false
 	}
 	switch t := target.(type) {
@@ -142,35 +311,70 @@ func Has(err, target error) bool {
 	case error:
 		text, _, _ = strings.Cut(t.Error(), ": ")
 	}
-	for {
+	return hasText(err, text) || stderrors.Is(err, target)
+}
+
+// hasText reports whether the textual error description of err or any of its
+// parent errors is text.
+func hasText(err error, text string) bool {
+	for err != nil {
 		switch t := err.(type) {
 		case Error:
 			if t.Text() == text {
 				return true
 			}
 			err = t.Parent()
-			if err == nil {
-				return false
+		case interface{ Unwrap() []error }:
+			if utext, _, _ := strings.Cut(err.Error(), ": "); utext == text {
+				return true
 			}
-		case error:
-			utext, _, _ := strings.Cut(err.Error(), ": ")
-			if utext == text {
+			for _, e := range t.Unwrap() {
+				if hasText(e, text) {
+					return true
+				}
+			}
+			return false
+		case interface{ Unwrap() error }:
+			if utext, _, _ := strings.Cut(err.Error(), ": "); utext == text {
 				return true
-			} else {
-				return false
 			}
+			err = t.Unwrap()
+		default:
+			utext, _, _ := strings.Cut(err.Error(), ": ")
+			return utext == text
 		}
 	}
+	return false
 }
 
 // Is reports whether the textual error description of err matches the textual
-// error description of target.
+// error description of target. If err combines several errors, as returned by
+// Join, Is also reports whether any of them matches target. Failing a textual
+// match, Is reports whether target is err or is found among its parent errors,
+// as by the standard library's errors.Is, so that a wrapped sentinel error such
+// as fs.ErrNotExist is recognised.
 func Is(err, target error) bool {
 	if err == nil && target == nil {
 		return true
 	} else if err == nil || target == nil {
 		return false
 	}
+	if sameText(err, target) {
+		return true
+	}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range j.Unwrap() {
+			if e != nil && Is(e, target) {
+				return true
+			}
+		}
+	}
+	return stderrors.Is(err, target)
+}
+
+// sameText reports whether the textual error descriptions of err and target
+// match.
+func sameText(err, target error) bool {
 	switch t := err.(type) {
 	case Error:
 		switch u := target.(type) {
@@ -192,6 +396,19 @@ func Is(err, target error) bool {
 	return utext == vtext
 }
 
+// IsTemporary reports whether err or any of its parent errors has a method
+// Temporary() bool which returns true, as implemented by errors such as
+// network timeouts that may not recur if an operation is retried.
+func IsTemporary(err error) bool {
+	for err != nil {
+		if t, ok := err.(interface{ Temporary() bool }); ok && t.Temporary() {
+			return true
+		}
+		err = parent(err)
+	}
+	return false
+}
+
 // New returns an error whose textual error description is given by
 // fmt.Sprintf(format, a...) and whose parent error is err. If the new error has
 // no parent, err should be given as nil.
@@ -210,56 +427,43 @@ func New(err error, format string, a ...any) error {
 		addr:   addr,
 		file:   file,
 		fn:     fn,
+		gid:    goroutine(),
 		line:   line,
 		parent: err,
 		text:   fmt.Sprintf(format, a...),
+		time:   time.Now(),
 	}
 }
 
-// Wrap is equivalent to New(err, "") in every way. Useful for maintaining
-// details in error stack traces without compromising visual aesthetics.
-func Wrap(err error) error {
-	addr, file, line, _ := runtime.Caller(1)
+// NewSkip is like New, except that the origin stored within the error is that
+// of a caller further up the stack. The argument skip is the number of stack
+// frames to ascend, with 0 identifying the caller of NewSkip, so that
+// NewSkip(err, 0, format, a...) is equivalent to New(err, format, a...). It
+// allows helper functions which create errors to report the origin of their
+// own callers:
+//
+//	func notFound(name string) error {
+//		return errors.NewSkip(nil, 1, "%s not found", name)
+//	}
+func NewSkip(err error, skip int, format string, a ...any) error {
+	addr, file, line, _ := runtime.Caller(skip + 1)
 	f := runtime.FuncForPC(addr)
 	fn := f.Name()
 	return Error{
 		addr:   addr,
 		file:   file,
 		fn:     fn,
+		gid:    goroutine(),
 		line:   line,
 		parent: err,
-		text:   "",
+		text:   fmt.Sprintf(format, a...),
+		time:   time.Now(),
 	}
 }
 
-// Join returns an error that combines the given errs. Any nil error values
-// are discarded. Join returns nil if errs contains no non-nil values. The
-// resultant error is formatted as a concatenation of the textual error
-// descriptions of all given errs, with a comma and space between each
-// description.
-//
-// An error can only have one parent, so the resultant error has nil parent.
-func Join(errs ...error) error {
-	first := true
-	var text string
-	for _, err := range errs {
-		if err != nil {
-			if first {
-				first = false
-			} else {
-				text += ", "
-			}
-			switch t := err.(type) {
-			case Error:
-				text += t.Text()
-			case error:
-				text += t.Error()
-			}
-		}
-	}
-	if text == "" {
-		return nil
-	}
+// Wrap is equivalent to New(err, "") in every way. Useful for maintaining
+// details in error stack traces without compromising visual aesthetics.
+func Wrap(err error) error {
 	addr, file, line, _ := runtime.Caller(1)
 	f := runtime.FuncForPC(addr)
 	fn := f.Name()
@@ -267,47 +471,244 @@ func Join(errs ...error) error {
 		addr:   addr,
 		file:   file,
 		fn:     fn,
+		gid:    goroutine(),
 		line:   line,
-		parent: nil,
-		text:   text,
+		parent: err,
+		text:   "",
+		time:   time.Now(),
 	}
 }
 
 // Raise returns the error equivalent to err whose program origin details (file,
 // name line number, etc.) are overridden with those of the caller of Raise.
 func Raise(err error) error {
+	return raise(err, 0)
+}
+
+// RaiseSkip is like Raise, except that the origin details are overridden with
+// those of a caller further up the stack. The argument skip is the number of
+// stack frames to ascend, with 0 identifying the caller of RaiseSkip, so that
+// RaiseSkip(err, 0) is equivalent to Raise(err). It allows helper functions
+// which raise predefined errors to report the origin of their own callers:
+//
+//	func checkOpen(f *File) error {
+//		if f.closed {
+//			return errors.RaiseSkip(ErrClosed, 1)
+//		}
+//		return nil
+//	}
+func RaiseSkip(err error, skip int) error {
+	return raise(err, skip)
+}
+
+// Root returns the deepest parent error of err, which is the original cause of
+// err, or err itself if it has no parent. Errors created elsewhere are followed
+// through their Unwrap methods. An error combining several errors, such as one
+// returned by Join, has no single cause, and so is its own root. Returns nil if
+// err is nil.
+func Root(err error) error {
+	for err != nil {
+		p := parent(err)
+		if p == nil {
+			return err
+		}
+		err = p
+	}
+	return nil
+}
+
+// goroutine returns the identifier of the current goroutine, as shown in the
+// stack traces of the runtime, or 0 if it cannot be determined.
+func goroutine() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b, ok := bytes.CutPrefix(b, []byte("goroutine "))
+	if !ok {
+		return 0
+	}
+	i := bytes.IndexByte(b, ' ')
+	if i < 0 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(b[:i]), 10, 64)
+	return id
+}
+
+// parent returns the parent error of err, or nil if it has none.
+func parent(err error) error {
+	switch t := err.(type) {
+	case Error:
+		return t.Parent()
+	case interface{ Unwrap() error }:
+		return t.Unwrap()
+	}
+	return nil
+}
+
+// raise implements Raise and RaiseSkip.
+func raise(err error, skip int) error {
 	switch e := err.(type) {
 	case Error:
-		return e.raise()
+		return e.raise(skip)
+	case *JoinError:
+		return e.raise(skip)
 	}
 	return err
 }
 
 // Trace writes human-friendly error traceback information from err to w. If w
-// is nil, Trace writes to the standard error stream.
+// is nil, Trace writes to the standard error stream. Annotations added by
+// Annotate are written in order of key beneath the frame to which they were
+// added, as are the time at which, and the goroutine on which, each error was
+// created. For an error describing a panic, as returned by Recover, the calls on
+// the stack below the site of the panic are written beneath its frame. The
+// errors combined by Join are each traced in turn, indented, before
+// the frame of the call to Join.
+//
+// If w is a terminal, on Unix systems, Trace colors its output using ANSI
+// escape sequences: error text is highlighted, origins are dimmed and link to
+// their files, and error text is wrapped to the width of the terminal. Output
+// to anything else, such as a pipe or file, or to any writer while the
+// NO_COLOR environment variable is set, is plain text.
 func Trace(w io.Writer, err error) {
 	if w == nil {
 		w = os.Stderr
 	}
-	fmt.Fprintln(w, "Traceback (most recent call first):")
-	for {
-		switch t := err.(type) {
+	t := &tracer{w: w}
+	if f, ok := w.(*os.File); ok && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" {
+		t.width, t.color = terminal(f)
+		if t.color {
+			t.host, _ = os.Hostname()
+		}
+	}
+	fmt.Fprintln(w, t.style(bold, "Traceback (most recent call first):"))
+	t.trace(err, "")
+}
+
+// ANSI styles used by Trace on terminals.
+const (
+	bold   = "1"
+	dim    = "2"
+	red    = "1;31"
+	cyan   = "36"
+	yellow = "33"
+)
+
+// tracer writes tracebacks, as described by Trace.
+type tracer struct {
+	w     io.Writer
+	color bool
+	width int // width of the terminal in cells, or 0 if unknown
+	host  string
+}
+
+// trace writes the frames of err and its parent errors, each line prefixed by
+// indent.
+func (t *tracer) trace(err error, indent string) {
+	var errs []error
+	for err != nil {
+		errs = append(errs, err)
+		e, ok := err.(Error)
+		if !ok {
+			break
+		}
+		err = e.Parent()
+	}
+	for i := len(errs) - 1; i >= 0; i-- {
+		switch e := errs[i].(type) {
 		case Error:
-			defer fmt.Fprintf(w, "\t%s:%d\n", t.File(), t.Line())
-			if t.Text() != "" {
-				defer fmt.Fprintf(w, "\t%s\n", t.Text())
+			fmt.Fprintf(t.w, "%s%s(...)\n", indent, t.style(bold, e.Func()))
+			if e.Text() != "" {
+				t.text(indent+"\t", red, e.Text())
 			}
-			defer fmt.Fprintf(w, "%s(...)\n", t.Func())
-			err = t.Parent()
-			if err == nil {
-				return
+			for _, f := range e.annotations() {
+				fmt.Fprintf(t.w, "%s\t\t%s=%v\n", indent, t.style(cyan, f.key), f.value)
 			}
-		case error:
-			if t.Error() != "" {
-				defer fmt.Fprintf(w, "\t%s\n", t.Error())
+			fmt.Fprintf(t.w, "%s\t%s\n", indent, t.origin(e.File(), e.Line()))
+			if !e.Time().IsZero() {
+				fmt.Fprintf(t.w, "%s\t%s\n", indent, t.style(dim, fmt.Sprintf("at %s on goroutine %d",
+					e.Time().Format("2006-01-02 15:04:05.000000"), e.Goroutine())))
+			}
+			if e.stack != nil {
+				for _, c := range *e.stack {
+					fmt.Fprintf(t.w, "%s\t%s %s %s %s\n", indent, t.style(dim, "called from"),
+						c.fn, t.style(dim, "at"), t.origin(c.file, c.line))
+				}
+			}
+		case *JoinError:
+			total := 0
+			for j, err := range e.errs {
+				header := fmt.Sprintf("error %d of %d:", j+1, len(e.errs))
+				if n := e.Count(j); n > 1 {
+					header = fmt.Sprintf("error %d of %d (x%d):", j+1, len(e.errs), n)
+				}
+				fmt.Fprintf(t.w, "%s%s\n", indent, t.style(yellow, header))
+				t.trace(err, indent+"\t")
+				total += e.Count(j)
+			}
+			fmt.Fprintf(t.w, "%s%s(...)\n", indent, t.style(bold, e.Func()))
+			fmt.Fprintf(t.w, "%s\tjoined %d errors\n", indent, total)
+			fmt.Fprintf(t.w, "%s\t%s\n", indent, t.origin(e.File(), e.Line()))
+		default:
+			fmt.Fprintf(t.w, "%sno-context error:\n", indent)
+			if e.Error() != "" {
+				t.text(indent+"\t", red, e.Error())
 			}
-			defer fmt.Fprintf(w, "no-context error:\n")
-			return
 		}
 	}
 }
+
+// style returns s styled by the ANSI style code, if coloring.
+func (t *tracer) style(code, s string) string {
+	if !t.color || s == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// origin returns file:line, dimmed and linked to file, if coloring.
+func (t *tracer) origin(file string, line int) string {
+	s := file + ":" + strconv.Itoa(line)
+	if !t.color {
+		return s
+	}
+	if filepath.IsAbs(file) {
+		u := url.URL{Scheme: "file", Host: t.host, Path: filepath.ToSlash(file)}
+		s = "\x1b]8;;" + u.String() + "\x1b\\" + s + "\x1b]8;;\x1b\\"
+	}
+	return t.style(dim, s)
+}
+
+// text writes the lines of s, each prefixed by indent and styled by code. If
+// coloring, lines are wrapped to fit the width of the terminal.
+func (t *tracer) text(indent, code, s string) {
+	if !t.color {
+		fmt.Fprintf(t.w, "%s%s\n", indent, s)
+		return
+	}
+	avail := t.width - 8*strings.Count(indent, "\t") - 1
+	for _, line := range strings.Split(s, "\n") {
+		if t.width <= 0 || avail < 20 {
+			fmt.Fprintf(t.w, "%s%s\n", indent, t.style(code, line))
+			continue
+		}
+		for _, l := range wrap(line, avail) {
+			fmt.Fprintf(t.w, "%s%s\n", indent, t.style(code, l))
+		}
+	}
+}
+
+// wrap splits s into lines of at most n cells, breaking at spaces where
+// possible.
+func wrap(s string, n int) []string {
+	var lines []string
+	for width.String(s) > n {
+		head, _ := width.Cut(s, n)
+		if i := strings.LastIndexByte(head, ' '); i > 0 {
+			head = head[:i]
+		}
+		lines = append(lines, head)
+		s = strings.TrimLeft(s[len(head):], " ")
+	}
+	return append(lines, s)
+}
@@ -0,0 +1,111 @@
+package errors
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"path"
+	"strings"
+)
+
+// Fingerprint returns a short string identifying the kind of failure described
+// by e, for grouping errors which have the same cause in monitoring and
+// aggregation. The fingerprint is a hash over the text, file name and function
+// name of e and each of its parent errors, and of the errors combined by Join.
+// It excludes line numbers, directories, times and goroutines, and text which
+// varies between occurrences of an error: numbers, hexadecimal values, and
+// quoted strings. Fingerprints are therefore stable across releases of a
+// program, unless the text of an error, or the name of the function or file in
+// which it is created, changes.
+//
+// For example, errors created by the same call to
+//
+//	errors.New(err, "cannot read %q at offset %d", name, off)
+//
+// have the same fingerprint, whatever the values of name and off, as long as
+// their parent errors do too.
+func (e Error) Fingerprint() string {
+	return fingerprint(e)
+}
+
+// Similar reports whether err1 and err2 describe the same kind of failure,
+// having the same fingerprint, as given by Error.Fingerprint. Errors created
+// elsewhere are fingerprinted by their messages.
+func Similar(err1, err2 error) bool {
+	if err1 == nil || err2 == nil {
+		return err1 == err2
+	}
+	return fingerprint(err1) == fingerprint(err2)
+}
+
+// fingerprint returns the fingerprint of err, as described by
+// Error.Fingerprint.
+func fingerprint(err error) string {
+	h := fnv.New64a()
+	hashChain(h, err)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// hashChain writes the fingerprinted parts of err and its parent errors to h.
+func hashChain(h hash.Hash64, err error) {
+	for err != nil {
+		switch e := err.(type) {
+		case Error:
+			h.Write([]byte(normalize(e.Text()) + "\x00" + path.Base(e.File()) + "\x00" + e.Func() + "\x00"))
+			err = e.Parent()
+		case *JoinError:
+			h.Write([]byte("join\x00" + path.Base(e.File()) + "\x00" + e.Func() + "\x00"))
+			for _, err := range e.errs {
+				h.Write([]byte{'('})
+				hashChain(h, err)
+				h.Write([]byte{')'})
+			}
+			return
+		default:
+			h.Write([]byte(normalize(err.Error()) + "\x00"))
+			return
+		}
+	}
+}
+
+// normalize replaces the parts of s which vary between occurrences of an
+// error: each quoted string with "*", and each number, or other word starting
+// with a digit, such as a hexadecimal value, with #.
+func normalize(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case (c == '"' || c == '\'' || c == '`') && (i == 0 || !isWord(s[i-1])):
+			end := i + 1
+			for end < len(s) && s[end] != c {
+				if s[end] == '\\' && c != '`' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				b.WriteByte(c)
+				continue
+			}
+			b.WriteString(`"*"`)
+			i = end
+		case c >= '0' && c <= '9' && (i == 0 || !isWord(s[i-1])):
+			for i+1 < len(s) && (isWord(s[i+1]) || s[i+1] == '.' && i+2 < len(s) && isDigit(s[i+2])) {
+				i++
+			}
+			b.WriteByte('#')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWord(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
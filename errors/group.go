@@ -0,0 +1,106 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Group runs a collection of goroutines and collects the errors they return,
+// recording where each goroutine was started. The zero value of a Group is
+// ready to use.
+//
+//	var g errors.Group
+//	for _, url := range urls {
+//		url := url
+//		g.Go(func() error {
+//			return fetch(url)
+//		})
+//	}
+//	if err := g.Wait(); err != nil {
+//		errors.Trace(nil, err)
+//	}
+type Group struct {
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	n    int
+	errs []groupError
+}
+
+type groupError struct {
+	n   int
+	err error
+}
+
+// Go calls f in a new goroutine. If f returns an error, or panics, the error,
+// as by Catch, is recorded for Wait as the parent of an error whose text
+// identifies the goroutine by the order in which it was started, and whose
+// origin is the call site of Go.
+func (g *Group) Go(f func() error) {
+	g.GoSkip(1, f)
+}
+
+// GoSkip is like Go, except that the origin recorded is that of a caller
+// further up the stack. The argument skip is the number of stack frames to
+// ascend, with 0 identifying the caller of GoSkip, as by NewSkip. It allows
+// types which start goroutines on behalf of their callers, such as conc.Group,
+// to report the call sites of their own callers.
+func (g *Group) GoSkip(skip int, f func() error) {
+	addr, file, line, _ := runtime.Caller(skip + 1)
+	fn := runtime.FuncForPC(addr).Name()
+	g.mu.Lock()
+	g.n++
+	n := g.n
+	g.mu.Unlock()
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		err := Catch(f)
+		if err == nil {
+			return
+		}
+		e := Error{
+			addr:   addr,
+			file:   file,
+			fn:     fn,
+			gid:    goroutine(),
+			line:   line,
+			parent: err,
+			text:   fmt.Sprintf("goroutine %d", n),
+			time:   time.Now(),
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.errs = append(g.errs, groupError{n, e})
+	}()
+}
+
+// Wait waits for every goroutine started by Go to return. Returns nil if none
+// failed, and otherwise a *JoinError combining the errors recorded by Go in the
+// order in which the goroutines were started, so that Trace shows which
+// goroutines failed, where each was started, and why.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.errs) == 0 {
+		return nil
+	}
+	sort.Slice(g.errs, func(i, j int) bool {
+		return g.errs[i].n < g.errs[j].n
+	})
+	errs := make([]error, len(g.errs))
+	for i, e := range g.errs {
+		errs[i] = e.err
+	}
+	addr, file, line, _ := runtime.Caller(1)
+	return &JoinError{
+		addr: addr,
+		file: file,
+		fn:   runtime.FuncForPC(addr).Name(),
+		line: line,
+		errs: errs,
+	}
+}
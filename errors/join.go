@@ -0,0 +1,150 @@
+package errors
+
+import (
+	"runtime"
+	"strconv"
+)
+
+// JoinError combines several errors into one, as returned by Join. Unlike an
+// Error, a JoinError has no parent; instead, it retains each of the errors it
+// combines, which are returned by its Unwrap method, so that Has, Is, Trace and
+// the standard library's errors.Is and errors.As consider every one of them.
+type JoinError struct {
+	addr   uintptr
+	file   string
+	fn     string
+	line   int
+	errs   []error
+	counts []int // occurrences of each of errs, if deduplicated by JoinDedup
+}
+
+func (e *JoinError) Addr() uintptr {
+	return e.addr
+}
+
+// Count returns the number of occurrences of the i'th error returned by Unwrap
+// among the errors given to JoinDedup, or 1 if e was returned by Join.
+func (e *JoinError) Count(i int) int {
+	if e.counts == nil {
+		return 1
+	}
+	return e.counts[i]
+}
+
+// Error returns the messages of the combined errors, including those of their
+// parent errors, with a comma and space between each message. The message of
+// an error which occurred more than once among the errors given to JoinDedup
+// is followed by its number of occurrences, such as "connection refused
+// (x490)".
+func (e *JoinError) Error() string {
+	var text string
+	for i, err := range e.errs {
+		if i > 0 {
+			text += ", "
+		}
+		text += err.Error()
+		if n := e.Count(i); n > 1 {
+			text += " (x" + strconv.Itoa(n) + ")"
+		}
+	}
+	return text
+}
+
+func (e *JoinError) File() string {
+	return e.file
+}
+
+func (e *JoinError) Func() string {
+	return e.fn
+}
+
+func (e *JoinError) Line() int {
+	return e.line
+}
+
+// Unwrap returns the errors combined by e, as expected by the standard
+// library's errors.Is and errors.As. The returned slice must not be modified.
+func (e *JoinError) Unwrap() []error {
+	return e.errs
+}
+
+func (e *JoinError) raise(skip int) error {
+	err := *e
+	addr, file, line, _ := runtime.Caller(skip + 3)
+	err.addr = addr
+	err.file = file
+	err.fn = runtime.FuncForPC(addr).Name()
+	err.line = line
+	return &err
+}
+
+// Join returns an error that combines the given errs. Any nil error values
+// are discarded. Join returns nil if errs contains no non-nil values. The
+// resultant error is formatted as a concatenation of the messages of all given
+// errs, including those of their parent errors, with a comma and space between
+// each message.
+//
+// The resultant error is a *JoinError, which retains the given errs along with
+// their context, and records the origin of the call to Join.
+func Join(errs ...error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+	addr, file, line, _ := runtime.Caller(1)
+	f := runtime.FuncForPC(addr)
+	fn := f.Name()
+	return &JoinError{
+		addr: addr,
+		file: file,
+		fn:   fn,
+		line: line,
+		errs: nonNil,
+	}
+}
+
+// JoinDedup is like Join, except that errors with the same message, including
+// the messages of their parent errors, are combined only once, keeping the
+// first such error as the representative of the others. The resultant error
+// records the number of occurrences of each distinct error, which is reported
+// by its Count method, and is included in its message and by Trace:
+//
+//	err := errors.JoinDedup(errs...)
+//	fmt.Println(err) // connection refused (x490), timeout (x10)
+func JoinDedup(errs ...error) error {
+	var unique []error
+	var counts []int
+	index := make(map[string]int)
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		msg := err.Error()
+		if i, ok := index[msg]; ok {
+			counts[i]++
+			continue
+		}
+		index[msg] = len(unique)
+		unique = append(unique, err)
+		counts = append(counts, 1)
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	addr, file, line, _ := runtime.Caller(1)
+	f := runtime.FuncForPC(addr)
+	fn := f.Name()
+	return &JoinError{
+		addr:   addr,
+		file:   file,
+		fn:     fn,
+		line:   line,
+		errs:   unique,
+		counts: counts,
+	}
+}
@@ -0,0 +1,45 @@
+package errors
+
+import (
+	"runtime"
+	"time"
+)
+
+// Must returns v if err is nil, and otherwise panics with an error whose parent
+// is err and whose origin is the caller of Must, as by Wrap. It is intended for
+// initialization code, where an error is a programming mistake, and keeps the
+// context of err when the panic is recovered by Recover or Catch:
+//
+//	var tmpl = errors.Must(template.ParseFiles("index.html"))
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(mustError(err))
+	}
+	return v
+}
+
+// Must2 is like Must, for functions returning two values and an error.
+func Must2[T, U any](v T, w U, err error) (T, U) {
+	if err != nil {
+		panic(mustError(err))
+	}
+	return v, w
+}
+
+// mustError returns err wrapped with the origin of the caller of Must or
+// Must2.
+func mustError(err error) error {
+	addr, file, line, _ := runtime.Caller(2)
+	f := runtime.FuncForPC(addr)
+	fn := f.Name()
+	return Error{
+		addr:   addr,
+		file:   file,
+		fn:     fn,
+		gid:    goroutine(),
+		line:   line,
+		parent: err,
+		text:   "",
+		time:   time.Now(),
+	}
+}
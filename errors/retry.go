@@ -0,0 +1,61 @@
+package errors
+
+import (
+	"context"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/internal/backoff"
+)
+
+// HasAny returns a predicate reporting whether an error Has any of targets,
+// for use with Retry:
+//
+//	err := errors.Retry(ctx, 5, time.Second, fetch, errors.HasAny(ErrBusy, io.ErrUnexpectedEOF))
+func HasAny(targets ...error) func(error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if Has(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Retry calls fn until it returns nil, it has been called attempts times, the
+// error it returns does not satisfy retryable, or ctx is done. If attempts is
+// less than one, fn is called until it succeeds or ctx is done. If retryable is
+// nil, every error is retried. Retry waits initial after the first failed
+// attempt, doubling the delay after each further attempt up to ten seconds, or
+// initial if longer, with each delay randomised by up to 20%, as by retry.Do.
+//
+// Returns nil if fn succeeded, and otherwise an error whose parent is the error
+// of the last attempt, and whose text records the number of attempts made,
+// such as "failed after 3 attempts". If ctx was done before the attempts were
+// exhausted, the parent instead joins the error of the last attempt with the
+// error of ctx. The origin of the error is the caller of Retry.
+func Retry(ctx context.Context, attempts int, initial time.Duration, fn func() error, retryable func(error) bool) error {
+	max := 10 * time.Second
+	if initial > max {
+		max = initial
+	}
+	errs, n := backoff.Do(ctx, backoff.Config{
+		Attempts:   attempts,
+		Initial:    initial,
+		Max:        max,
+		Multiplier: 2,
+		Jitter:     0.2,
+		RetryIf:    retryable,
+	}, fn)
+	if errs == nil {
+		return nil
+	}
+	parent := errs[n-1]
+	if len(errs) > n {
+		parent = Join(parent, errs[n])
+	}
+	if n == 1 {
+		return NewSkip(parent, 1, "failed after 1 attempt")
+	}
+	return NewSkip(parent, 1, "failed after %d attempts", n)
+}
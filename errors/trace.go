@@ -0,0 +1,199 @@
+package errors
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// frame describes a single error in a chain, as written by TraceJSON and
+// TraceLogfmt.
+type frame struct {
+	Text      string         `json:"text"`
+	Func      string         `json:"func,omitempty"`
+	File      string         `json:"file,omitempty"`
+	Line      int            `json:"line,omitempty"`
+	Addr      string         `json:"addr,omitempty"`
+	Time      string         `json:"time,omitempty"`
+	Goroutine uint64         `json:"goroutine,omitempty"`
+	Fields    map[string]any `json:"fields,omitempty"`
+	Stack     []string       `json:"stack,omitempty"`
+	Errors    [][]frame      `json:"errors,omitempty"`
+	Counts    []int          `json:"counts,omitempty"`
+}
+
+// TraceJSON writes the chain of err to w as a single JSON object followed by a
+// newline. If w is nil, TraceJSON writes to the standard error stream. The
+// object holds the full error message and each error in the chain, most recent
+// first:
+//
+//	{"error":"load: read: EOF","chain":[
+//		{"text":"load","func":"main.load","file":"/src/main.go","line":12,
+//			"addr":"0x4a2f1c","time":"2024-05-01T12:00:00.5Z","goroutine":7,
+//			"fields":{"path":"app.conf"}},
+//		{"text":"EOF"}]}
+//
+// An error not created by this package is given by its text alone, and the
+// chains of the errors combined by Join are given by the errors field of its
+// frame, along with their numbers of occurrences, as counted by JoinDedup, in
+// its counts field. For a panic recovered by Recover, the stack field lists
+// the calls on the stack below the site of the panic. Annotation values which
+// cannot be encoded as JSON are written as by fmt.Sprint.
+func TraceJSON(w io.Writer, err error) {
+	if w == nil {
+		w = os.Stderr
+	}
+	if err == nil {
+		return
+	}
+	v := struct {
+		Error string  `json:"error"`
+		Chain []frame `json:"chain"`
+	}{err.Error(), chain(err)}
+	encodable(v.Chain)
+	data, _ := json.Marshal(v)
+	w.Write(append(data, '\n'))
+}
+
+// encodable replaces annotation values in fs which cannot be encoded as JSON.
+func encodable(fs []frame) {
+	for _, f := range fs {
+		for k, val := range f.Fields {
+			if _, err := json.Marshal(val); err != nil {
+				f.Fields[k] = fmt.Sprint(val)
+			} else if e, ok := val.(error); ok {
+				f.Fields[k] = e.Error()
+			}
+		}
+		for _, c := range f.Errors {
+			encodable(c)
+		}
+	}
+}
+
+// TraceLogfmt writes the chain of err to w in logfmt, one line per error, most
+// recent first. If w is nil, TraceLogfmt writes to the standard error stream.
+// Each line holds the depth of the error in the chain, its text, origin,
+// address, creation time and goroutine, its annotations, prefixed by "field.",
+// and for a panic recovered by Recover, the calls on the stack below the site
+// of the panic:
+//
+//	depth=0 text=load func=main.load file=/src/main.go line=12 addr=0x4a2f1c time=2024-05-01T12:00:00.5Z goroutine=7 field.path=app.conf
+//	depth=1 text=EOF
+//
+// The errors combined by Join follow the line of its frame, the depth of each
+// being the depth of the frame, the index of the combined error, and the depth
+// within its chain, separated by dots, such as 1.0.0 for the first error
+// combined by a Join at depth 1.
+func TraceLogfmt(w io.Writer, err error) {
+	if w == nil {
+		w = os.Stderr
+	}
+	b := bufio.NewWriter(w)
+	writeLogfmt(b, chain(err), "")
+	b.Flush()
+}
+
+// writeLogfmt writes the frames fs to b, prefixing each depth with prefix.
+func writeLogfmt(b *bufio.Writer, fs []frame, prefix string) {
+	for i, f := range fs {
+		depth := prefix + strconv.Itoa(i)
+		fmt.Fprintf(b, "depth=%s text=%s", depth, quote(f.Text))
+		if f.Func != "" {
+			fmt.Fprintf(b, " func=%s file=%s line=%d addr=%s", quote(f.Func), quote(f.File), f.Line, f.Addr)
+		}
+		if f.Time != "" {
+			fmt.Fprintf(b, " time=%s goroutine=%d", f.Time, f.Goroutine)
+		}
+		keys := make([]string, 0, len(f.Fields))
+		for k := range f.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(b, " field.%s=%s", k, quote(fmt.Sprint(f.Fields[k])))
+		}
+		if len(f.Stack) > 0 {
+			fmt.Fprintf(b, " stack=%s", quote(strings.Join(f.Stack, "; ")))
+		}
+		b.WriteByte('\n')
+		for j, c := range f.Errors {
+			writeLogfmt(b, c, depth+"."+strconv.Itoa(j)+".")
+		}
+	}
+}
+
+// chain returns the frames of err and its parent errors, most recent first.
+func chain(err error) []frame {
+	var fs []frame
+	for err != nil {
+		if j, ok := err.(*JoinError); ok {
+			f := frame{
+				Text: j.Error(),
+				Func: j.Func(),
+				File: j.File(),
+				Line: j.Line(),
+				Addr: "0x" + strconv.FormatUint(uint64(j.Addr()), 16),
+			}
+			for _, e := range j.errs {
+				f.Errors = append(f.Errors, chain(e))
+			}
+			if j.counts != nil {
+				f.Counts = j.counts
+			}
+			fs = append(fs, f)
+			break
+		}
+		e, ok := err.(Error)
+		if !ok {
+			fs = append(fs, frame{Text: err.Error()})
+			break
+		}
+		f := frame{
+			Text:      e.Text(),
+			Func:      e.Func(),
+			File:      e.File(),
+			Line:      e.Line(),
+			Addr:      "0x" + strconv.FormatUint(uint64(e.Addr()), 16),
+			Goroutine: e.Goroutine(),
+		}
+		if !e.Time().IsZero() {
+			f.Time = e.Time().Format(time.RFC3339Nano)
+		}
+		if e.stack != nil {
+			for _, c := range *e.stack {
+				f.Stack = append(f.Stack, c.fn+" "+c.file+":"+strconv.Itoa(c.line))
+			}
+		}
+		for _, a := range e.annotations() {
+			if f.Fields == nil {
+				f.Fields = make(map[string]any)
+			}
+			f.Fields[a.key] = a.value
+		}
+		fs = append(fs, f)
+		err = e.Parent()
+	}
+	return fs
+}
+
+// quote quotes s for logfmt if it is empty or contains spaces, quotes, equals
+// signs or unprintable characters.
+func quote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r == ' ' || r == '"' || r == '=' || r == '\\' || unicode.IsControl(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
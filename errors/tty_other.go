@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package errors
+
+import "os"
+
+// terminal reports whether f is a terminal, and if so, returns its width in
+// cells. Terminals are not detected on this system.
+func terminal(f *os.File) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,33 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package errors
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminal reports whether f is a terminal, and if so, returns its width in
+// cells. The ioctl is made through the raw connection of f, as term.GetSize
+// cannot be used by this package, which term imports, and f.Fd would switch
+// f to blocking mode.
+func terminal(f *os.File) (int, bool) {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return 0, false
+	}
+	var ws struct {
+		row, col, xpixel, ypixel uint16
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(
+			syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)),
+		)
+	})
+	if err != nil || errno != 0 {
+		return 0, false
+	}
+	return int(ws.col), true
+}
@@ -0,0 +1,210 @@
+// Package execx implements helpers for running external commands.
+//
+// Run runs a command to completion, capturing its output as strings:
+//
+//	res, err := execx.Run(ctx, []string{"git", "rev-parse", "HEAD"},
+//		execx.Dir(repo),
+//		execx.Timeout(10*time.Second),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	commit := strings.TrimSpace(res.Stdout)
+//
+// A command exiting with a non-zero status is reported as an error describing
+// the command line, the exit status and the end of the command's standard
+// error, such as:
+//
+//	command git rev-parse HEAD failed with stderr "fatal: not a git repository": exit status 128
+//
+// The exit status can be recovered from such an error using ExitCode.
+package execx
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// tailLines is the number of lines at the end of standard error included in
+// the error describing a failed command.
+const tailLines = 5
+
+// waitDelay is the time allowed for a killed command's output to be closed,
+// after which it is abandoned. This prevents Run from blocking on
+// subprocesses which inherited the command's output.
+const waitDelay = time.Second
+
+// Result holds the outcome of a command.
+type Result struct {
+	// Stdout holds the standard output of the command. With Combined, it
+	// holds both the standard output and standard error.
+	Stdout string
+	// Stderr holds the standard error of the command, or nothing with
+	// Combined.
+	Stderr string
+	// ExitCode is the exit status of the command, or -1 if the command did
+	// not exit normally, such as when killed by a signal.
+	ExitCode int
+	// Duration is the time taken by the command.
+	Duration time.Duration
+}
+
+// Option configures Run.
+type Option func(*config)
+
+type config struct {
+	combined bool
+	dir      string
+	env      []string
+	stdin    io.Reader
+	timeout  time.Duration
+}
+
+// Combined captures standard output and standard error together, interleaved
+// as written by the command, in Result.Stdout.
+func Combined() Option {
+	return func(c *config) {
+		c.combined = true
+	}
+}
+
+// Dir sets the working directory of the command.
+func Dir(dir string) Option {
+	return func(c *config) {
+		c.dir = dir
+	}
+}
+
+// Env adds variables, each of the form "KEY=value", to the environment
+// inherited by the command, replacing any inherited variables of the same
+// name. Env may be given more than once, with later variables taking
+// precedence.
+func Env(vars ...string) Option {
+	return func(c *config) {
+		c.env = append(c.env, vars...)
+	}
+}
+
+// Stdin feeds r to the standard input of the command. Without Stdin, the
+// command reads from the null device.
+func Stdin(r io.Reader) Option {
+	return func(c *config) {
+		c.stdin = r
+	}
+}
+
+// Timeout kills the command if it runs for longer than d.
+func Timeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+// ExitCode returns the exit status recorded in err, which must be an error
+// returned by Run for a command which exited with a non-zero status. Returns
+// false if err records no exit status.
+func ExitCode(err error) (int, bool) {
+	if ee, ok := errors.As[*exec.ExitError](err); ok {
+		return ee.ExitCode(), true
+	}
+	return 0, false
+}
+
+// Run runs the command described by cmd, whose first element is the program
+// to run and whose remaining elements are its arguments, and waits for it to
+// finish. The command is killed if ctx is done before it finishes.
+//
+// Run returns the command's output along with any error, so that the output of
+// a failed command can be examined. Returns error if the command cannot be
+// started, does not exit with status zero, or is killed by its timeout or by
+// ctx.
+func Run(ctx context.Context, cmd []string, opts ...Option) (*Result, error) {
+	if len(cmd) == 0 {
+		return nil, errors.New(nil, "cannot run empty command")
+	}
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	line := commandLine(cmd)
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	x := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	x.Dir = c.dir
+	x.Stdin = c.stdin
+	x.WaitDelay = waitDelay
+	if len(c.env) > 0 {
+		x.Env = append(os.Environ(), c.env...)
+	}
+	var stdout, stderr bytes.Buffer
+	x.Stdout = &stdout
+	x.Stderr = &stderr
+	if c.combined {
+		x.Stderr = &stdout
+	}
+	start := time.Now()
+	err := x.Run()
+	res := &Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: -1,
+		Duration: time.Since(start),
+	}
+	if x.ProcessState != nil {
+		res.ExitCode = x.ProcessState.ExitCode()
+	}
+	if err == nil {
+		return res, nil
+	}
+	if x.ProcessState == nil {
+		return res, errors.New(err, "cannot run %s", line)
+	}
+	if ctx.Err() == context.DeadlineExceeded && c.timeout > 0 {
+		return res, errors.New(nil, "command %s timed out after %s", line, c.timeout)
+	} else if ctx.Err() != nil {
+		return res, errors.New(ctx.Err(), "command %s interrupted", line)
+	}
+	output := res.Stderr
+	if c.combined {
+		output = res.Stdout
+	}
+	if t := tail(output, tailLines); t != "" {
+		return res, errors.New(err, "command %s failed with stderr %q", line, t)
+	}
+	return res, errors.New(err, "command %s failed", line)
+}
+
+// commandLine formats cmd for display, quoting arguments as needed.
+func commandLine(cmd []string) string {
+	args := make([]string, len(cmd))
+	for i, arg := range cmd {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`*?[]{}()<>|&;#~") {
+			arg = strconv.Quote(arg)
+		}
+		args[i] = arg
+	}
+	return strings.Join(args, " ")
+}
+
+// tail returns the last n non-empty lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\r\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, "\r")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,126 @@
+package std
+
+import (
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// FrozenSlice is a read-only view of a slice. A FrozenSlice provides access to
+// the elements of its underlying slice, but no means of modifying them, so a
+// package can hand its internal slices to callers without copying them on
+// every access.
+//
+// A FrozenSlice does not copy the slice it was created from. Changes made to
+// that slice by its owner are visible through the FrozenSlice.
+//
+// The zero value of a FrozenSlice is an empty view.
+type FrozenSlice[T any] struct {
+	s []T
+}
+
+// FreezeSlice returns a read-only view of s.
+func FreezeSlice[T any](s []T) FrozenSlice[T] {
+	return FrozenSlice[T]{s[:len(s):len(s)]}
+}
+
+// All returns a sequence of the elements of f, in order.
+func (f FrozenSlice[T]) All() Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range f.s {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// At returns the nth element of f. If n is negative, it counts from the end of
+// f. Returns error if f does not have an element at index n.
+func (f FrozenSlice[T]) At(n int) (T, error) {
+	i := n
+	if i < 0 {
+		i += len(f.s)
+	}
+	if i > len(f.s)-1 || i < 0 {
+		var none T
+		return none, errors.New(nil,
+			"index out of range [%d] with length %d", n, len(f.s),
+		)
+	}
+	return f.s[i], nil
+}
+
+// Clone returns a mutable copy of the elements of f.
+func (f FrozenSlice[T]) Clone() []T {
+	return append([]T(nil), f.s...)
+}
+
+// Len returns the number of elements in f.
+func (f FrozenSlice[T]) Len() int {
+	return len(f.s)
+}
+
+// FrozenMap is a read-only view of a map. A FrozenMap provides access to the
+// entries of its underlying map, but no means of modifying them, so a package
+// can hand its internal maps to callers without copying them on every access.
+//
+// A FrozenMap does not copy the map it was created from. Changes made to that
+// map by its owner are visible through the FrozenMap.
+//
+// The zero value of a FrozenMap is an empty view.
+type FrozenMap[K comparable, V any] struct {
+	m map[K]V
+}
+
+// FreezeMap returns a read-only view of m.
+func FreezeMap[K comparable, V any](m map[K]V) FrozenMap[K, V] {
+	return FrozenMap[K, V]{m}
+}
+
+// Clone returns a mutable copy of the entries of f.
+func (f FrozenMap[K, V]) Clone() map[K]V {
+	clone := make(map[K]V, len(f.m))
+	for k, v := range f.m {
+		clone[k] = v
+	}
+	return clone
+}
+
+// Get returns the value stored under key k. Returns error if k is not present
+// in f.
+func (f FrozenMap[K, V]) Get(k K) (V, error) {
+	v, ok := f.m[k]
+	if !ok {
+		return v, errors.New(nil, "key %v not found", k)
+	}
+	return v, nil
+}
+
+// Has reports whether key k is present in f.
+func (f FrozenMap[K, V]) Has(k K) bool {
+	_, ok := f.m[k]
+	return ok
+}
+
+// Keys returns the keys of f in an unspecified order.
+func (f FrozenMap[K, V]) Keys() []K {
+	keys := make([]K, 0, len(f.m))
+	for k := range f.m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Len returns the number of entries in f.
+func (f FrozenMap[K, V]) Len() int {
+	return len(f.m)
+}
+
+// Walk calls fn for each key and value in f, in an unspecified order.
+// Iteration stops early if fn returns false.
+func (f FrozenMap[K, V]) Walk(fn func(k K, v V) bool) {
+	for k, v := range f.m {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package fsx
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/pathx"
+)
+
+// SymlinkMode determines how symbolic links are treated when copying.
+type SymlinkMode int
+
+const (
+	// SymlinkCopy copies symbolic links as links, with the same target.
+	SymlinkCopy SymlinkMode = iota
+	// SymlinkFollow copies the files and directories to which symbolic
+	// links refer.
+	SymlinkFollow
+	// SymlinkSkip ignores symbolic links.
+	SymlinkSkip
+)
+
+// CopyOptions configures CopyFile, CopyDir and Move. A nil *CopyOptions is
+// equivalent to a zero CopyOptions, which does not overwrite existing files,
+// copies symbolic links as links, and creates files with default permissions
+// and the current time.
+type CopyOptions struct {
+	// Overwrite allows existing files to be replaced.
+	Overwrite bool
+
+	// PreserveMode gives copies the permission bits of the originals.
+	PreserveMode bool
+
+	// PreserveTimes gives copies the modification times of the originals.
+	PreserveTimes bool
+
+	// Symlinks determines how symbolic links are treated.
+	Symlinks SymlinkMode
+
+	// Progress, if not nil, is called after each file is copied, with the
+	// path of the original and the total number of bytes copied so far.
+	Progress func(path string, copied int64)
+}
+
+// copier holds the state of a single copy operation.
+type copier struct {
+	opts    CopyOptions
+	copied  int64
+	visited map[string]bool
+}
+
+func newCopier(opts *CopyOptions) *copier {
+	c := &copier{visited: make(map[string]bool)}
+	if opts != nil {
+		c.opts = *opts
+	}
+	return c
+}
+
+// CopyDir copies the directory src and its contents to dst, creating dst if
+// it does not exist, and merging with its contents if it does. Returns error
+// if a file cannot be copied, naming the file, or if dst is src or lies within
+// it, once symbolic links are evaluated.
+func CopyDir(dst, src string, opts *CopyOptions) error {
+	c := newCopier(opts)
+	info, err := os.Stat(src)
+	if err != nil {
+		return errors.New(err, "cannot copy %s", src)
+	}
+	if !info.IsDir() {
+		return errors.New(nil, "cannot copy %s: not a directory", src)
+	}
+	return c.copyDir(dst, src, info)
+}
+
+// CopyFile copies the file src to dst. If src is a symbolic link, it is
+// treated according to opts.Symlinks. Returns error if dst exists and
+// opts.Overwrite is not set.
+func CopyFile(dst, src string, opts *CopyOptions) error {
+	c := newCopier(opts)
+	info, err := os.Lstat(src)
+	if err != nil {
+		return errors.New(err, "cannot copy %s", src)
+	}
+	return c.copy(dst, src, info)
+}
+
+// Move moves the file or directory src to dst. It renames src where possible,
+// and otherwise, such as when moving between file systems, copies src to dst
+// and then removes src. Returns error if dst exists and opts.Overwrite is not
+// set.
+func Move(dst, src string, opts *CopyOptions) error {
+	c := newCopier(opts)
+	info, err := os.Lstat(src)
+	if err != nil {
+		return errors.New(err, "cannot move %s", src)
+	}
+	if _, err := os.Lstat(dst); err == nil && !c.opts.Overwrite {
+		return errors.New(nil, "cannot move %s to %s: destination exists", src, dst)
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if info.IsDir() {
+		err = c.copyDir(dst, src, info)
+	} else {
+		err = c.copy(dst, src, info)
+	}
+	if err != nil {
+		return errors.New(err, "cannot move %s to %s", src, dst)
+	}
+	if err := os.RemoveAll(src); err != nil {
+		return errors.New(err, "cannot remove %s after copying", src)
+	}
+	return nil
+}
+
+// copy copies the file or directory src, whose Lstat information is info.
+func (c *copier) copy(dst, src string, info fs.FileInfo) error {
+	if info.Mode()&fs.ModeSymlink != 0 {
+		switch c.opts.Symlinks {
+		case SymlinkSkip:
+			return nil
+		case SymlinkCopy:
+			return c.copySymlink(dst, src)
+		}
+		target, err := os.Stat(src)
+		if err != nil {
+			return errors.New(err, "cannot copy %s", src)
+		}
+		info = target
+	}
+	if info.IsDir() {
+		return c.copyDir(dst, src, info)
+	}
+	if !info.Mode().IsRegular() {
+		return errors.New(nil, "cannot copy %s: not a regular file", src)
+	}
+	return c.copyRegular(dst, src, info)
+}
+
+func (c *copier) copyDir(dst, src string, info fs.FileInfo) error {
+	real, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return errors.New(err, "cannot copy %s", src)
+	}
+	if c.visited[real] {
+		return errors.New(nil, "cannot copy %s: symbolic link loop", src)
+	}
+	realDst, err := realPath(dst)
+	if err != nil {
+		return errors.New(err, "cannot copy %s to %s", src, dst)
+	}
+	if pathx.WithinRoot(real, realDst) {
+		return errors.New(nil, "cannot copy %s to %s: destination within source", src, dst)
+	}
+	c.visited[real] = true
+	defer delete(c.visited, real)
+	perm := fs.FileMode(0o777)
+	if c.opts.PreserveMode {
+		perm = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(dst, perm|0o700); err != nil {
+		return errors.New(err, "cannot create directory %s", dst)
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return errors.New(err, "cannot read directory %s", src)
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			return errors.New(err, "cannot copy %s", filepath.Join(src, e.Name()))
+		}
+		err = c.copy(filepath.Join(dst, e.Name()), filepath.Join(src, e.Name()), info)
+		if err != nil {
+			return err
+		}
+	}
+	if c.opts.PreserveMode {
+		if err := os.Chmod(dst, perm); err != nil {
+			return errors.New(err, "cannot set mode of %s", dst)
+		}
+	}
+	if c.opts.PreserveTimes {
+		if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+			return errors.New(err, "cannot set times of %s", dst)
+		}
+	}
+	return nil
+}
+
+// realPath returns the absolute path of path with symbolic links evaluated,
+// as by filepath.EvalSymlinks, where the elements of path which do not exist
+// are joined as they are.
+func realPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var rest []string
+	for {
+		real, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		dir := filepath.Dir(path)
+		if dir == path {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(path)}, rest...)
+		path = dir
+	}
+}
+
+func (c *copier) copyRegular(dst, src string, info fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.New(err, "cannot copy %s", src)
+	}
+	defer in.Close()
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !c.opts.Overwrite {
+		flag |= os.O_EXCL
+	}
+	perm := fs.FileMode(0o666)
+	if c.opts.PreserveMode {
+		perm = info.Mode().Perm()
+	}
+	out, err := os.OpenFile(dst, flag, perm)
+	if err != nil {
+		return errors.New(err, "cannot copy %s to %s", src, dst)
+	}
+	n, err := io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.New(err, "cannot copy %s to %s", src, dst)
+	}
+	if c.opts.PreserveMode {
+		if err := os.Chmod(dst, perm); err != nil {
+			return errors.New(err, "cannot set mode of %s", dst)
+		}
+	}
+	if c.opts.PreserveTimes {
+		if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+			return errors.New(err, "cannot set times of %s", dst)
+		}
+	}
+	c.copied += n
+	if c.opts.Progress != nil {
+		c.opts.Progress(src, c.copied)
+	}
+	return nil
+}
+
+func (c *copier) copySymlink(dst, src string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return errors.New(err, "cannot copy %s", src)
+	}
+	if c.opts.Overwrite {
+		if info, err := os.Lstat(dst); err == nil && !info.IsDir() {
+			os.Remove(dst)
+		}
+	}
+	if err := os.Symlink(target, dst); err != nil {
+		return errors.New(err, "cannot copy %s to %s", src, dst)
+	}
+	return nil
+}
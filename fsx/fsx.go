@@ -0,0 +1,50 @@
+// Package fsx implements file system operations beyond those of package os.
+//
+// CopyFile, CopyDir and Move copy and move files and directory trees, with
+// control over overwriting, permissions, modification times and symbolic
+// links:
+//
+//	err := fsx.CopyDir("backup", "data", &fsx.CopyOptions{
+//		PreserveMode:  true,
+//		PreserveTimes: true,
+//		Symlinks:      fsx.SymlinkSkip,
+//	})
+//
+// Walk walks a directory tree, filtering entries by glob patterns:
+//
+//	opts := &fsx.WalkOptions{
+//		Include:  []string{"*.go"},
+//		Exclude:  []string{"vendor", "**/testdata"},
+//		Parallel: 8,
+//	}
+//	err := fsx.Walk(".", opts, func(path string, d fs.DirEntry) error {
+//		fmt.Println(path)
+//		return nil
+//	})
+//
+// A Temp creates temporary files and directories, removing them all when
+// closed:
+//
+//	tmp := fsx.NewTemp("")
+//	defer tmp.Close()
+//	tmp.RemoveOnSignal()
+//	dir, err := tmp.Dir("build-*")
+//
+// A Watcher reports changes to files and directories, optionally watching
+// directory trees recursively and coalescing bursts of events:
+//
+//	w, err := fsx.NewWatcher(&fsx.WatchOptions{
+//		Recursive: true,
+//		Debounce:  100 * time.Millisecond,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Close()
+//	if err := w.Add("src"); err != nil {
+//		return err
+//	}
+//	for ev := range w.Events() {
+//		fmt.Println(ev.Op, ev.Path)
+//	}
+package fsx
@@ -0,0 +1,134 @@
+package fsx
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Temp creates temporary files and directories, and removes them all when
+// closed. A Temp must be created with NewTemp, and is safe for concurrent use.
+//
+// Go provides no hook for process exit, so a program should defer a call to
+// Close, which also covers panics unwinding through the deferring function.
+// To also clean up when the program is terminated by a signal, call
+// RemoveOnSignal.
+type Temp struct {
+	mu     sync.Mutex
+	dir    string
+	paths  []string
+	closed bool
+	stop   chan struct{}
+}
+
+// NewTemp returns a Temp which creates files and directories in dir. If dir
+// is empty, os.TempDir is used.
+func NewTemp(dir string) *Temp {
+	return &Temp{dir: dir}
+}
+
+// Close removes every file and directory created or tracked by t, most
+// recent first, and stops any signal handling started by RemoveOnSignal.
+// Subsequent calls to File and Dir return an error. Returns error if any path
+// cannot be removed, having attempted to remove every path.
+func (t *Temp) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	if t.stop != nil {
+		close(t.stop)
+	}
+	var errs []error
+	for i := len(t.paths) - 1; i >= 0; i-- {
+		if err := os.RemoveAll(t.paths[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	t.paths = nil
+	if len(errs) > 0 {
+		return errors.New(errors.Join(errs...), "cannot remove temporary files")
+	}
+	return nil
+}
+
+// Dir creates a new temporary directory, as by os.MkdirTemp with the given
+// pattern, and returns its path.
+func (t *Temp) Dir(pattern string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return "", errors.New(nil, "cannot create temporary directory: closed")
+	}
+	name, err := os.MkdirTemp(t.dir, pattern)
+	if err != nil {
+		return "", errors.New(err, "cannot create temporary directory")
+	}
+	t.paths = append(t.paths, name)
+	return name, nil
+}
+
+// File creates a new temporary file, as by os.CreateTemp with the given
+// pattern, and returns it open for reading and writing. The caller should
+// close the file when done with it; Close only removes it.
+func (t *Temp) File(pattern string) (*os.File, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, errors.New(nil, "cannot create temporary file: closed")
+	}
+	f, err := os.CreateTemp(t.dir, pattern)
+	if err != nil {
+		return nil, errors.New(err, "cannot create temporary file")
+	}
+	t.paths = append(t.paths, f.Name())
+	return f, nil
+}
+
+// RemoveOnSignal causes t to be closed when the process receives one of sigs,
+// or if none are given, an interrupt or termination signal. Having closed t,
+// the signal is raised again with its default behaviour, which typically ends
+// the process; where that is not possible, the process exits with status 1.
+//
+// RemoveOnSignal suits programs which do not otherwise handle these signals.
+func (t *Temp) RemoveOnSignal(sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed || t.stop != nil {
+		return
+	}
+	t.stop = make(chan struct{})
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func(stop chan struct{}) {
+		defer signal.Stop(ch)
+		select {
+		case sig := <-ch:
+			t.Close()
+			signal.Reset(sig)
+			p, err := os.FindProcess(os.Getpid())
+			if err == nil {
+				err = p.Signal(sig)
+			}
+			if err != nil {
+				os.Exit(1)
+			}
+		case <-stop:
+		}
+	}(t.stop)
+}
+
+// Track adds path to the files and directories removed by Close.
+func (t *Temp) Track(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.paths = append(t.paths, path)
+}
@@ -0,0 +1,226 @@
+package fsx
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/glob"
+)
+
+// WalkOptions configures Walk. A nil *WalkOptions is equivalent to a zero
+// WalkOptions, which walks the whole tree sequentially without following
+// symbolic links.
+type WalkOptions struct {
+	// Include, if not empty, limits the entries reported to those matching
+	// at least one pattern. Directories are traversed whether or not they
+	// match.
+	Include []string
+
+	// Exclude skips entries matching any pattern. Excluded directories are
+	// not traversed.
+	Exclude []string
+
+	// MaxDepth limits the depth of entries reported, where the entries of
+	// the root have depth 1. If MaxDepth is zero or negative, depth is
+	// unlimited.
+	MaxDepth int
+
+	// FollowSymlinks causes symbolic links to directories to be traversed.
+	// A link to a directory which is already being traversed, which would
+	// otherwise cause a loop, is reported but not traversed.
+	FollowSymlinks bool
+
+	// Parallel is the number of directories read concurrently ahead of the
+	// walk. Whatever its value, entries are reported in the same order, from
+	// a single goroutine.
+	Parallel int
+}
+
+// Walk walks the file tree rooted at root, calling fn for each file and
+// directory below root in lexical order. The path given to fn is root joined
+// with the entry's path relative to root.
+//
+// Patterns in opts are matched against the slash-separated path of an entry
+// relative to root, as by glob.Compile, except that a pattern with no slash is
+// matched against the final element of the path only, so that "*.go" matches
+// Go files in any directory. Each list of patterns is matched as a glob.Set,
+// so that a negated pattern, such as "!keep.log", excepts entries from the
+// patterns before it.
+//
+// If fn returns fs.SkipDir for a directory, Walk does not traverse it, and if
+// fn returns fs.SkipAll, Walk stops and returns nil. Any other error returned
+// by fn stops the walk and is returned by Walk. Returns error if a pattern is
+// malformed, or if a directory cannot be read, naming the directory.
+func Walk(root string, opts *WalkOptions, fn func(path string, d fs.DirEntry) error) error {
+	w := &walker{fn: fn}
+	if opts != nil {
+		w.opts = *opts
+	}
+	var err error
+	if len(w.opts.Include) > 0 {
+		if w.include, err = compilePatterns(w.opts.Include); err != nil {
+			return errors.New(err, "cannot walk %s", root)
+		}
+	}
+	if w.exclude, err = compilePatterns(w.opts.Exclude); err != nil {
+		return errors.New(err, "cannot walk %s", root)
+	}
+	if w.opts.Parallel > 1 {
+		w.sem = make(chan struct{}, w.opts.Parallel)
+		w.pending = make(map[string]*listing)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return errors.New(err, "cannot walk %s", root)
+	}
+	err = w.walk(root, "", 1, []fs.FileInfo{info})
+	if err == fs.SkipAll || err == fs.SkipDir {
+		return nil
+	}
+	return err
+}
+
+type walker struct {
+	opts    WalkOptions
+	include *glob.Set // nil if every entry is included
+	exclude *glob.Set
+	fn      func(string, fs.DirEntry) error
+	sem     chan struct{}
+	pending map[string]*listing
+}
+
+type listing struct {
+	done    chan struct{}
+	entries []fs.DirEntry
+	err     error
+}
+
+func (w *walker) walk(dir, rel string, depth int, ancestors []fs.FileInfo) error {
+	entries, err := w.list(dir)
+	if err != nil {
+		return errors.New(err, "cannot read directory %s", dir)
+	}
+	type child struct {
+		entry fs.DirEntry
+		path  string
+		rel   string
+		info  fs.FileInfo // non-nil if the entry is to be traversed
+	}
+	var children []child
+	for _, e := range entries {
+		c := child{entry: e, path: filepath.Join(dir, e.Name()), rel: path.Join(rel, e.Name())}
+		if w.exclude.Match(c.rel) {
+			continue
+		}
+		if w.opts.MaxDepth <= 0 || depth < w.opts.MaxDepth {
+			c.info = w.traversable(c.path, e, ancestors)
+		}
+		children = append(children, c)
+	}
+	if w.sem != nil {
+		for _, c := range children {
+			if c.info != nil {
+				w.prefetch(c.path)
+			}
+		}
+	}
+	for i, c := range children {
+		if w.include == nil || w.include.Match(c.rel) {
+			err := w.fn(c.path, c.entry)
+			if err == fs.SkipDir && c.entry.IsDir() {
+				w.discard(c.path)
+				continue
+			} else if err != nil {
+				for _, c := range children[i:] {
+					w.discard(c.path)
+				}
+				return err
+			}
+		}
+		if c.info == nil {
+			continue
+		}
+		if err := w.walk(c.path, c.rel, depth+1, append(ancestors, c.info)); err != nil {
+			for _, c := range children[i+1:] {
+				w.discard(c.path)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// traversable returns information on the directory at path if it should be
+// traversed, and otherwise returns nil.
+func (w *walker) traversable(path string, e fs.DirEntry, ancestors []fs.FileInfo) fs.FileInfo {
+	if e.Type()&fs.ModeSymlink != 0 && !w.opts.FollowSymlinks {
+		return nil
+	}
+	if !e.IsDir() && e.Type()&fs.ModeSymlink == 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return nil
+	}
+	for _, a := range ancestors {
+		if os.SameFile(a, info) {
+			return nil
+		}
+	}
+	return info
+}
+
+// list returns the entries of dir, sorted by name.
+func (w *walker) list(dir string) ([]fs.DirEntry, error) {
+	if w.sem == nil {
+		return os.ReadDir(dir)
+	}
+	l, ok := w.pending[dir]
+	if !ok {
+		l = w.prefetch(dir)
+	}
+	delete(w.pending, dir)
+	<-l.done
+	return l.entries, l.err
+}
+
+// prefetch starts reading dir concurrently with the walk.
+func (w *walker) prefetch(dir string) *listing {
+	l := &listing{done: make(chan struct{})}
+	w.pending[dir] = l
+	go func() {
+		w.sem <- struct{}{}
+		l.entries, l.err = os.ReadDir(dir)
+		<-w.sem
+		close(l.done)
+	}()
+	return l
+}
+
+// discard forgets a prefetched directory which will not be walked.
+func (w *walker) discard(dir string) {
+	if w.pending != nil {
+		delete(w.pending, dir)
+	}
+}
+
+// compilePatterns compiles patterns as described by Walk.
+func compilePatterns(patterns []string) (*glob.Set, error) {
+	ps := make([]string, len(patterns))
+	for i, p := range patterns {
+		if !strings.Contains(p, "/") {
+			if strings.HasPrefix(p, "!") {
+				p = "!**/" + p[1:]
+			} else {
+				p = "**/" + p
+			}
+		}
+		ps[i] = p
+	}
+	return glob.NewSet(ps...)
+}
@@ -0,0 +1,382 @@
+package fsx
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// pollInterval is the interval at which backends which cannot be interrupted
+// check whether their watcher has been closed.
+const pollInterval = 100 * time.Millisecond
+
+// Op describes a set of file system operations.
+type Op uint32
+
+const (
+	// Create indicates that a file or directory was created, or was renamed
+	// to the path of the event.
+	Create Op = 1 << iota
+	// Write indicates that a file was written to.
+	Write
+	// Remove indicates that a file or directory was removed.
+	Remove
+	// Rename indicates that a file or directory was renamed from the path of
+	// the event. A Create event usually follows for the new path.
+	Rename
+	// Chmod indicates that the attributes of a file or directory changed.
+	Chmod
+)
+
+func (op Op) String() string {
+	var names []string
+	for _, o := range []struct {
+		op   Op
+		name string
+	}{
+		{Create, "CREATE"},
+		{Write, "WRITE"},
+		{Remove, "REMOVE"},
+		{Rename, "RENAME"},
+		{Chmod, "CHMOD"},
+	} {
+		if op&o.op != 0 {
+			names = append(names, o.name)
+		}
+	}
+	if len(names) == 0 {
+		return "0"
+	}
+	return strings.Join(names, "|")
+}
+
+// Event describes operations on a file or directory.
+type Event struct {
+	Path string
+	Op   Op
+}
+
+func (e Event) String() string {
+	return e.Op.String() + " " + e.Path
+}
+
+// WatchOptions configures a Watcher. A nil *WatchOptions is equivalent to a
+// zero WatchOptions, which watches directories non-recursively and reports
+// every event as it happens.
+type WatchOptions struct {
+	// Recursive causes directories added to the watcher to be watched along
+	// with every directory below them, including those created later.
+	Recursive bool
+
+	// Debounce delays events until no event has occurred for the given
+	// duration, and then reports the operations on each path as a single
+	// event, in order of path. This suits tools which act on bursts of
+	// changes, such as rebuilding when files are saved.
+	Debounce time.Duration
+}
+
+// Watcher reports changes to watched files and directories. A Watcher must be
+// created with NewWatcher, and is safe for concurrent use.
+//
+// Watching a directory reports changes to the entries of the directory, and
+// watching a file reports changes to the file. Watchers use inotify on Linux,
+// kqueue on BSD systems and macOS, and ReadDirectoryChangesW on Windows.
+type Watcher struct {
+	opts    WatchOptions
+	b       backend
+	events  chan Event
+	errs    chan error
+	raw     chan Event
+	rawErrs chan error
+	done    chan struct{}
+	exited  chan struct{}
+	mu      sync.Mutex
+	roots   map[string]bool
+	watched map[string]bool
+	closed  bool
+}
+
+// backend is implemented by each platform's file system notification API.
+// Backends report events and errors with Watcher.handle and Watcher.fail.
+type backend interface {
+	// add watches a single file or directory, non-recursively unless the
+	// backend is natively recursive.
+	add(path string) error
+	// remove stops watching a path given to add.
+	remove(path string) error
+	// close releases the backend, waiting for its goroutines to return.
+	close() error
+}
+
+// NewWatcher returns a watcher watching nothing. Returns error if file system
+// notification is unsupported or cannot be initialized.
+func NewWatcher(opts *WatchOptions) (*Watcher, error) {
+	w := &Watcher{
+		events:  make(chan Event),
+		errs:    make(chan error),
+		raw:     make(chan Event, 1024),
+		rawErrs: make(chan error),
+		done:    make(chan struct{}),
+		exited:  make(chan struct{}),
+		roots:   make(map[string]bool),
+		watched: make(map[string]bool),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	b, err := newBackend(w)
+	if err != nil {
+		return nil, errors.New(err, "cannot create watcher")
+	}
+	w.b = b
+	go w.run()
+	return w, nil
+}
+
+// Add starts watching the file or directory at path. Returns error if path
+// cannot be watched.
+func (w *Watcher) Add(path string) error {
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.New(err, "cannot watch %s", path)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errors.New(nil, "cannot watch %s: watcher is closed", path)
+	}
+	w.roots[path] = true
+	if info.IsDir() && w.opts.Recursive && !nativeRecursive {
+		_, err := w.addTree(path)
+		return err
+	}
+	return w.addPath(path)
+}
+
+// Close stops watching every path, and closes the channels returned by Events
+// and Errors.
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+	close(w.done)
+	err := w.b.close()
+	<-w.exited
+	if err != nil {
+		return errors.New(err, "cannot close watcher")
+	}
+	return nil
+}
+
+// Errors returns a channel receiving errors encountered while watching, such
+// as the loss of events when the system's event queue overflows.
+func (w *Watcher) Errors() <-chan error {
+	return w.errs
+}
+
+// Events returns a channel receiving events on watched paths.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Remove stops watching path, which must have been given to Add, along with
+// any directories watched below it. Returns error if path is not watched.
+func (w *Watcher) Remove(path string) error {
+	path = filepath.Clean(path)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.roots[path] {
+		return errors.New(nil, "cannot remove %s: not watched", path)
+	}
+	delete(w.roots, path)
+	var errs []error
+	for p := range w.watched {
+		if within(p, path) && (p == path || !w.roots[p]) {
+			if err := w.b.remove(p); err != nil {
+				errs = append(errs, err)
+			}
+			delete(w.watched, p)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(errors.Join(errs...), "cannot remove %s", path)
+	}
+	return nil
+}
+
+// addPath watches a single path. The caller must hold w.mu.
+func (w *Watcher) addPath(path string) error {
+	if w.watched[path] {
+		return nil
+	}
+	if err := w.b.add(path); err != nil {
+		return errors.New(err, "cannot watch %s", path)
+	}
+	w.watched[path] = true
+	return nil
+}
+
+// addTree watches the directory root and every directory below it, and
+// returns the paths of the entries found below root. The caller must hold
+// w.mu.
+func (w *Watcher) addTree(root string) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			// Removed since it was found.
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if path != root {
+			found = append(found, path)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := w.addPath(path); err != nil {
+			if _, serr := os.Stat(path); os.IsNotExist(serr) {
+				return nil
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return found, errors.New(err, "cannot watch %s", root)
+	}
+	return found, nil
+}
+
+// handle processes an event reported by the backend.
+func (w *Watcher) handle(ev Event) {
+	var created []string
+	w.mu.Lock()
+	if ev.Op&(Remove|Rename) != 0 {
+		for p := range w.watched {
+			if within(p, ev.Path) && !w.roots[p] {
+				w.b.remove(p)
+				delete(w.watched, p)
+			}
+		}
+	}
+	if ev.Op&Create != 0 && w.opts.Recursive && !nativeRecursive && !w.closed {
+		if info, err := os.Stat(ev.Path); err == nil && info.IsDir() && w.underRoot(ev.Path) {
+			var err error
+			created, err = w.addTree(ev.Path)
+			if err != nil {
+				defer w.fail(err)
+			}
+		}
+	}
+	w.mu.Unlock()
+	w.send(ev)
+	// Report entries created before the new directory was watched.
+	for _, p := range created {
+		w.send(Event{p, Create})
+	}
+}
+
+func (w *Watcher) send(ev Event) {
+	select {
+	case w.raw <- ev:
+	case <-w.done:
+	}
+}
+
+// fail reports an error encountered by the backend.
+func (w *Watcher) fail(err error) {
+	select {
+	case w.rawErrs <- err:
+	case <-w.done:
+	}
+}
+
+// underRoot reports whether path is a watched root or below a recursively
+// watched root. The caller must hold w.mu.
+func (w *Watcher) underRoot(path string) bool {
+	for root := range w.roots {
+		if within(path, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// run delivers events and errors, debouncing events if configured.
+func (w *Watcher) run() {
+	defer close(w.exited)
+	defer close(w.errs)
+	defer close(w.events)
+	pending := make(map[string]Op)
+	var timer *time.Timer
+	var fire <-chan time.Time
+	for {
+		select {
+		case ev := <-w.raw:
+			if w.opts.Debounce <= 0 {
+				select {
+				case w.events <- ev:
+				case <-w.done:
+					return
+				}
+				continue
+			}
+			pending[ev.Path] |= ev.Op
+			if timer == nil {
+				timer = time.NewTimer(w.opts.Debounce)
+			} else {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(w.opts.Debounce)
+			}
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			paths := make([]string, 0, len(pending))
+			for p := range pending {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			for _, p := range paths {
+				select {
+				case w.events <- Event{p, pending[p]}:
+				case <-w.done:
+					return
+				}
+				delete(pending, p)
+			}
+		case err := <-w.rawErrs:
+			select {
+			case w.errs <- err:
+			case <-w.done:
+				return
+			}
+		case <-w.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
+
+// within reports whether path is dir or lies below it.
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
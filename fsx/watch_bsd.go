@@ -0,0 +1,248 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+const nativeRecursive = false
+
+const kqueueMask = syscall.NOTE_DELETE | syscall.NOTE_WRITE | syscall.NOTE_EXTEND |
+	syscall.NOTE_ATTRIB | syscall.NOTE_RENAME
+
+// kqueue watches files and directories with kqueue. A kqueue event on a
+// directory reports only that its entries changed, so the entries of each
+// watched directory are kept, and compared with the directory after each
+// change. Files in watched directories are watched individually to report
+// writes to them.
+type kqueue struct {
+	w        *Watcher
+	kq       int
+	mu       sync.Mutex
+	fds      map[int]string
+	paths    map[string]int
+	dirs     map[string]map[string]bool
+	explicit map[string]bool
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newBackend(w *Watcher) (backend, error) {
+	kq, err := syscall.Kqueue()
+	if err != nil {
+		return nil, os.NewSyscallError("kqueue", err)
+	}
+	syscall.CloseOnExec(kq)
+	b := &kqueue{
+		w:        w,
+		kq:       kq,
+		fds:      make(map[int]string),
+		paths:    make(map[string]int),
+		dirs:     make(map[string]map[string]bool),
+		explicit: make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.read()
+	return b, nil
+}
+
+func (b *kqueue) add(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.watch(path); err != nil {
+		return err
+	}
+	b.explicit[path] = true
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return nil
+	}
+	names, err := readNames(path)
+	if err != nil {
+		return err
+	}
+	b.dirs[path] = names
+	for name := range names {
+		b.watchFile(filepath.Join(path, name))
+	}
+	return nil
+}
+
+func (b *kqueue) close() error {
+	close(b.done)
+	b.wg.Wait()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for fd := range b.fds {
+		syscall.Close(fd)
+	}
+	return syscall.Close(b.kq)
+}
+
+func (b *kqueue) remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.explicit, path)
+	for name := range b.dirs[path] {
+		child := filepath.Join(path, name)
+		if !b.explicit[child] {
+			b.unwatch(child)
+		}
+	}
+	b.unwatch(path)
+	return nil
+}
+
+func (b *kqueue) read() {
+	defer b.wg.Done()
+	events := make([]syscall.Kevent_t, 64)
+	timeout := syscall.NsecToTimespec(int64(pollInterval))
+	for {
+		select {
+		case <-b.done:
+			return
+		default:
+		}
+		n, err := syscall.Kevent(b.kq, nil, events, &timeout)
+		if err == syscall.EINTR {
+			continue
+		} else if err != nil {
+			b.w.fail(errors.New(os.NewSyscallError("kevent", err), "cannot read events"))
+			return
+		}
+		for _, ev := range events[:n] {
+			for _, e := range b.translate(int(ev.Ident), ev.Fflags) {
+				b.w.handle(e)
+			}
+		}
+	}
+}
+
+// translate returns the events described by flags for the file descriptor fd.
+func (b *kqueue) translate(fd int, flags uint32) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	path, ok := b.fds[fd]
+	if !ok {
+		return nil
+	}
+	_, isDir := b.dirs[path]
+	var events []Event
+	if isDir && flags&syscall.NOTE_WRITE != 0 {
+		events = append(events, b.rescan(path)...)
+	}
+	if !isDir && flags&(syscall.NOTE_WRITE|syscall.NOTE_EXTEND) != 0 {
+		events = append(events, Event{path, Write})
+	}
+	if flags&syscall.NOTE_ATTRIB != 0 && (!isDir || b.explicit[path]) {
+		events = append(events, Event{path, Chmod})
+	}
+	if flags&(syscall.NOTE_DELETE|syscall.NOTE_RENAME) != 0 {
+		op := Remove
+		if flags&syscall.NOTE_RENAME != 0 {
+			op = Rename
+		}
+		if !isDir || b.explicit[path] {
+			events = append(events, Event{path, op})
+		}
+		for name := range b.dirs[path] {
+			if child := filepath.Join(path, name); !b.explicit[child] {
+				b.unwatch(child)
+			}
+		}
+		b.unwatch(path)
+		if names, ok := b.dirs[filepath.Dir(path)]; ok {
+			delete(names, filepath.Base(path))
+		}
+	}
+	return events
+}
+
+// rescan compares the entries of the watched directory dir with those last
+// seen, and returns the resulting events. The caller must hold b.mu.
+func (b *kqueue) rescan(dir string) []Event {
+	names, err := readNames(dir)
+	if err != nil {
+		return nil
+	}
+	old := b.dirs[dir]
+	var events []Event
+	for name := range names {
+		if !old[name] {
+			child := filepath.Join(dir, name)
+			events = append(events, Event{child, Create})
+			b.watchFile(child)
+		}
+	}
+	for name := range old {
+		if !names[name] {
+			child := filepath.Join(dir, name)
+			if _, watched := b.paths[child]; !watched {
+				events = append(events, Event{child, Remove})
+			}
+		}
+	}
+	b.dirs[dir] = names
+	return events
+}
+
+// unwatch stops watching path. The caller must hold b.mu.
+func (b *kqueue) unwatch(path string) {
+	fd, ok := b.paths[path]
+	if !ok {
+		return
+	}
+	syscall.Close(fd)
+	delete(b.paths, path)
+	delete(b.fds, fd)
+	delete(b.dirs, path)
+}
+
+// watch starts watching path. The caller must hold b.mu.
+func (b *kqueue) watch(path string) error {
+	if _, ok := b.paths[path]; ok {
+		return nil
+	}
+	fd, err := syscall.Open(path, syscall.O_RDONLY|syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return os.NewSyscallError("open", err)
+	}
+	var ev syscall.Kevent_t
+	syscall.SetKevent(&ev, fd, syscall.EVFILT_VNODE, syscall.EV_ADD|syscall.EV_CLEAR|syscall.EV_ENABLE)
+	ev.Fflags = kqueueMask
+	if _, err := syscall.Kevent(b.kq, []syscall.Kevent_t{ev}, nil, nil); err != nil {
+		syscall.Close(fd)
+		return os.NewSyscallError("kevent", err)
+	}
+	b.fds[fd] = path
+	b.paths[path] = fd
+	return nil
+}
+
+// watchFile watches path if it is a regular file. Directories are watched by
+// Watcher when watching recursively. The caller must hold b.mu.
+func (b *kqueue) watchFile(path string) {
+	info, err := os.Lstat(path)
+	if err == nil && info.Mode().IsRegular() {
+		b.watch(path)
+	}
+}
+
+func readNames(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		names[e.Name()] = true
+	}
+	return names, nil
+}
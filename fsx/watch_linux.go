@@ -0,0 +1,164 @@
+//go:build linux
+
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// nativeRecursive reports whether the backend watches directories
+// recursively by itself.
+const nativeRecursive = false
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_DELETE | syscall.IN_DELETE_SELF | syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO | syscall.IN_MOVE_SELF
+
+type inotify struct {
+	w     *Watcher
+	fd    int
+	f     *os.File
+	mu    sync.Mutex
+	paths map[int32]string
+	wds   map[string]int32
+	wg    sync.WaitGroup
+}
+
+func newBackend(w *Watcher) (backend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	b := &inotify{
+		w:     w,
+		fd:    fd,
+		f:     os.NewFile(uintptr(fd), "inotify"),
+		paths: make(map[int32]string),
+		wds:   make(map[string]int32),
+	}
+	b.wg.Add(1)
+	go b.read()
+	return b, nil
+}
+
+func (b *inotify) add(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wd, err := syscall.InotifyAddWatch(b.fd, path, inotifyMask)
+	if err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+	b.paths[int32(wd)] = path
+	b.wds[path] = int32(wd)
+	return nil
+}
+
+func (b *inotify) close() error {
+	err := b.f.Close()
+	b.wg.Wait()
+	return err
+}
+
+func (b *inotify) remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wd, ok := b.wds[path]
+	if !ok {
+		return nil
+	}
+	delete(b.wds, path)
+	delete(b.paths, wd)
+	if _, err := syscall.InotifyRmWatch(b.fd, uint32(wd)); err != nil && err != syscall.EINVAL {
+		return os.NewSyscallError("inotify_rm_watch", err)
+	}
+	return nil
+}
+
+func (b *inotify) read() {
+	defer b.wg.Done()
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := b.f.Read(buf)
+		if err != nil {
+			select {
+			case <-b.w.done:
+			default:
+				b.w.fail(errors.New(err, "cannot read events"))
+			}
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameBytes := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(raw.Len)]
+			off += syscall.SizeofInotifyEvent + int(raw.Len)
+			if raw.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				b.w.fail(errors.New(nil, "event queue overflowed"))
+				continue
+			}
+			b.mu.Lock()
+			path, ok := b.paths[raw.Wd]
+			if raw.Mask&syscall.IN_IGNORED != 0 && ok {
+				delete(b.paths, raw.Wd)
+				if b.wds[path] == raw.Wd {
+					delete(b.wds, path)
+				}
+			}
+			b.mu.Unlock()
+			if !ok {
+				continue
+			}
+			self := true
+			if i := indexZero(nameBytes); i > 0 {
+				path = filepath.Join(path, string(nameBytes[:i]))
+				self = false
+			}
+			var op Op
+			switch {
+			case raw.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+				op = Create
+			case raw.Mask&syscall.IN_MODIFY != 0:
+				op = Write
+			case raw.Mask&syscall.IN_ATTRIB != 0:
+				op = Chmod
+			case raw.Mask&syscall.IN_DELETE != 0:
+				op = Remove
+			case raw.Mask&syscall.IN_MOVED_FROM != 0:
+				op = Rename
+			case raw.Mask&syscall.IN_DELETE_SELF != 0 && b.isRoot(path):
+				op = Remove
+			case raw.Mask&syscall.IN_MOVE_SELF != 0 && b.isRoot(path):
+				op = Rename
+			}
+			if raw.Mask&syscall.IN_MOVE_SELF != 0 && self {
+				// The watch follows the moved file, whose path is now stale.
+				b.remove(path)
+			}
+			if op != 0 {
+				b.w.handle(Event{path, op})
+			}
+		}
+	}
+}
+
+// isRoot reports whether path was given to Watcher.Add, so that events on
+// the path itself are reported, rather than by the watch of its parent.
+func (b *inotify) isRoot(path string) bool {
+	b.w.mu.Lock()
+	defer b.w.mu.Unlock()
+	return b.w.roots[path]
+}
+
+func indexZero(b []byte) int {
+	for i, c := range b {
+		if c == 0 {
+			return i
+		}
+	}
+	return len(b)
+}
@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package fsx
+
+import (
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+const nativeRecursive = false
+
+func newBackend(w *Watcher) (backend, error) {
+	return nil, errors.New(nil, "file system notification is not supported on this platform")
+}
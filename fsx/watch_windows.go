@@ -0,0 +1,245 @@
+//go:build windows
+
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+const nativeRecursive = true
+
+const windowsFilter = syscall.FILE_NOTIFY_CHANGE_FILE_NAME | syscall.FILE_NOTIFY_CHANGE_DIR_NAME |
+	syscall.FILE_NOTIFY_CHANGE_ATTRIBUTES | syscall.FILE_NOTIFY_CHANGE_SIZE |
+	syscall.FILE_NOTIFY_CHANGE_LAST_WRITE | syscall.FILE_NOTIFY_CHANGE_CREATION
+
+// windowsWatch is a pending ReadDirectoryChangesW call on a directory. A file
+// is watched through its parent directory, reporting only events whose name
+// matches the file.
+type windowsWatch struct {
+	key       uint32
+	path      string
+	dir       string
+	name      string
+	recursive bool
+	h         syscall.Handle
+	ov        syscall.Overlapped
+	buf       [64 * 1024]byte
+}
+
+// windows watches directories with ReadDirectoryChangesW, receiving the
+// completion of each call through an I/O completion port.
+type windows struct {
+	w       *Watcher
+	port    syscall.Handle
+	mu      sync.Mutex
+	next    uint32
+	watches map[uint32]*windowsWatch
+	keys    map[string]uint32
+	// closing holds removed watches until their cancellation completes, as
+	// the system may write to them until then.
+	closing map[uint32]*windowsWatch
+	quit    bool
+	wg      sync.WaitGroup
+}
+
+func newBackend(w *Watcher) (backend, error) {
+	port, err := syscall.CreateIoCompletionPort(syscall.InvalidHandle, 0, 0, 1)
+	if err != nil {
+		return nil, os.NewSyscallError("CreateIoCompletionPort", err)
+	}
+	b := &windows{
+		w:       w,
+		port:    port,
+		watches: make(map[uint32]*windowsWatch),
+		keys:    make(map[string]uint32),
+		closing: make(map[uint32]*windowsWatch),
+	}
+	b.wg.Add(1)
+	go b.read()
+	return b, nil
+}
+
+func (b *windows) add(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	ww := &windowsWatch{path: path, dir: path}
+	if info.IsDir() {
+		ww.recursive = b.w.opts.Recursive
+	} else {
+		ww.dir, ww.name = filepath.Split(path)
+		ww.dir = filepath.Clean(ww.dir)
+	}
+	name, err := syscall.UTF16PtrFromString(ww.dir)
+	if err != nil {
+		return err
+	}
+	ww.h, err = syscall.CreateFile(name,
+		syscall.FILE_LIST_DIRECTORY,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING,
+		syscall.FILE_FLAG_BACKUP_SEMANTICS|syscall.FILE_FLAG_OVERLAPPED, 0,
+	)
+	if err != nil {
+		return os.NewSyscallError("CreateFile", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.next++
+	ww.key = b.next
+	if _, err := syscall.CreateIoCompletionPort(ww.h, b.port, ww.key, 0); err != nil {
+		syscall.CloseHandle(ww.h)
+		return os.NewSyscallError("CreateIoCompletionPort", err)
+	}
+	if err := b.start(ww); err != nil {
+		syscall.CloseHandle(ww.h)
+		return err
+	}
+	b.watches[ww.key] = ww
+	b.keys[path] = ww.key
+	return nil
+}
+
+func (b *windows) close() error {
+	b.mu.Lock()
+	b.quit = true
+	for key := range b.watches {
+		b.unwatch(key)
+	}
+	b.mu.Unlock()
+	if err := syscall.PostQueuedCompletionStatus(b.port, 0, 0, nil); err != nil {
+		return os.NewSyscallError("PostQueuedCompletionStatus", err)
+	}
+	b.wg.Wait()
+	return syscall.CloseHandle(b.port)
+}
+
+func (b *windows) remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if key, ok := b.keys[path]; ok {
+		b.unwatch(key)
+	}
+	return nil
+}
+
+func (b *windows) read() {
+	defer b.wg.Done()
+	for {
+		var n, key uint32
+		var ov *syscall.Overlapped
+		err := syscall.GetQueuedCompletionStatus(b.port, &n, &key, &ov, syscall.INFINITE)
+		b.mu.Lock()
+		if key == 0 && ov == nil {
+			if b.quit {
+				b.mu.Unlock()
+				return
+			}
+			b.mu.Unlock()
+			continue
+		}
+		if _, ok := b.closing[key]; ok {
+			delete(b.closing, key)
+			b.mu.Unlock()
+			continue
+		}
+		ww, ok := b.watches[key]
+		if !ok {
+			b.mu.Unlock()
+			continue
+		}
+		var events []Event
+		var failure error
+		switch {
+		case err == syscall.ERROR_OPERATION_ABORTED:
+		case err == syscall.ERROR_ACCESS_DENIED:
+			// The watched directory was removed.
+			events = append(events, Event{ww.path, Remove})
+			b.unwatch(key)
+			delete(b.closing, key)
+		case err != nil:
+			b.mu.Unlock()
+			b.w.fail(errors.New(os.NewSyscallError("ReadDirectoryChanges", err), "cannot read events for %s", ww.path))
+			continue
+		case n == 0:
+			failure = errors.New(nil, "event queue overflowed for %s", ww.path)
+		default:
+			events = b.parse(ww, n)
+		}
+		if _, ok := b.watches[key]; ok {
+			if err := b.start(ww); err != nil {
+				b.unwatch(key)
+				failure = errors.New(err, "cannot watch %s", ww.path)
+			}
+		}
+		b.mu.Unlock()
+		for _, ev := range events {
+			b.w.handle(ev)
+		}
+		if failure != nil {
+			b.w.fail(failure)
+		}
+	}
+}
+
+// parse returns the events in the first n bytes of the buffer of ww.
+func (b *windows) parse(ww *windowsWatch, n uint32) []Event {
+	var events []Event
+	for offset := uint32(0); offset < n; {
+		info := (*syscall.FileNotifyInformation)(unsafe.Pointer(&ww.buf[offset]))
+		name := syscall.UTF16ToString(unsafe.Slice(&info.FileName, info.FileNameLength/2))
+		if ww.name == "" || strings.EqualFold(name, ww.name) {
+			var op Op
+			switch info.Action {
+			case syscall.FILE_ACTION_ADDED, syscall.FILE_ACTION_RENAMED_NEW_NAME:
+				op = Create
+			case syscall.FILE_ACTION_REMOVED:
+				op = Remove
+			case syscall.FILE_ACTION_MODIFIED:
+				op = Write
+			case syscall.FILE_ACTION_RENAMED_OLD_NAME:
+				op = Rename
+			}
+			if op != 0 {
+				events = append(events, Event{filepath.Join(ww.dir, name), op})
+			}
+		}
+		if info.NextEntryOffset == 0 {
+			break
+		}
+		offset += info.NextEntryOffset
+	}
+	return events
+}
+
+// start issues a ReadDirectoryChangesW call for ww. The caller must hold b.mu.
+func (b *windows) start(ww *windowsWatch) error {
+	ww.ov = syscall.Overlapped{}
+	err := syscall.ReadDirectoryChanges(ww.h, &ww.buf[0], uint32(len(ww.buf)),
+		ww.recursive, windowsFilter, nil, &ww.ov, 0)
+	if err != nil {
+		return os.NewSyscallError("ReadDirectoryChanges", err)
+	}
+	return nil
+}
+
+// unwatch closes the watch identified by key, cancelling its pending call.
+// The caller must hold b.mu.
+func (b *windows) unwatch(key uint32) {
+	ww, ok := b.watches[key]
+	if !ok {
+		return
+	}
+	syscall.CloseHandle(ww.h)
+	delete(b.watches, key)
+	delete(b.keys, ww.path)
+	b.closing[key] = ww
+}
@@ -0,0 +1,97 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Files returns the names of the files and directories matching pattern, as
+// compiled by Compile, in lexical order. The pattern is a path in the
+// slash-separated form of package path, which may be absolute or relative to
+// the current directory; the names returned are in the form of package
+// filepath. Unlike a shell, * and ? match names starting with a dot.
+//
+// A pattern with no special characters names a file, and if the file does not
+// exist, no names are returned. Returns error if pattern is malformed, or if
+// a directory cannot be read.
+func Files(pattern string) ([]string, error) {
+	if strings.HasPrefix(pattern, "!") {
+		return nil, errors.New(nil, "invalid pattern %q: cannot select files by negated pattern", pattern)
+	}
+	pattern = filepath.ToSlash(pattern)
+	if !HasMeta(pattern) {
+		name := filepath.FromSlash(pattern)
+		if _, err := os.Lstat(name); err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, errors.New(err, "cannot select files by %q", pattern)
+		}
+		return []string{name}, nil
+	}
+	g, err := Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	// Walk from the longest leading directory without special characters.
+	elems := strings.Split(pattern, "/")
+	n := 0
+	for n < len(elems)-1 && !HasMeta(elems[n]) {
+		n++
+	}
+	base := strings.Join(elems[:n], "/")
+	if n == 1 && base == "" {
+		base = "/"
+	}
+	maxDepth := len(elems) - n
+	if strings.Contains(strings.Join(elems[n:], "/"), "**") {
+		maxDepth = -1
+	}
+	var names []string
+	err = walkFiles(base, 1, maxDepth, func(rel string) {
+		if g.Match(rel) {
+			names = append(names, filepath.FromSlash(rel))
+		}
+	})
+	if err != nil {
+		return nil, errors.New(err, "cannot select files by %q", pattern)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// walkFiles calls fn with the slash-separated path of each entry below dir, to
+// the given depth, or without limit if maxDepth is negative. Entries are given
+// in lexical order, each directory before its contents.
+func walkFiles(dir string, depth, maxDepth int, fn func(string)) error {
+	name := dir
+	if name == "" {
+		name = "."
+	}
+	entries, err := os.ReadDir(filepath.FromSlash(name))
+	if err != nil {
+		if os.IsNotExist(err) && depth == 1 {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		rel := e.Name()
+		if strings.HasSuffix(dir, "/") {
+			rel = dir + rel
+		} else if dir != "" {
+			rel = dir + "/" + rel
+		}
+		fn(rel)
+		if e.IsDir() && (maxDepth < 0 || depth < maxDepth) {
+			if err := walkFiles(rel, depth+1, maxDepth, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+// Package glob implements extended glob patterns.
+//
+// A pattern is compiled once and may then be matched against any number of
+// names:
+//
+//	g, err := glob.Compile("src/**/*.{c,h}")
+//	if g.Match("src/lib/util.h") {
+//		...
+//	}
+//
+// Patterns compiled by Compile match slash-separated paths, in which * and ?
+// never match a slash and ** matches any number of path elements. Patterns
+// compiled by CompilePlain match arbitrary strings, in which * and ? match any
+// character.
+//
+// A Set holds an ordered list of patterns, of which later negated patterns
+// exclude names matched by earlier ones, as in a .gitignore file:
+//
+//	s, err := glob.NewSet("*.go", "!*_test.go")
+//	s.Match("main.go")      // true
+//	s.Match("main_test.go") // false
+//
+// Files expands a pattern into the names of the files matching it.
+package glob
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Glob is a compiled glob pattern. A Glob is safe for concurrent use.
+type Glob struct {
+	pattern string
+	negate  bool
+	re      *regexp.Regexp
+}
+
+// Compile compiles a pattern which matches slash-separated paths. The pattern
+// syntax is:
+//
+//	?        any single character other than a slash
+//	*        any sequence of characters other than a slash
+//	**       as a whole path element, any number of path elements, including
+//	         none, so that "a/**/b" matches "a/b" and "a/x/y/b", and "a/**"
+//	         matches "a" and everything below it; otherwise as *
+//	[class]  any single character in class
+//	{a,b}    any of the comma-separated alternatives, which are themselves
+//	         patterns, and may be nested
+//	\c       the character c
+//	c        the character c, for any other c
+//
+// A class is a list of characters, ranges such as a-z, and named classes such
+// as [:alpha:], as accepted by package regexp. A class starting with ! or ^
+// matches any character not in the list, other than a slash. A ] at the start
+// of the list is taken literally.
+//
+// A pattern starting with ! is negated, and matches exactly those names which
+// the rest of the pattern does not.
+//
+// Returns error if pattern is malformed.
+func Compile(pattern string) (*Glob, error) {
+	return compile(pattern, true)
+}
+
+// CompilePlain compiles a pattern which matches arbitrary strings. The syntax
+// is that of Compile, except that a slash is an ordinary character, which *, ?
+// and classes may match, and ** is equivalent to *.
+//
+// Returns error if pattern is malformed.
+func CompilePlain(pattern string) (*Glob, error) {
+	return compile(pattern, false)
+}
+
+// HasMeta reports whether s contains any of the characters special in a
+// pattern, and so would not match only itself.
+func HasMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[{\!`)
+}
+
+// Match reports whether the slash-separated path name matches pattern, as
+// compiled by Compile. Returns error if pattern is malformed.
+func Match(pattern, name string) (bool, error) {
+	g, err := Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return g.Match(name), nil
+}
+
+// Match reports whether name matches g.
+func (g *Glob) Match(name string) bool {
+	return g.re.MatchString(name) != g.negate
+}
+
+// String returns the pattern from which g was compiled.
+func (g *Glob) String() string {
+	return g.pattern
+}
+
+// Set is an ordered list of path patterns. A Set is safe for concurrent use.
+type Set struct {
+	globs []*Glob
+}
+
+// NewSet compiles patterns into a set, as by Compile. Returns error if any
+// pattern is malformed.
+func NewSet(patterns ...string) (*Set, error) {
+	s := &Set{}
+	for _, p := range patterns {
+		g, err := Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		s.globs = append(s.globs, g)
+	}
+	return s, nil
+}
+
+// Match reports whether name matches s. The last pattern in s which matches
+// name, ignoring any negation, decides: name matches s if that pattern is not
+// negated. A name which no pattern matches does not match s, so that a set of
+// patterns none of which is negated matches the names matching any of them.
+func (s *Set) Match(name string) bool {
+	for i := len(s.globs) - 1; i >= 0; i-- {
+		g := s.globs[i]
+		if g.re.MatchString(name) {
+			return !g.negate
+		}
+	}
+	return false
+}
+
+func compile(pattern string, paths bool) (*Glob, error) {
+	g := &Glob{pattern: pattern}
+	p := pattern
+	if strings.HasPrefix(p, "!") {
+		g.negate = true
+		p = p[1:]
+	}
+	expr, err := translate(p, paths)
+	if err != nil {
+		return nil, errors.New(err, "invalid pattern %q", pattern)
+	}
+	g.re, err = regexp.Compile(expr)
+	if err != nil {
+		return nil, errors.New(err, "invalid pattern %q", pattern)
+	}
+	return g, nil
+}
+
+// translate translates a pattern into an equivalent regular expression.
+func translate(p string, paths bool) (string, error) {
+	star, single := ".*", "."
+	if paths {
+		star, single = "[^/]*", "[^/]"
+	}
+	var sb strings.Builder
+	sb.WriteString(`^(?s:`)
+	depth := 0
+	// boundary reports whether p[i] ends a path element.
+	boundary := func(i int) bool {
+		return i == len(p) || p[i] == '/' || p[i] == '}' && depth > 0 || p[i] == ',' && depth > 0
+	}
+	for i := 0; i < len(p); {
+		switch c := p[i]; c {
+		case '*':
+			start := i == 0 || p[i-1] == '/' || p[i-1] == '{' || p[i-1] == ',' && depth > 0
+			n := 1
+			for i+n < len(p) && p[i+n] == '*' {
+				n++
+			}
+			switch {
+			case !paths || n == 1 || !start || !boundary(i+n):
+				sb.WriteString(star)
+			case i+n < len(p) && p[i+n] == '/':
+				sb.WriteString(`(?:.*/)?`)
+				n++
+			default:
+				sb.WriteString(`.*`)
+			}
+			i += n
+		case '/':
+			// A trailing /** also matches the directory itself.
+			if paths && strings.HasPrefix(p[i:], "/**") && boundary(i+3) {
+				sb.WriteString(`(?:/.*)?`)
+				i += 3
+			} else {
+				sb.WriteByte('/')
+				i++
+			}
+		case '?':
+			sb.WriteString(single)
+			i++
+		case '[':
+			n, err := translateClass(&sb, p[i:], paths)
+			if err != nil {
+				return "", err
+			}
+			i += n
+		case '{':
+			sb.WriteString(`(?:`)
+			depth++
+			i++
+		case ',':
+			if depth > 0 {
+				sb.WriteByte('|')
+			} else {
+				sb.WriteByte(',')
+			}
+			i++
+		case '}':
+			if depth == 0 {
+				return "", errors.New(nil, "unmatched }")
+			}
+			sb.WriteByte(')')
+			depth--
+			i++
+		case '\\':
+			if i+1 == len(p) {
+				return "", errors.New(nil, "trailing backslash")
+			}
+			r, n := utf8.DecodeRuneInString(p[i+1:])
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			i += 1 + n
+		default:
+			r, n := utf8.DecodeRuneInString(p[i:])
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			i += n
+		}
+	}
+	if depth > 0 {
+		return "", errors.New(nil, "unmatched {")
+	}
+	sb.WriteString(`)$`)
+	return sb.String(), nil
+}
+
+// translateClass translates the class at the start of p into sb, and returns
+// its length in p.
+func translateClass(sb *strings.Builder, p string, paths bool) (int, error) {
+	i := 1
+	sb.WriteByte('[')
+	if i < len(p) && (p[i] == '!' || p[i] == '^') {
+		sb.WriteByte('^')
+		if paths {
+			sb.WriteByte('/')
+		}
+		i++
+	}
+	first := true
+	for {
+		if i == len(p) {
+			return 0, errors.New(nil, "unterminated class")
+		}
+		if p[i] == ']' && !first {
+			break
+		}
+		first = false
+		if strings.HasPrefix(p[i:], "[:") {
+			if end := strings.Index(p[i:], ":]"); end > 0 {
+				sb.WriteString(p[i : i+end+2])
+				i += end + 2
+				continue
+			}
+		}
+		lo, n, err := classChar(p[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n
+		if strings.HasPrefix(p[i:], "-") && i+1 < len(p) && p[i+1] != ']' {
+			hi, n, err := classChar(p[i+1:])
+			if err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, errors.New(nil, "invalid range %c-%c", lo, hi)
+			}
+			i += 1 + n
+			sb.WriteString(quoteClass(lo) + "-" + quoteClass(hi))
+			continue
+		}
+		sb.WriteString(quoteClass(lo))
+	}
+	sb.WriteByte(']')
+	return i + 1, nil
+}
+
+// classChar decodes the possibly escaped character at the start of p, and
+// returns it with its length in p.
+func classChar(p string) (rune, int, error) {
+	if p[0] != '\\' {
+		r, n := utf8.DecodeRuneInString(p)
+		return r, n, nil
+	}
+	if len(p) == 1 {
+		return 0, 0, errors.New(nil, "trailing backslash")
+	}
+	r, n := utf8.DecodeRuneInString(p[1:])
+	return r, 1 + n, nil
+}
+
+// quoteClass returns r quoted for use within a regular expression class.
+func quoteClass(r rune) string {
+	switch r {
+	case '\\', ']', '[', '^', '-':
+		return `\` + string(r)
+	}
+	return string(r)
+}
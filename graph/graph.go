@@ -0,0 +1,283 @@
+// Package graph implements directed and undirected graphs.
+//
+// A Graph holds a set of nodes of any comparable type and the edges between
+// them. Nodes and edges are kept in insertion order, so that traversals and
+// sorts over the same graph always produce the same result.
+//
+// A common use of directed graphs is dependency resolution, where an edge from
+// a to b means that a must come before b:
+//
+//	g := graph.NewDirected[string]()
+//	g.AddEdge("fetch", "build")
+//	g.AddEdge("build", "test")
+//	order, err := g.TopoSort()
+//
+// TopoSort returns error if the dependencies contain a cycle, and the cycle
+// itself can be found using FindCycle.
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Graph represents a directed or undirected graph with nodes of type T.
+//
+// A Graph is not safe for concurrent use by multiple goroutines.
+type Graph[T comparable] struct {
+	directed bool
+	nodes    []T
+	index    map[T]int
+	adj      [][]int
+	edges    map[[2]int]bool
+}
+
+// NewDirected returns a new, empty directed graph.
+func NewDirected[T comparable]() *Graph[T] {
+	return &Graph[T]{
+		directed: true,
+		index:    make(map[T]int),
+		edges:    make(map[[2]int]bool),
+	}
+}
+
+// NewUndirected returns a new, empty undirected graph.
+func NewUndirected[T comparable]() *Graph[T] {
+	return &Graph[T]{
+		directed: false,
+		index:    make(map[T]int),
+		edges:    make(map[[2]int]bool),
+	}
+}
+
+// AddEdge adds an edge from node from to node to, adding either node to g if it
+// is not already present. If g is undirected, the edge is also traversable from
+// to to from. Adding an edge which already exists has no effect.
+func (g *Graph[T]) AddEdge(from, to T) {
+	i, j := g.add(from), g.add(to)
+	if g.edges[[2]int{i, j}] {
+		return
+	}
+	g.edges[[2]int{i, j}] = true
+	g.adj[i] = append(g.adj[i], j)
+	if !g.directed && i != j {
+		g.edges[[2]int{j, i}] = true
+		g.adj[j] = append(g.adj[j], i)
+	}
+}
+
+// AddNode adds node n to g, if it is not already present.
+func (g *Graph[T]) AddNode(n T) {
+	g.add(n)
+}
+
+// BFS traverses g in breadth-first order starting from node start, calling
+// visit for each reachable node. Traversal stops early if visit returns false.
+// Returns error if start is not a node of g.
+func (g *Graph[T]) BFS(start T, visit func(T) bool) error {
+	i, ok := g.index[start]
+	if !ok {
+		return errors.New(nil, "node %v not in graph", start)
+	}
+	seen := make([]bool, len(g.nodes))
+	seen[i] = true
+	queue := []int{i}
+	for len(queue) > 0 {
+		i, queue = queue[0], queue[1:]
+		if !visit(g.nodes[i]) {
+			return nil
+		}
+		for _, j := range g.adj[i] {
+			if !seen[j] {
+				seen[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+	return nil
+}
+
+// DFS traverses g in depth-first order starting from node start, calling visit
+// for each reachable node before any of its descendants. Traversal stops early
+// if visit returns false. Returns error if start is not a node of g.
+func (g *Graph[T]) DFS(start T, visit func(T) bool) error {
+	i, ok := g.index[start]
+	if !ok {
+		return errors.New(nil, "node %v not in graph", start)
+	}
+	seen := make([]bool, len(g.nodes))
+	var walk func(i int) bool
+	walk = func(i int) bool {
+		seen[i] = true
+		if !visit(g.nodes[i]) {
+			return false
+		}
+		for _, j := range g.adj[i] {
+			if !seen[j] && !walk(j) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(i)
+	return nil
+}
+
+// Directed reports whether g is a directed graph.
+func (g *Graph[T]) Directed() bool {
+	return g.directed
+}
+
+// FindCycle returns the nodes of a cycle in g, in order, or nil if g has no
+// cycles. The first node of the cycle is not repeated at the end. In an
+// undirected graph, a single edge between two distinct nodes does not form a
+// cycle.
+func (g *Graph[T]) FindCycle() []T {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.nodes))
+	parent := make([]int, len(g.nodes))
+	var cycle []T
+	var walk func(i int) bool
+	walk = func(i int) bool {
+		state[i] = visiting
+		for _, j := range g.adj[i] {
+			if !g.directed && j == parent[i] && i != j {
+				continue
+			}
+			switch state[j] {
+			case unvisited:
+				parent[j] = i
+				if walk(j) {
+					return true
+				}
+			case visiting:
+				for k := i; k != j; k = parent[k] {
+					cycle = append(cycle, g.nodes[k])
+				}
+				cycle = append(cycle, g.nodes[j])
+				for l, r := 0, len(cycle)-1; l < r; l, r = l+1, r-1 {
+					cycle[l], cycle[r] = cycle[r], cycle[l]
+				}
+				return true
+			}
+		}
+		state[i] = visited
+		return false
+	}
+	for i := range g.nodes {
+		if state[i] == unvisited {
+			parent[i] = -1
+			if walk(i) {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// HasCycle reports whether g contains a cycle.
+func (g *Graph[T]) HasCycle() bool {
+	return g.FindCycle() != nil
+}
+
+// HasEdge reports whether g contains an edge from node from to node to.
+func (g *Graph[T]) HasEdge(from, to T) bool {
+	i, ok := g.index[from]
+	if !ok {
+		return false
+	}
+	j, ok := g.index[to]
+	if !ok {
+		return false
+	}
+	return g.edges[[2]int{i, j}]
+}
+
+// HasNode reports whether n is a node of g.
+func (g *Graph[T]) HasNode(n T) bool {
+	_, ok := g.index[n]
+	return ok
+}
+
+// Neighbors returns the nodes reachable from node n by a single edge, in the
+// order in which the edges were added. Returns error if n is not a node of g.
+func (g *Graph[T]) Neighbors(n T) ([]T, error) {
+	i, ok := g.index[n]
+	if !ok {
+		return nil, errors.New(nil, "node %v not in graph", n)
+	}
+	neighbors := make([]T, len(g.adj[i]))
+	for k, j := range g.adj[i] {
+		neighbors[k] = g.nodes[j]
+	}
+	return neighbors, nil
+}
+
+// Nodes returns the nodes of g in the order in which they were added.
+func (g *Graph[T]) Nodes() []T {
+	return append([]T(nil), g.nodes...)
+}
+
+// TopoSort returns the nodes of g in topological order, such that for every
+// edge from a to b, a comes before b. Among nodes whose relative order is not
+// constrained by any edge, the order in which nodes were added is preserved.
+//
+// Returns error if g is undirected or if g contains a cycle. The error
+// describes the offending cycle.
+func (g *Graph[T]) TopoSort() ([]T, error) {
+	if !g.directed {
+		return nil, errors.New(nil, "cannot sort undirected graph")
+	}
+	indegree := make([]int, len(g.nodes))
+	for _, adj := range g.adj {
+		for _, j := range adj {
+			indegree[j]++
+		}
+	}
+	var queue []int
+	for i, n := range indegree {
+		if n == 0 {
+			queue = append(queue, i)
+		}
+	}
+	sorted := make([]T, 0, len(g.nodes))
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		sorted = append(sorted, g.nodes[i])
+		for _, j := range g.adj[i] {
+			indegree[j]--
+			if indegree[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+	if len(sorted) < len(g.nodes) {
+		cycle := g.FindCycle()
+		path := make([]string, 0, len(cycle)+1)
+		for _, n := range append(cycle, cycle[0]) {
+			path = append(path, fmt.Sprint(n))
+		}
+		return nil, errors.New(nil,
+			"graph contains cycle: %s", strings.Join(path, " -> "),
+		)
+	}
+	return sorted, nil
+}
+
+func (g *Graph[T]) add(n T) int {
+	if i, ok := g.index[n]; ok {
+		return i
+	}
+	i := len(g.nodes)
+	g.index[n] = i
+	g.nodes = append(g.nodes, n)
+	g.adj = append(g.adj, nil)
+	return i
+}
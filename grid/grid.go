@@ -0,0 +1,206 @@
+// Package grid implements two-dimensional grids.
+//
+// A Grid stores width × height cells in a single contiguous slice, addressed
+// by column x and row y, where (0, 0) is the top-left cell. All accessors are
+// bounds-checked and return errors rather than panicking.
+//
+// The Sub method returns a view onto a rectangular region of a grid. A view
+// shares its cells with the grid it was taken from, so that board regions,
+// image masks and simulation neighbourhoods can be manipulated in place:
+//
+//	g, _ := grid.New[bool](80, 24)
+//	box, _ := g.Sub(10, 5, 20, 10)
+//	box.Fill(true)
+package grid
+
+import (
+	"git.sr.ht/~kvo/go-std"
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Grid represents a two-dimensional grid of cells of type T.
+type Grid[T any] struct {
+	cells  []T
+	offset int
+	stride int
+	width  int
+	height int
+}
+
+// New returns a grid of the given width and height whose cells hold the zero
+// value of T. Returns error if width or height is negative.
+func New[T any](width, height int) (*Grid[T], error) {
+	if width < 0 || height < 0 {
+		return nil, errors.New(nil, "invalid grid size %dx%d", width, height)
+	}
+	return &Grid[T]{
+		cells:  make([]T, width*height),
+		stride: width,
+		width:  width,
+		height: height,
+	}, nil
+}
+
+// FromRows returns a grid whose cells are copied from rows, such that rows[y][x]
+// becomes the cell at (x, y). Returns error if the rows are not all of the same
+// length.
+func FromRows[T any](rows [][]T) (*Grid[T], error) {
+	width := 0
+	if len(rows) > 0 {
+		width = len(rows[0])
+	}
+	g, err := New[T](width, len(rows))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	for y, row := range rows {
+		if len(row) != width {
+			return nil, errors.New(nil,
+				"row %d has length %d, expected %d", y, len(row), width,
+			)
+		}
+		copy(g.cells[y*width:], row)
+	}
+	return g, nil
+}
+
+// At returns the cell at column x and row y. Returns error if (x, y) lies
+// outside of g.
+func (g *Grid[T]) At(x, y int) (T, error) {
+	if err := g.check(x, y); err != nil {
+		var none T
+		return none, err
+	}
+	return g.cells[g.index(x, y)], nil
+}
+
+// Clone returns a copy of g which does not share cells with g.
+func (g *Grid[T]) Clone() *Grid[T] {
+	clone, _ := New[T](g.width, g.height)
+	for y := 0; y < g.height; y++ {
+		copy(clone.cells[y*g.width:], g.row(y))
+	}
+	return clone
+}
+
+// Col returns a copy of the cells in column x, from top to bottom. Returns
+// error if g has no column x.
+func (g *Grid[T]) Col(x int) ([]T, error) {
+	if x < 0 || x >= g.width {
+		return nil, errors.New(nil,
+			"column out of range [%d] with width %d", x, g.width,
+		)
+	}
+	return g.col(x), nil
+}
+
+// Cols returns a sequence of copies of each column of g, from left to right.
+func (g *Grid[T]) Cols() std.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		for x := 0; x < g.width; x++ {
+			if !yield(g.col(x)) {
+				return
+			}
+		}
+	}
+}
+
+// Fill sets every cell of g to v.
+func (g *Grid[T]) Fill(v T) {
+	for y := 0; y < g.height; y++ {
+		row := g.row(y)
+		for x := range row {
+			row[x] = v
+		}
+	}
+}
+
+// Height returns the number of rows in g.
+func (g *Grid[T]) Height() int {
+	return g.height
+}
+
+// Row returns a copy of the cells in row y, from left to right. Returns error
+// if g has no row y.
+func (g *Grid[T]) Row(y int) ([]T, error) {
+	if y < 0 || y >= g.height {
+		return nil, errors.New(nil,
+			"row out of range [%d] with height %d", y, g.height,
+		)
+	}
+	return append([]T(nil), g.row(y)...), nil
+}
+
+// Rows returns a sequence of copies of each row of g, from top to bottom.
+func (g *Grid[T]) Rows() std.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		for y := 0; y < g.height; y++ {
+			if !yield(append([]T(nil), g.row(y)...)) {
+				return
+			}
+		}
+	}
+}
+
+// Set sets the cell at column x and row y to v. Returns error if (x, y) lies
+// outside of g.
+func (g *Grid[T]) Set(x, y int, v T) error {
+	if err := g.check(x, y); err != nil {
+		return err
+	}
+	g.cells[g.index(x, y)] = v
+	return nil
+}
+
+// Sub returns a view onto the region of g with top-left cell (x, y) and the
+// given width and height. The view shares its cells with g, so that changes to
+// one are visible in the other. Returns error if the region does not lie
+// entirely within g.
+func (g *Grid[T]) Sub(x, y, width, height int) (*Grid[T], error) {
+	if x < 0 || y < 0 || width < 0 || height < 0 ||
+		x+width > g.width || y+height > g.height {
+		return nil, errors.New(nil,
+			"region %dx%d at (%d, %d) out of range with size %dx%d",
+			width, height, x, y, g.width, g.height,
+		)
+	}
+	return &Grid[T]{
+		cells:  g.cells,
+		offset: g.index(x, y),
+		stride: g.stride,
+		width:  width,
+		height: height,
+	}, nil
+}
+
+// Width returns the number of columns in g.
+func (g *Grid[T]) Width() int {
+	return g.width
+}
+
+func (g *Grid[T]) check(x, y int) error {
+	if x < 0 || x >= g.width || y < 0 || y >= g.height {
+		return errors.New(nil,
+			"cell (%d, %d) out of range with size %dx%d",
+			x, y, g.width, g.height,
+		)
+	}
+	return nil
+}
+
+func (g *Grid[T]) col(x int) []T {
+	col := make([]T, g.height)
+	for y := range col {
+		col[y] = g.cells[g.index(x, y)]
+	}
+	return col
+}
+
+func (g *Grid[T]) index(x, y int) int {
+	return g.offset + y*g.stride + x
+}
+
+func (g *Grid[T]) row(y int) []T {
+	start := g.index(0, y)
+	return g.cells[start : start+g.width]
+}
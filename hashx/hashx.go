@@ -0,0 +1,192 @@
+// Package hashx implements hashing schemes for distributing keys across nodes.
+//
+// A Ring is a consistent-hash ring, which assigns keys to nodes such that
+// adding or removing a node moves only the keys assigned to that node:
+//
+//	r := hashx.NewRing(nil)
+//	r.AddNode("cache-a:11211")
+//	r.AddNode("cache-b:11211")
+//	r.AddNode("cache-c:11211")
+//	node, err := r.Lookup("user:42")
+//
+// LookupN selects several distinct nodes for a key, such as to place its
+// replicas:
+//
+//	nodes, err := r.LookupN("user:42", 2)
+package hashx
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// RingOptions configures a Ring. A nil *RingOptions is equivalent to a zero
+// RingOptions, in which every field takes its default.
+type RingOptions struct {
+	// Replicas is the number of points on the ring for each node, known as
+	// virtual nodes. More points spread keys more evenly at the cost of
+	// memory. It defaults to 128.
+	Replicas int
+
+	// Hash hashes keys and the names of virtual nodes. It defaults to a
+	// 64-bit FNV-1a hash with extra mixing of the result. Rings which must
+	// agree across processes must use the same hash and replica count.
+	Hash func(data []byte) uint64
+}
+
+// Ring is a consistent-hash ring of nodes, identified by name. A Ring must be
+// created with NewRing, and is safe for concurrent use.
+type Ring struct {
+	replicas int
+	hash     func([]byte) uint64
+	mu       sync.RWMutex
+	points   []point
+	nodes    map[string]bool
+}
+
+type point struct {
+	hash uint64
+	node string
+}
+
+// NewRing returns an empty ring.
+func NewRing(opts *RingOptions) *Ring {
+	r := &Ring{
+		replicas: 128,
+		hash:     defaultHash,
+		nodes:    make(map[string]bool),
+	}
+	if opts != nil {
+		if opts.Replicas > 0 {
+			r.replicas = opts.Replicas
+		}
+		if opts.Hash != nil {
+			r.hash = opts.Hash
+		}
+	}
+	return r
+}
+
+// AddNode adds a node to r. Returns error if r already holds the node.
+func (r *Ring) AddNode(node string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.nodes[node] {
+		return errors.New(nil, "cannot add node %s: already in ring", node)
+	}
+	r.nodes[node] = true
+	for i := 0; i < r.replicas; i++ {
+		h := r.hash([]byte(node + "#" + strconv.Itoa(i)))
+		r.points = append(r.points, point{h, node})
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		a, b := r.points[i], r.points[j]
+		// Break ties by node so that the order does not depend on the order
+		// in which nodes were added.
+		return a.hash < b.hash || a.hash == b.hash && a.node < b.node
+	})
+	return nil
+}
+
+// Lookup returns the node to which key is assigned. Returns error if r holds
+// no nodes.
+func (r *Ring) Lookup(key string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return "", errors.New(nil, "cannot look up %q: ring is empty", key)
+	}
+	return r.points[r.search(key)].node, nil
+}
+
+// LookupN returns up to n distinct nodes for key, in order of preference. The
+// first node is that returned by Lookup, and the rest are those which would
+// take over the key, in turn, were the preceding nodes removed. Fewer than n
+// nodes are returned if r holds fewer than n, and none if n is not positive.
+// Returns error if r holds no nodes.
+func (r *Ring) LookupN(key string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return nil, errors.New(nil, "cannot look up %q: ring is empty", key)
+	}
+	if n > len(r.nodes) {
+		n = len(r.nodes)
+	}
+	nodes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	for i := r.search(key); len(nodes) < n; i = (i + 1) % len(r.points) {
+		node := r.points[i].node
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// Nodes returns the nodes held by r, in lexical order.
+func (r *Ring) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	nodes := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// RemoveNode removes a node from r, reassigning its keys to the remaining
+// nodes. Returns error if r does not hold the node.
+func (r *Ring) RemoveNode(node string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.nodes[node] {
+		return errors.New(nil, "cannot remove node %s: not in ring", node)
+	}
+	delete(r.nodes, node)
+	points := r.points[:0]
+	for _, p := range r.points {
+		if p.node != node {
+			points = append(points, p)
+		}
+	}
+	r.points = points
+	return nil
+}
+
+// search returns the index of the first point at or after the hash of key,
+// wrapping around the ring. The caller must hold r.mu.
+func (r *Ring) search(key string) int {
+	h := r.hash([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash >= h
+	})
+	if i == len(r.points) {
+		return 0
+	}
+	return i
+}
+
+// defaultHash returns the FNV-1a hash of data, mixed with the finalizer of
+// SplitMix64, as FNV alone spreads similar keys such as "node#1" and "node#2"
+// poorly.
+func defaultHash(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
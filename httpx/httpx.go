@@ -0,0 +1,266 @@
+// Package httpx implements a resilient HTTP client on top of package net/http.
+//
+// A Client bounds the duration of each request, retries idempotent requests
+// which fail transiently, and limits the size of response bodies:
+//
+//	c := &httpx.Client{Timeout: 5 * time.Second, Attempts: 4}
+//	var user User
+//	if err := c.GetJSON(ctx, "https://api.example.com/users/1", &user); err != nil {
+//		return err // GET https://api.example.com/users/1: 404 Not Found
+//	}
+//
+// Every failure is an errors.Error naming the method and URL of the request.
+// A response with an unsuccessful status gives a *StatusError, whose status
+// code is reported by Status:
+//
+//	if httpx.Status(err) == http.StatusNotFound {
+//		...
+//	}
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/compress"
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/retry"
+)
+
+// Client sends HTTP requests. The zero value of a Client is ready to use, and
+// uses the defaults given below.
+type Client struct {
+	// HTTP sends each attempt at a request. If nil, http.DefaultClient is
+	// used.
+	HTTP *http.Client
+
+	// Timeout limits each attempt at a request, including the reading of
+	// its response body. It defaults to 30s; a negative timeout sets no
+	// limit.
+	Timeout time.Duration
+
+	// Attempts is the maximum number of attempts made at an idempotent
+	// request, including the first. It defaults to 3.
+	Attempts int
+
+	// MaxBody is the size in bytes beyond which a response body is rejected.
+	// It defaults to 10 MiB; a negative size sets no limit.
+	MaxBody int64
+}
+
+// Default is the client used by the package-level functions.
+var Default = &Client{}
+
+// StatusError describes a response with an unsuccessful status.
+type StatusError struct {
+	// Code is the status code, such as 404.
+	Code int
+	// Status is the status line, such as "404 Not Found".
+	Status string
+	// Header holds the header of the response.
+	Header http.Header
+	// Body holds the body of the response.
+	Body []byte
+}
+
+// Error returns the status of e, followed by the first line of its body, if
+// any, such as "400 Bad Request: missing field name".
+func (e *StatusError) Error() string {
+	line, _, _ := strings.Cut(strings.TrimSpace(string(e.Body)), "\n")
+	if len(line) > 200 {
+		line = line[:200] + "..."
+	}
+	if line == "" {
+		return e.Status
+	}
+	return e.Status + ": " + line
+}
+
+// Do sends req, as by the Do method of Default.
+func Do(req *http.Request) (*http.Response, error) {
+	return Default.Do(req)
+}
+
+// Do sends req and reads its response. The body of the response is read in
+// full before Do returns, so that its size may be limited, and need not be
+// closed. A body compressed with a Content-Encoding which package compress
+// implements, such as gzip, deflate or zstd, is decompressed, so that a request
+// may ask for any such encoding with its own Accept-Encoding header; the
+// limit on its size applies to the decompressed body.
+//
+// An idempotent request, whose method is GET, HEAD, OPTIONS, TRACE, PUT or
+// DELETE, or which has an Idempotency-Key header, is attempted again if it
+// fails, or if its response has status 429 Too Many Requests, 500, 502, 503
+// or 504, waiting with exponential backoff as by package retry. A request with
+// a body is attempted only once unless its GetBody field is set, as it is by
+// http.NewRequest for bodies held in memory.
+//
+// Returns error if every attempt fails, if ctx is done, if the response body
+// exceeds the limit, or if the response status is not 2xx or 3xx, in which
+// case the parent error is a *StatusError.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if idempotent(req) && (req.GetBody != nil || req.Body == nil || req.Body == http.NoBody) {
+		attempts = c.Attempts
+		if attempts <= 0 {
+			attempts = 3
+		}
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	var resp *http.Response
+	var last error
+	err := retry.Do(req.Context(), func(ctx context.Context) error {
+		resp, last = c.try(ctx, req)
+		return last
+	},
+		retry.Attempts(attempts),
+		retry.If(retryable),
+		retry.Timeout(timeout),
+	)
+	if err != nil {
+		if last == nil {
+			last = req.Context().Err()
+		}
+		return nil, errors.New(last, "%s %s", req.Method, req.URL.Redacted())
+	}
+	return resp, nil
+}
+
+// errTooLarge reports a response body exceeding the limit.
+type errTooLarge int64
+
+func (e errTooLarge) Error() string {
+	return fmt.Sprintf("response body exceeds %d bytes", int64(e))
+}
+
+// try makes a single attempt at req, with the context ctx.
+func (c *Client) try(ctx context.Context, req *http.Request) (*http.Response, error) {
+	r := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	rc, err := decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	max := c.MaxBody
+	if max == 0 {
+		max = 10 << 20
+	}
+	var body []byte
+	if max < 0 {
+		body, err = io.ReadAll(rc)
+	} else {
+		body, err = io.ReadAll(io.LimitReader(rc, max+1))
+		if err == nil && int64(len(body)) > max {
+			return nil, errTooLarge(max)
+		}
+	}
+	if err != nil {
+		return nil, errors.New(err, "cannot read response body")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 399 {
+		return nil, &StatusError{
+			Code:   resp.StatusCode,
+			Status: resp.Status,
+			Header: resp.Header,
+			Body:   body,
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// decode returns a reader of the body of resp, decompressing it if its
+// Content-Encoding is that of a codec of package compress, in which case the
+// header of resp is updated to describe the decompressed body.
+func decode(resp *http.Response) (io.ReadCloser, error) {
+	enc := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	switch enc {
+	case "", "identity":
+		return io.NopCloser(resp.Body), nil
+	case "x-gzip":
+		enc = "gzip"
+	case "deflate":
+		enc = "zlib"
+	}
+	codec, err := compress.Lookup(enc)
+	if err != nil {
+		return io.NopCloser(resp.Body), nil
+	}
+	br := bufio.NewReader(resp.Body)
+	if _, err := br.Peek(1); err == io.EOF {
+		return io.NopCloser(br), nil
+	}
+	r, err := codec.NewReader(br)
+	if err != nil {
+		return nil, errors.New(err, "cannot decompress response body")
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return r, nil
+}
+
+// Status returns the status code of the *StatusError which err, or one of its
+// parent errors, is, or 0 if there is none.
+func Status(err error) int {
+	if e, ok := errors.As[*StatusError](err); ok {
+		return e.Code
+	}
+	return 0
+}
+
+// idempotent reports whether req may be sent more than once with the same
+// effect as sending it once.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	return ok
+}
+
+// retryable reports whether an attempt which failed with err should be made
+// again.
+func retryable(err error) bool {
+	switch e := err.(type) {
+	case *StatusError:
+		switch e.Code {
+		case http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	case errTooLarge:
+		return false
+	}
+	return true
+}
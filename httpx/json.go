@@ -0,0 +1,81 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// GetJSON sends a GET request to url, as by the GetJSON method of Default.
+func GetJSON(ctx context.Context, url string, out any) error {
+	return Default.GetJSON(ctx, url, out)
+}
+
+// JSON sends a request, as by the JSON method of Default.
+func JSON(ctx context.Context, method, url string, in, out any) error {
+	return Default.JSON(ctx, method, url, in, out)
+}
+
+// PostJSON sends a POST request to url, as by the PostJSON method of Default.
+func PostJSON(ctx context.Context, url string, in, out any) error {
+	return Default.PostJSON(ctx, url, in, out)
+}
+
+// GetJSON sends a GET request to url, and decodes the JSON response into out,
+// as described for JSON.
+func (c *Client) GetJSON(ctx context.Context, url string, out any) error {
+	return c.JSON(ctx, http.MethodGet, url, nil, out)
+}
+
+// JSON sends a request with the given method to url, as by Do, with a body
+// holding in encoded as JSON, unless in is nil. If out is not nil, the
+// response body is decoded as JSON into the value to which out points; an
+// empty body leaves it unchanged.
+//
+// Returns error if in cannot be encoded, if the request fails as described for
+// Do, or if the response body cannot be decoded. The error names the method
+// and URL of the request.
+func (c *Client) JSON(ctx context.Context, method, url string, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return errors.New(errors.New(err, "cannot encode request"), "%s %s", method, url)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.New(err, "%s %s", method, url)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if out != nil {
+		req.Header.Set("Accept", "application/json")
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	dec := json.NewDecoder(resp.Body)
+	if err := dec.Decode(out); err != nil && err != io.EOF {
+		return errors.New(errors.New(err, "cannot decode response"), "%s %s", method, req.URL.Redacted())
+	}
+	return nil
+}
+
+// PostJSON sends a POST request to url, with in as its body, and decodes the
+// JSON response into out, as described for JSON. As POST is not idempotent,
+// the request is attempted only once.
+func (c *Client) PostJSON(ctx context.Context, url string, in, out any) error {
+	return c.JSON(ctx, http.MethodPost, url, in, out)
+}
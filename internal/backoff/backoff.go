@@ -0,0 +1,74 @@
+// Package backoff implements the loop which retries a failing operation with
+// exponential backoff, as shared by package retry and errors.Retry. It must
+// not import package errors, which depends on it.
+package backoff
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Config configures Do.
+type Config struct {
+	// Attempts is the maximum number of attempts made, including the
+	// first. If it is less than one, Do attempts until it succeeds or its
+	// context is done.
+	Attempts int
+
+	// Initial is the delay after the first failed attempt, and Max the
+	// longest delay between attempts.
+	Initial time.Duration
+	Max     time.Duration
+
+	// Multiplier is the factor by which the delay grows after each failed
+	// attempt.
+	Multiplier float64
+
+	// Jitter randomises each delay by up to the given fraction of it, in
+	// either direction.
+	Jitter float64
+
+	// RetryIf, if not nil, decides whether the error of an attempt should
+	// be retried.
+	RetryIf func(err error) bool
+}
+
+// Do calls f until it returns nil, c.Attempts attempts are made, the error
+// returned is not to be retried, or ctx is done. Returns nil if an attempt
+// succeeded, and otherwise the error of each attempt, in order, followed by
+// the error of ctx if it stopped Do, along with the number of attempts made.
+func Do(ctx context.Context, c Config, f func() error) (errs []error, attempts int) {
+	delay := c.Initial
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil {
+			return nil, attempt
+		}
+		errs = append(errs, err)
+		if c.Attempts > 0 && attempt >= c.Attempts || c.RetryIf != nil && !c.RetryIf(err) {
+			return errs, attempt
+		}
+		if err := ctx.Err(); err != nil {
+			return append(errs, err), attempt
+		}
+		t := time.NewTimer(jitter(delay, c.Jitter))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return append(errs, ctx.Err()), attempt
+		case <-t.C:
+		}
+		delay = time.Duration(float64(delay) * c.Multiplier)
+		if delay > c.Max {
+			delay = c.Max
+		}
+	}
+}
+
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + fraction*(2*rand.Float64()-1)))
+}
@@ -0,0 +1,173 @@
+// Package convert implements the conversion of text into Go values, and of Go
+// values into text, using reflection, as needed by packages which populate
+// structs from textual sources such as the environment, configuration files
+// and CSV records.
+package convert
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Format returns v in the textual form parsed by Set, joining the elements of
+// slices with sep. A nil pointer gives the empty string.
+func Format(v reflect.Value, sep string) (string, error) {
+	t := v.Type()
+	if t.Implements(textMarshalerType) && (t.Kind() != reflect.Pointer || !v.IsNil()) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", errors.New(err, "cannot format %s", t)
+		}
+		return string(text), nil
+	}
+	if v.CanAddr() && reflect.PointerTo(t).Implements(textMarshalerType) {
+		return Format(v.Addr(), sep)
+	}
+	switch t {
+	case durationType:
+		return time.Duration(v.Int()).String(), nil
+	case timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return "", nil
+		}
+		return Format(v.Elem(), sep)
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			s, err := Format(v.Index(i), sep)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, sep), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, t.Bits()), nil
+	}
+	return "", errors.New(nil, "unsupported type %s", t)
+}
+
+// Supported reports whether values of type t can be set by Set.
+func Supported(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return Supported(t.Elem())
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && Supported(t.Elem())
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// Set parses s and stores the result in v, which must be settable. Values of
+// types implementing encoding.TextUnmarshaler are parsed using UnmarshalText,
+// durations using time.ParseDuration, times as RFC 3339, and other scalar types
+// using package strconv. Pointers are allocated as needed. Slices are parsed by
+// splitting s on sep, with surrounding whitespace trimmed from each element; an
+// empty s gives an empty slice.
+func Set(v reflect.Value, s string, sep string) error {
+	t := v.Type()
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		u := v.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			return errors.New(nil, "expected %s", t)
+		}
+		return nil
+	}
+	switch t {
+	case durationType:
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return errors.New(nil, "expected duration")
+		}
+		v.SetInt(int64(d))
+		return nil
+	case timeType:
+		tm, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+		if err != nil {
+			return errors.New(nil, "expected RFC 3339 time")
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(t.Elem())
+		if err := Set(elem.Elem(), s, sep); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice:
+		var parts []string
+		if strings.TrimSpace(s) != "" {
+			parts = strings.Split(s, sep)
+		}
+		slice := reflect.MakeSlice(t, len(parts), len(parts))
+		for i, part := range parts {
+			if err := Set(slice.Index(i), strings.TrimSpace(part), sep); err != nil {
+				return errors.New(err, "element %d", i)
+			}
+		}
+		v.Set(slice)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return errors.New(nil, "expected boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 0, t.Bits())
+		if err != nil {
+			return errors.New(nil, "expected %d-bit integer", t.Bits())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 0, t.Bits())
+		if err != nil {
+			return errors.New(nil, "expected %d-bit unsigned integer", t.Bits())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), t.Bits())
+		if err != nil {
+			return errors.New(nil, "expected number")
+		}
+		v.SetFloat(f)
+	default:
+		return errors.New(nil, "unsupported type %s", t)
+	}
+	return nil
+}
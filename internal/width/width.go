@@ -0,0 +1,204 @@
+// Package width measures the display width of text in a terminal.
+//
+// Most characters occupy a single terminal cell, but East Asian wide and
+// fullwidth characters, and most emoji, occupy two, while combining marks and
+// other zero-width characters occupy none. ANSI escape sequences are not
+// displayed, and so also occupy no cells.
+package width
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wide holds the ranges of characters which occupy two cells.
+var wide = []struct{ lo, hi rune }{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x2329, 0x232a},
+	{0x23e9, 0x23ec},
+	{0x23f0, 0x23f0},
+	{0x23f3, 0x23f3},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267f, 0x267f},
+	{0x2693, 0x2693},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f3},
+	{0x26f5, 0x26f5},
+	{0x26fa, 0x26fa},
+	{0x26fd, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x274e, 0x274e},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x16fe0, 0x16fe4},
+	{0x17000, 0x18cff},
+	{0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x3fffd},
+}
+
+// Cut splits s into a head occupying at most w cells and the remaining tail.
+// ANSI escape sequences are kept intact, and those immediately following the
+// head are included in it.
+func Cut(s string, w int) (head, tail string) {
+	n := 0
+	i := 0
+	for i < len(s) {
+		if s[i] == '\x1b' {
+			i += EscapeLen(s[i:])
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if n+Rune(r) > w {
+			break
+		}
+		n += Rune(r)
+		i += size
+	}
+	return s[:i], s[i:]
+}
+
+// EscapeLen returns the length in bytes of the ANSI escape sequence at the
+// start of s, which must begin with an escape character. Control sequences
+// (CSI) and operating system commands (OSC) are recognized in full; any other
+// escape is assumed to be two bytes long.
+func EscapeLen(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	}
+	return 2
+}
+
+// Rune returns the number of cells occupied by r.
+func Rune(r rune) int {
+	switch {
+	case r == 0 || r == '\u200b' || r == '\u200d':
+		return 0
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	}
+	lo, hi := 0, len(wide)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wide[mid].lo:
+			hi = mid - 1
+		case r > wide[mid].hi:
+			lo = mid + 1
+		default:
+			return 2
+		}
+	}
+	return 1
+}
+
+// String returns the number of cells occupied by s, ignoring any ANSI escape
+// sequences.
+func String(s string) int {
+	n := 0
+	for _, r := range Strip(s) {
+		n += Rune(r)
+	}
+	return n
+}
+
+// Truncate returns the longest prefix of s occupying at most w cells. If s must
+// be shortened, tail is appended, and the prefix is shortened further so that
+// the result, including tail, occupies at most w cells. ANSI escape sequences
+// are not preserved in a truncated result.
+func Truncate(s string, w int, tail string) string {
+	if String(s) <= w {
+		return s
+	}
+	w -= String(tail)
+	var b strings.Builder
+	n := 0
+	for _, r := range Strip(s) {
+		rw := Rune(r)
+		if n+rw > w {
+			break
+		}
+		n += rw
+		b.WriteRune(r)
+	}
+	return b.String() + tail
+}
+
+// Strip returns s with all ANSI escape sequences removed.
+func Strip(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i += EscapeLen(s[i:]) - 1
+	}
+	return b.String()
+}
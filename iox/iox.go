@@ -0,0 +1,162 @@
+// Package iox implements additional I/O primitives.
+//
+// CountingReader and CountingWriter count the bytes passing through them, for
+// reporting the progress and throughput of transfers:
+//
+//	cr := iox.NewCountingReader(resp.Body)
+//	_, err := io.Copy(f, cr)
+//	fmt.Printf("%d bytes at %.0f B/s\n", cr.Count(), cr.Rate())
+//
+// LimitWriter enforces a quota on the bytes written to a writer.
+//
+// Pipe connects a writer and a reader through a buffer, optionally spilling to
+// disk, so that a fast producer is not held in lock-step with its consumer:
+//
+//	r, w := iox.Pipe(ctx, iox.PipeOptions{Buffer: 1 << 20, Spill: true})
+//	go func() {
+//		w.CloseWithError(produce(w))
+//	}()
+//	err := consume(r)
+//
+// Lines and Chunks iterate over the contents of a reader as a std.Seq, with
+// any error reported once iteration ends.
+package iox
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// ErrLimit is returned by writers returned by LimitWriter when a write would
+// exceed their limit.
+var ErrLimit = errors.New(nil, "write limit exceeded")
+
+// counter counts bytes and measures the rate at which they are counted.
+type counter struct {
+	n     atomic.Int64
+	start atomic.Int64
+}
+
+func (c *counter) add(n int) {
+	c.start.CompareAndSwap(0, time.Now().UnixNano())
+	c.n.Add(int64(n))
+}
+
+func (c *counter) rate() float64 {
+	start := c.start.Load()
+	if start == 0 {
+		return 0
+	}
+	secs := time.Since(time.Unix(0, start)).Seconds()
+	if secs <= 0 {
+		return 0
+	}
+	return float64(c.n.Load()) / secs
+}
+
+// CountingReader counts the bytes read from an underlying reader. Its methods
+// are safe to call concurrently with Read.
+type CountingReader struct {
+	r io.Reader
+	c counter
+}
+
+// NewCountingReader returns a reader which reads from r, counting the bytes
+// read.
+func NewCountingReader(r io.Reader) *CountingReader {
+	return &CountingReader{r: r}
+}
+
+// Count returns the number of bytes read.
+func (r *CountingReader) Count() int64 {
+	return r.c.n.Load()
+}
+
+// Rate returns the average number of bytes read per second since the first
+// call to Read.
+func (r *CountingReader) Rate() float64 {
+	return r.c.rate()
+}
+
+func (r *CountingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.c.add(n)
+	return n, err
+}
+
+// CountingWriter counts the bytes written to an underlying writer. Its methods
+// are safe to call concurrently with Write.
+type CountingWriter struct {
+	w io.Writer
+	c counter
+}
+
+// NewCountingWriter returns a writer which writes to w, counting the bytes
+// written.
+func NewCountingWriter(w io.Writer) *CountingWriter {
+	return &CountingWriter{w: w}
+}
+
+// Count returns the number of bytes written.
+func (w *CountingWriter) Count() int64 {
+	return w.c.n.Load()
+}
+
+// Rate returns the average number of bytes written per second since the first
+// call to Write.
+func (w *CountingWriter) Rate() float64 {
+	return w.c.rate()
+}
+
+func (w *CountingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.c.add(n)
+	return n, err
+}
+
+// LimitWriter returns a writer which writes to w until n bytes have been
+// written. A write which would exceed the limit writes as many bytes as the
+// limit allows, and returns ErrLimit. A negative n is taken as zero.
+func LimitWriter(w io.Writer, n int64) io.Writer {
+	if n < 0 {
+		n = 0
+	}
+	return &limitWriter{w, n}
+}
+
+type limitWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= l.n {
+		n, err := l.w.Write(p)
+		l.n -= int64(n)
+		return n, err
+	}
+	n, err := l.w.Write(p[:l.n])
+	l.n -= int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, errors.Raise(ErrLimit)
+}
+
+// TeeReadCloser returns a reader which writes to w everything it reads from r,
+// like io.TeeReader. Closing it closes r, but not w.
+func TeeReadCloser(r io.ReadCloser, w io.Writer) io.ReadCloser {
+	return &teeReadCloser{io.TeeReader(r, w), r}
+}
+
+type teeReadCloser struct {
+	io.Reader
+	c io.Closer
+}
+
+func (t *teeReadCloser) Close() error {
+	return t.c.Close()
+}
@@ -0,0 +1,79 @@
+package iox
+
+import (
+	"bufio"
+	"io"
+
+	"git.sr.ht/~kvo/go-std"
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Chunks returns a sequence of the contents of r in chunks of size bytes, the
+// last of which may be shorter, and a function returning the error which ended
+// the sequence, if any. Each chunk is a new slice which the caller may retain.
+// If size is not positive, the sequence is empty and the function returns an
+// error.
+func Chunks(r io.Reader, size int) (std.Seq[[]byte], func() error) {
+	var err error
+	seq := func(yield func([]byte) bool) {
+		if size <= 0 {
+			err = errors.New(nil, "invalid chunk size %d", size)
+			return
+		}
+		for {
+			buf := make([]byte, size)
+			n, rerr := io.ReadFull(r, buf)
+			if n > 0 && !yield(buf[:n]) {
+				return
+			}
+			if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+				return
+			} else if rerr != nil {
+				err = errors.New(rerr, "cannot read chunk")
+				return
+			}
+		}
+	}
+	return seq, func() error { return err }
+}
+
+// Lines returns a sequence of the lines of r, without their "\n" or "\r\n"
+// terminators, and a function returning the error which ended the sequence,
+// if any. A final line without a terminator is included. Unlike
+// bufio.Scanner, Lines places no limit on the length of a line.
+//
+//	lines, errf := iox.Lines(r)
+//	lines(func(line string) bool {
+//		fmt.Println(line)
+//		return true
+//	})
+//	if err := errf(); err != nil {
+//		return err
+//	}
+func Lines(r io.Reader) (std.Seq[string], func() error) {
+	var err error
+	seq := func(yield func(string) bool) {
+		br := bufio.NewReader(r)
+		for {
+			line, rerr := br.ReadString('\n')
+			if rerr != nil && rerr != io.EOF {
+				err = errors.New(rerr, "cannot read line")
+				return
+			}
+			if rerr == io.EOF && line == "" {
+				return
+			}
+			n := len(line)
+			if n > 0 && line[n-1] == '\n' {
+				n--
+				if n > 0 && line[n-1] == '\r' {
+					n--
+				}
+			}
+			if !yield(line[:n]) || rerr == io.EOF {
+				return
+			}
+		}
+	}
+	return seq, func() error { return err }
+}
@@ -0,0 +1,258 @@
+package iox
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// ErrClosedPipe is returned by reads and writes on a closed end of a pipe.
+var ErrClosedPipe = errors.New(nil, "read/write on closed pipe")
+
+// PipeOptions configures a pipe created by Pipe. The zero value of a
+// PipeOptions describes a pipe buffering up to 64 KiB in memory, which does
+// not spill to disk.
+type PipeOptions struct {
+	// Buffer is the number of bytes buffered in memory. If Buffer is zero or
+	// negative, 64 KiB are buffered.
+	Buffer int
+
+	// Spill causes data written beyond the memory buffer to be stored in a
+	// temporary file, rather than blocking the writer until the reader
+	// catches up.
+	Spill bool
+
+	// SpillDir is the directory in which the temporary file is created. If
+	// SpillDir is empty, os.TempDir is used.
+	SpillDir string
+}
+
+// Pipe creates a pipe whose writer buffers data for its reader, so that each
+// side blocks only when the buffer is full or empty, rather than on every
+// write as with io.Pipe. When ctx is done, the pipe is closed, with reads and
+// writes returning the cause of the context.
+//
+// Data is buffered in memory, and if opts.Spill is set, in a temporary file
+// once the memory buffer is full. The temporary file is removed once both ends
+// of the pipe are closed.
+func Pipe(ctx context.Context, opts PipeOptions) (*PipeReader, *PipeWriter) {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 64 << 10
+	}
+	p := &pipe{opts: opts, done: make(chan struct{})}
+	p.cond = sync.NewCond(&p.mu)
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.abort(errors.New(context.Cause(ctx), "pipe closed"))
+		case <-p.done:
+		}
+	}()
+	return &PipeReader{p}, &PipeWriter{p}
+}
+
+type pipe struct {
+	opts PipeOptions
+	mu   sync.Mutex
+	cond *sync.Cond
+	mem  bytes.Buffer
+	file *os.File
+	roff int64
+	woff int64
+	rerr error // returned to the reader once the buffer is drained
+	werr error // returned to the writer once the reader is closed
+	cerr error // returned to both ends once the context is done
+	rclosed,
+	wclosed bool
+	done chan struct{}
+}
+
+func (p *pipe) read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if p.cerr != nil {
+			return 0, p.cerr
+		}
+		if p.rclosed {
+			return 0, errors.Raise(ErrClosedPipe)
+		}
+		if p.mem.Len() > 0 {
+			n, _ := p.mem.Read(b)
+			p.cond.Broadcast()
+			return n, nil
+		}
+		if p.roff < p.woff {
+			if int64(len(b)) > p.woff-p.roff {
+				b = b[:p.woff-p.roff]
+			}
+			n, err := p.file.ReadAt(b, p.roff)
+			p.roff += int64(n)
+			if p.roff == p.woff {
+				p.roff, p.woff = 0, 0
+			}
+			if err != nil && err != io.EOF {
+				return n, errors.New(err, "cannot read spilled data")
+			}
+			return n, nil
+		}
+		if p.wclosed {
+			return 0, p.rerr
+		}
+		p.cond.Wait()
+	}
+}
+
+func (p *pipe) write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	written := 0
+	for len(b) > 0 {
+		if p.cerr != nil {
+			return written, p.cerr
+		}
+		if p.rclosed && p.werr != nil && !p.wclosed {
+			return written, p.werr
+		}
+		if p.wclosed || p.rclosed {
+			return written, errors.Raise(ErrClosedPipe)
+		}
+		if p.woff == 0 {
+			if space := p.opts.Buffer - p.mem.Len(); space > 0 {
+				n := len(b)
+				if n > space {
+					n = space
+				}
+				p.mem.Write(b[:n])
+				b = b[n:]
+				written += n
+				p.cond.Broadcast()
+				continue
+			}
+		}
+		if p.opts.Spill {
+			if p.file == nil {
+				f, err := os.CreateTemp(p.opts.SpillDir, "pipe-")
+				if err != nil {
+					return written, errors.New(err, "cannot spill pipe to disk")
+				}
+				p.file = f
+			}
+			n, err := p.file.WriteAt(b, p.woff)
+			p.woff += int64(n)
+			written += n
+			p.cond.Broadcast()
+			if err != nil {
+				return written, errors.New(err, "cannot spill pipe to disk")
+			}
+			return written, nil
+		}
+		p.cond.Wait()
+	}
+	return written, nil
+}
+
+func (p *pipe) abort(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rclosed && p.wclosed {
+		return
+	}
+	p.cerr = err
+	p.rclosed, p.wclosed = true, true
+	p.finish()
+}
+
+func (p *pipe) closeRead(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rclosed {
+		return
+	}
+	p.rclosed = true
+	p.werr = err
+	p.finish()
+}
+
+func (p *pipe) closeWrite(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.wclosed {
+		return
+	}
+	p.wclosed = true
+	if err == nil {
+		err = io.EOF
+	}
+	p.rerr = err
+	p.finish()
+}
+
+// finish wakes waiters and releases resources once both ends are closed. The
+// caller must hold p.mu.
+func (p *pipe) finish() {
+	p.cond.Broadcast()
+	if !p.rclosed || !p.wclosed {
+		return
+	}
+	close(p.done)
+	if p.file != nil {
+		p.file.Close()
+		os.Remove(p.file.Name())
+		p.file = nil
+	}
+}
+
+// PipeReader is the read end of a pipe created by Pipe.
+type PipeReader struct {
+	p *pipe
+}
+
+// Close closes the reader. Subsequent writes to the pipe return ErrClosedPipe.
+func (r *PipeReader) Close() error {
+	r.p.closeRead(nil)
+	return nil
+}
+
+// CloseWithError closes the reader. Subsequent writes to the pipe return err,
+// or ErrClosedPipe if err is nil.
+func (r *PipeReader) CloseWithError(err error) error {
+	r.p.closeRead(err)
+	return nil
+}
+
+// Read reads buffered data from the pipe, blocking until data is available or
+// the writer is closed. Once the writer is closed and the buffer drained, Read
+// returns the error given to CloseWithError, or io.EOF.
+func (r *PipeReader) Read(b []byte) (int, error) {
+	return r.p.read(b)
+}
+
+// PipeWriter is the write end of a pipe created by Pipe.
+type PipeWriter struct {
+	p *pipe
+}
+
+// Close closes the writer. Once the buffer is drained, reads from the pipe
+// return io.EOF.
+func (w *PipeWriter) Close() error {
+	w.p.closeWrite(nil)
+	return nil
+}
+
+// CloseWithError closes the writer. Once the buffer is drained, reads from the
+// pipe return err, or io.EOF if err is nil.
+func (w *PipeWriter) CloseWithError(err error) error {
+	w.p.closeWrite(err)
+	return nil
+}
+
+// Write writes b to the pipe, blocking while the memory buffer is full, unless
+// the pipe spills to disk. Returns error if either end of the pipe is closed.
+func (w *PipeWriter) Write(b []byte) (int, error) {
+	return w.p.write(b)
+}
@@ -0,0 +1,105 @@
+// Package lazy implements lazy initialization and functions which run once.
+//
+// A Lazy holds a value computed on first use:
+//
+//	var db = lazy.New(func() (*sql.DB, error) {
+//		return sql.Open("postgres", os.Getenv("DATABASE_URL"))
+//	})
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//		conn, err := db.Get()
+//		...
+//	}
+//
+// If initialization fails, the error is returned to the caller and the next
+// call to Get tries again. In contrast, the functions returned by OnceFunc and
+// OnceValue run exactly once, and return the same result, including any error,
+// on every call.
+package lazy
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Lazy holds a value of type T which is initialized on first use. A Lazy must
+// be created with New, and is safe for concurrent use.
+type Lazy[T any] struct {
+	init  func() (T, error)
+	mu    sync.Mutex
+	value atomic.Pointer[T] // nil until initialized
+}
+
+// New returns a Lazy whose value is initialized by init.
+func New[T any](init func() (T, error)) *Lazy[T] {
+	return &Lazy[T]{init: init}
+}
+
+// Get returns the value of l, calling its initialization function if l is not
+// yet initialized. Concurrent callers wait for a single call in progress.
+// Returns error if initialization fails, in which case l remains
+// uninitialized.
+func (l *Lazy[T]) Get() (T, error) {
+	if p := l.value.Load(); p != nil {
+		return *p, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p := l.value.Load(); p != nil {
+		return *p, nil
+	}
+	v, err := l.init()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	l.value.Store(&v)
+	return v, nil
+}
+
+// Initialized reports whether l holds an initialized value.
+func (l *Lazy[T]) Initialized() bool {
+	return l.value.Load() != nil
+}
+
+// Reset discards the value of l, so that the next call to Get initializes it
+// again. It is mostly useful in tests.
+func (l *Lazy[T]) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.value.Store(nil)
+}
+
+// OnceFunc returns a function which calls f the first time it is called, and
+// returns the error returned by f on every call. If f panics, the returned
+// function panics with the same value, and later calls return an error.
+func OnceFunc(f func() error) func() error {
+	g := OnceValue(func() (struct{}, error) {
+		return struct{}{}, f()
+	})
+	return func() error {
+		_, err := g()
+		return err
+	}
+}
+
+// OnceValue returns a function which calls f the first time it is called, and
+// returns the value and error returned by f on every call. If f panics, the
+// returned function panics with the same value, and later calls return an
+// error.
+func OnceValue[T any](f func() (T, error)) func() (T, error) {
+	var (
+		once  sync.Once
+		value T
+		err   error
+	)
+	return func() (T, error) {
+		once.Do(func() {
+			err = errors.New(nil, "once function panicked")
+			value, err = f()
+		})
+		return value, err
+	}
+}
@@ -0,0 +1,270 @@
+// Package log implements leveled, structured logging.
+//
+// A Logger writes records, each made up of a time, a level, a message, and a
+// list of key-value fields, to a Sink. The TextSink and JSONSink functions
+// return sinks which format records as logfmt-style text and as JSON objects:
+//
+//	logger := log.New(log.TextSink(os.Stderr), log.LevelInfo)
+//	logger.Info("listening", "addr", addr, "tls", true)
+//	db := logger.With("component", "db")
+//	db.Warn("slow query", "took", d)
+//
+// Fields are given as alternating keys and values. A key which is not a string
+// is replaced by "!BADKEY", as is the key of a value without one.
+//
+// Errors created by package errors are logged with their origin. A field whose
+// value is an errors.Error with key k is expanded into the fields k (the error
+// message), k.func, and k.source (file:line of the origin of the error), and
+// for each annotation added by errors.Annotate with key a, the field k.a. If
+// tracing is enabled with SetTrace, the field k.trace additionally holds every
+// Frame in the error's chain, most recent first:
+//
+//	if err != nil {
+//		logger.Error("cannot load configuration", "err", err)
+//	}
+//
+// The functions Debug, Info, Warn, and Error log to the Default logger, which
+// writes text to the standard error stream at LevelInfo.
+package log
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Level represents the severity of a record.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// ParseLevel returns the level named by s, ignoring case. Returns error if s
+// names no level.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToUpper(s) {
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN", "WARNING":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	}
+	return 0, errors.New(nil, "unknown level %q", s)
+}
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "Level(" + strconv.Itoa(int(l)) + ")"
+}
+
+// Field represents a key-value pair attached to a record.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Frame represents a single error in the chain of an errors.Error, as included
+// in a record when tracing is enabled.
+type Frame struct {
+	Func string `json:"func,omitempty"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+// Record represents a single log entry.
+type Record struct {
+	Time   time.Time
+	Level  Level
+	Msg    string
+	Fields []Field
+}
+
+// Sink represents a destination for records. A Sink must be safe for
+// concurrent use.
+type Sink interface {
+	Write(r Record) error
+}
+
+// Logger writes records at or above a minimum level to a Sink. A Logger is
+// safe for concurrent use.
+type Logger struct {
+	sink   Sink
+	level  *atomic.Int64
+	trace  *atomic.Bool
+	fields []Field
+}
+
+// Default is the logger used by the package-level logging functions.
+var Default = New(TextSink(os.Stderr), LevelInfo)
+
+// New returns a logger which writes records at or above level to sink.
+func New(sink Sink, level Level) *Logger {
+	l := &Logger{
+		sink:  sink,
+		level: new(atomic.Int64),
+		trace: new(atomic.Bool),
+	}
+	l.level.Store(int64(level))
+	return l
+}
+
+// Debug logs a record at LevelDebug.
+func (l *Logger) Debug(msg string, kv ...any) {
+	l.log(LevelDebug, msg, kv)
+}
+
+// Enabled reports whether l logs records at level.
+func (l *Logger) Enabled(level Level) bool {
+	return level >= Level(l.level.Load())
+}
+
+// Error logs a record at LevelError.
+func (l *Logger) Error(msg string, kv ...any) {
+	l.log(LevelError, msg, kv)
+}
+
+// Info logs a record at LevelInfo.
+func (l *Logger) Info(msg string, kv ...any) {
+	l.log(LevelInfo, msg, kv)
+}
+
+// Log logs a record at level.
+func (l *Logger) Log(level Level, msg string, kv ...any) {
+	l.log(level, msg, kv)
+}
+
+// SetLevel sets the minimum level of records logged by l. The level is shared
+// with every logger derived from l by With.
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(int64(level))
+}
+
+// SetTrace sets whether errors are logged with every frame of their chain. The
+// setting is shared with every logger derived from l by With.
+func (l *Logger) SetTrace(trace bool) {
+	l.trace.Store(trace)
+}
+
+// Warn logs a record at LevelWarn.
+func (l *Logger) Warn(msg string, kv ...any) {
+	l.log(LevelWarn, msg, kv)
+}
+
+// With returns a logger which adds the fields given by kv to every record.
+func (l *Logger) With(kv ...any) *Logger {
+	child := *l
+	child.fields = append(append([]Field(nil), l.fields...), l.expand(kv)...)
+	return &child
+}
+
+func (l *Logger) expand(kv []any) []Field {
+	var fields []Field
+	for i := 0; i < len(kv); i++ {
+		if i+1 == len(kv) {
+			fields = l.appendField(fields, "!BADKEY", kv[i])
+			break
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			fields = l.appendField(fields, "!BADKEY", kv[i])
+			continue
+		}
+		fields = l.appendField(fields, key, kv[i+1])
+		i++
+	}
+	return fields
+}
+
+func (l *Logger) appendField(fields []Field, key string, v any) []Field {
+	e, ok := v.(errors.Error)
+	if !ok {
+		return append(fields, Field{key, v})
+	}
+	fields = append(fields,
+		Field{key, e.Error()},
+		Field{key + ".func", e.Func()},
+		Field{key + ".source", e.File() + ":" + strconv.Itoa(e.Line())},
+	)
+	annotations := e.Fields()
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, Field{key + "." + k, annotations[k]})
+	}
+	if l.trace.Load() {
+		fields = append(fields, Field{key + ".trace", frames(e)})
+	}
+	return fields
+}
+
+func (l *Logger) log(level Level, msg string, kv []any) {
+	if !l.Enabled(level) {
+		return
+	}
+	r := Record{
+		Time:   time.Now(),
+		Level:  level,
+		Msg:    msg,
+		Fields: append(append([]Field(nil), l.fields...), l.expand(kv)...),
+	}
+	l.sink.Write(r)
+}
+
+// Debug logs a record at LevelDebug to the Default logger.
+func Debug(msg string, kv ...any) {
+	Default.log(LevelDebug, msg, kv)
+}
+
+// Error logs a record at LevelError to the Default logger.
+func Error(msg string, kv ...any) {
+	Default.log(LevelError, msg, kv)
+}
+
+// Info logs a record at LevelInfo to the Default logger.
+func Info(msg string, kv ...any) {
+	Default.log(LevelInfo, msg, kv)
+}
+
+// Warn logs a record at LevelWarn to the Default logger.
+func Warn(msg string, kv ...any) {
+	Default.log(LevelWarn, msg, kv)
+}
+
+func frames(err error) []Frame {
+	var fs []Frame
+	for err != nil {
+		e, ok := err.(errors.Error)
+		if !ok {
+			fs = append(fs, Frame{Text: err.Error()})
+			break
+		}
+		fs = append(fs, Frame{e.Func(), e.File(), e.Line(), e.Text()})
+		err = e.Parent()
+	}
+	return fs
+}
@@ -0,0 +1,149 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// timeFormat is the format of record times written by the text and JSON sinks.
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+type textSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// TextSink returns a sink which writes each record to w as a line of logfmt
+// key-value pairs, beginning with the time, level, and message:
+//
+//	time=2024-01-02T15:04:05.000Z level=INFO msg=listening addr=:8080
+//
+// Values are quoted when they contain spaces, quotes, equals signs, or control
+// characters. Error trace frames are written on indented lines following the
+// record.
+func TextSink(w io.Writer) Sink {
+	return &textSink{w: w}
+}
+
+func (s *textSink) Write(r Record) error {
+	var b bytes.Buffer
+	b.WriteString("time=")
+	b.WriteString(r.Time.Format(timeFormat))
+	b.WriteString(" level=")
+	b.WriteString(r.Level.String())
+	b.WriteString(" msg=")
+	b.WriteString(quote(r.Msg))
+	var trace []Frame
+	for _, f := range r.Fields {
+		if fs, ok := f.Value.([]Frame); ok {
+			trace = append(trace, fs...)
+			continue
+		}
+		b.WriteByte(' ')
+		b.WriteString(quote(f.Key))
+		b.WriteByte('=')
+		b.WriteString(quote(textValue(f.Value)))
+	}
+	b.WriteByte('\n')
+	for _, f := range trace {
+		if f.Func == "" {
+			fmt.Fprintf(&b, "\t%s\n", f.Text)
+		} else if f.Text == "" {
+			fmt.Fprintf(&b, "\t%s (%s:%d)\n", f.Func, f.File, f.Line)
+		} else {
+			fmt.Fprintf(&b, "\t%s (%s:%d): %s\n", f.Func, f.File, f.Line, f.Text)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(b.Bytes())
+	return err
+}
+
+type jsonSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// JSONSink returns a sink which writes each record to w as a JSON object on a
+// single line. The object holds the keys time, level, and msg, followed by the
+// fields of the record in order. Errors are written as their messages, and
+// values which cannot be encoded as JSON are written as strings.
+func JSONSink(w io.Writer) Sink {
+	return &jsonSink{w: w}
+}
+
+func (s *jsonSink) Write(r Record) error {
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	writeJSON(&b, r.Time.Format(timeFormat))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, r.Level.String())
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, r.Msg)
+	for _, f := range r.Fields {
+		b.WriteByte(',')
+		writeJSON(&b, f.Key)
+		b.WriteByte(':')
+		writeJSON(&b, jsonValue(f.Value))
+	}
+	b.WriteString("}\n")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(b.Bytes())
+	return err
+}
+
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case json.Marshaler:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+func writeJSON(b *bytes.Buffer, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	b.Write(data)
+}
+
+func textValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+func quote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r == ' ' || r == '"' || r == '=' || r == '\\' || unicode.IsControl(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
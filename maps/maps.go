@@ -0,0 +1,147 @@
+// Package maps implements functions to manipulate maps.
+//
+// Iteration order over a Go map is unspecified, so functions such as Keys and
+// Values return their results in no particular order. Where a stable order is
+// needed, such as when printing or comparing output, the Sorted variants order
+// their results by key.
+package maps
+
+import (
+	"sort"
+
+	"git.sr.ht/~kvo/go-std"
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Pair represents a single key-value association in a map.
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Clone returns a shallow copy of m. The keys and values are copied using
+// assignment, so the copy shares any memory referenced by them. If m is nil,
+// Clone returns nil.
+func Clone[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[K]V, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+// Entries returns the key-value pairs of m in an unspecified order.
+func Entries[K comparable, V any](m map[K]V) []Pair[K, V] {
+	entries := make([]Pair[K, V], 0, len(m))
+	for k, v := range m {
+		entries = append(entries, Pair[K, V]{k, v})
+	}
+	return entries
+}
+
+// Invert returns a map whose keys are the values of m and whose values are the
+// corresponding keys of m. Returns error if two keys of m share the same value,
+// as one of the two keys would otherwise be silently discarded.
+//
+// For maps whose values are not unique, InvertMulti should be used instead.
+func Invert[K, V comparable](m map[K]V) (map[V]K, error) {
+	inv := make(map[V]K, len(m))
+	for k, v := range m {
+		if prev, ok := inv[v]; ok {
+			return nil, errors.New(nil,
+				"duplicate value %v for keys %v and %v", v, prev, k,
+			)
+		}
+		inv[v] = k
+	}
+	return inv, nil
+}
+
+// InvertMulti returns a map whose keys are the values of m and whose values
+// are all keys of m which map to that value. The order of keys within each
+// value of the resulting map is unspecified.
+func InvertMulti[K, V comparable](m map[K]V) map[V][]K {
+	inv := make(map[V][]K)
+	for k, v := range m {
+		inv[v] = append(inv[v], k)
+	}
+	return inv
+}
+
+// Keys returns the keys of m in an unspecified order.
+func Keys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Merge copies all key-value pairs of src into dst. If a key is present in both
+// maps, the value stored in dst is given by resolve(k, old, new), where old is
+// the value in dst and new is the value in src. If resolve is nil, Merge
+// behaves like MergeLast.
+//
+// Merge modifies dst in place, so dst must not be nil.
+func Merge[K comparable, V any](dst, src map[K]V, resolve func(k K, old, new V) V) {
+	if resolve == nil {
+		MergeLast(dst, src)
+		return
+	}
+	for k, v := range src {
+		if old, ok := dst[k]; ok {
+			dst[k] = resolve(k, old, v)
+		} else {
+			dst[k] = v
+		}
+	}
+}
+
+// MergeLast copies all key-value pairs of src into dst, overwriting the values
+// of any keys already present in dst.
+//
+// MergeLast modifies dst in place, so dst must not be nil.
+func MergeLast[K comparable, V any](dst, src map[K]V) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// SortedEntries returns the key-value pairs of m in ascending order of key.
+func SortedEntries[K std.Ordered, V any](m map[K]V) []Pair[K, V] {
+	entries := Entries(m)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// SortedKeys returns the keys of m in ascending order.
+func SortedKeys[K std.Ordered, V any](m map[K]V) []K {
+	keys := Keys(m)
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// SortedValues returns the values of m in ascending order of their keys.
+func SortedValues[K std.Ordered, V any](m map[K]V) []V {
+	values := make([]V, 0, len(m))
+	for _, k := range SortedKeys(m) {
+		values = append(values, m[k])
+	}
+	return values
+}
+
+// Values returns the values of m in an unspecified order.
+func Values[K comparable, V any](m map[K]V) []V {
+	values := make([]V, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,97 @@
+package mathx
+
+import (
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// AddChecked returns a + b. Returns error if the sum overflows T.
+func AddChecked[T Integer](a, b T) (T, error) {
+	c := a + b
+	if (c > a) != (b > 0) && b != 0 {
+		return 0, errors.New(nil, "%v + %v overflows %T", a, b, a)
+	}
+	return c, nil
+}
+
+// AddSat returns a + b, or the nearest value representable in T if the sum
+// overflows.
+func AddSat[T Integer](a, b T) T {
+	c, err := AddChecked(a, b)
+	switch {
+	case err == nil:
+		return c
+	case b < 0:
+		return minOf[T]()
+	default:
+		return maxOf[T]()
+	}
+}
+
+// DivChecked returns a / b, truncated towards zero. Returns error if b is
+// zero, or if the quotient overflows T, which is only the case when a is the
+// most negative value of a signed type and b is -1.
+func DivChecked[T Integer](a, b T) (T, error) {
+	if b == 0 {
+		return 0, errors.New(nil, "%v / 0: division by zero", a)
+	}
+	if signed[T]() && a == minOf[T]() && b == ^T(0) {
+		return 0, errors.New(nil, "%v / %v overflows %T", a, b, a)
+	}
+	return a / b, nil
+}
+
+// MulChecked returns a * b. Returns error if the product overflows T.
+func MulChecked[T Integer](a, b T) (T, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	c := a * b
+	overflow := c/b != a
+	if signed[T]() {
+		// The division above cannot detect these cases, as it overflows
+		// itself.
+		min := minOf[T]()
+		overflow = overflow || a == ^T(0) && b == min || b == ^T(0) && a == min
+	}
+	if overflow {
+		return 0, errors.New(nil, "%v * %v overflows %T", a, b, a)
+	}
+	return c, nil
+}
+
+// MulSat returns a * b, or the nearest value representable in T if the
+// product overflows.
+func MulSat[T Integer](a, b T) T {
+	c, err := MulChecked(a, b)
+	switch {
+	case err == nil:
+		return c
+	case (a < 0) != (b < 0):
+		return minOf[T]()
+	default:
+		return maxOf[T]()
+	}
+}
+
+// SubChecked returns a - b. Returns error if the difference overflows T.
+func SubChecked[T Integer](a, b T) (T, error) {
+	c := a - b
+	if (c < a) != (b > 0) && b != 0 {
+		return 0, errors.New(nil, "%v - %v overflows %T", a, b, a)
+	}
+	return c, nil
+}
+
+// SubSat returns a - b, or the nearest value representable in T if the
+// difference overflows.
+func SubSat[T Integer](a, b T) T {
+	c, err := SubChecked(a, b)
+	switch {
+	case err == nil:
+		return c
+	case b > 0:
+		return minOf[T]()
+	default:
+		return maxOf[T]()
+	}
+}
@@ -0,0 +1,133 @@
+// Package mathx implements integer arithmetic which reports overflow, rather
+// than silently wrapping around.
+//
+// The checked functions return error on overflow, and the saturating functions
+// return the nearest representable value:
+//
+//	n, err := mathx.MulChecked(size, count) // error if the product overflows
+//	total := mathx.AddSat[uint8](200, 100)  // 255
+//
+// Convert converts an integer between types, returning error if the value
+// cannot be represented in the new type:
+//
+//	port, err := mathx.Convert[uint16](n)
+//
+// Every function accepts any integer type, signed or unsigned, of any width.
+package mathx
+
+import (
+	"unsafe"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Signed is a constraint satisfied by any signed integer type.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is a constraint satisfied by any unsigned integer type.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Integer is a constraint satisfied by any integer type.
+type Integer interface {
+	Signed | Unsigned
+}
+
+// Abs returns the absolute value of v. Returns error if v is the most negative
+// value of its type, whose absolute value cannot be represented.
+func Abs[T Integer](v T) (T, error) {
+	if v >= 0 {
+		return v, nil
+	}
+	if v == minOf[T]() {
+		return 0, errors.New(nil, "absolute value of %v overflows %T", v, v)
+	}
+	return -v, nil
+}
+
+// Convert returns v converted to the integer type To. Returns error if v
+// cannot be represented in To, such as a negative value converted to an
+// unsigned type, or a value exceeding the range of a narrower type.
+func Convert[To, From Integer](v From) (To, error) {
+	t := To(v)
+	if From(t) != v || (v < 0) != (t < 0) {
+		return 0, errors.New(nil, "%v overflows %T", v, t)
+	}
+	return t, nil
+}
+
+// ConvertSat returns v converted to the integer type To, or the nearest value
+// representable in To if v is out of its range.
+func ConvertSat[To, From Integer](v From) To {
+	t, err := Convert[To](v)
+	switch {
+	case err == nil:
+		return t
+	case v < 0:
+		return minOf[To]()
+	default:
+		return maxOf[To]()
+	}
+}
+
+// GCD returns the greatest common divisor of a and b, which is never negative.
+// The greatest common divisor of 0 and 0 is 0. Returns error if the result
+// cannot be represented, which is only the case when the inputs are the most
+// negative value of their type and either 0 or that value.
+func GCD[T Integer](a, b T) (T, error) {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	if x < 0 {
+		if x == minOf[T]() {
+			return 0, errors.New(nil, "greatest common divisor of %v and %v overflows %T", a, b, a)
+		}
+		x = -x
+	}
+	return x, nil
+}
+
+// LCM returns the least common multiple of a and b, which is never negative.
+// The least common multiple of 0 and any value is 0. Returns error if the
+// result cannot be represented.
+func LCM[T Integer](a, b T) (T, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	g, err := GCD(a, b)
+	if err == nil {
+		var m T
+		if m, err = MulChecked(a/g, b); err == nil {
+			if m, err = Abs(m); err == nil {
+				return m, nil
+			}
+		}
+	}
+	return 0, errors.New(nil, "least common multiple of %v and %v overflows %T", a, b, a)
+}
+
+// signed reports whether T is a signed integer type.
+func signed[T Integer]() bool {
+	return ^T(0) < 0
+}
+
+// maxOf returns the greatest value of the integer type T.
+func maxOf[T Integer]() T {
+	if signed[T]() {
+		var zero T
+		return T(1)<<(unsafe.Sizeof(zero)*8-1) - 1
+	}
+	return ^T(0)
+}
+
+// minOf returns the least value of the integer type T.
+func minOf[T Integer]() T {
+	if signed[T]() {
+		return ^maxOf[T]()
+	}
+	return 0
+}
@@ -0,0 +1,119 @@
+package std
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// MemoOptions configures the cache used by MemoizeWith. The zero value of a
+// MemoOptions describes an unbounded cache whose entries never expire.
+type MemoOptions struct {
+	// MaxEntries is the maximum number of results held in the cache. When the
+	// cache is full, the least recently used result is evicted. If MaxEntries
+	// is zero or negative, the cache is unbounded.
+	MaxEntries int
+
+	// TTL is the duration for which a result remains in the cache after it is
+	// computed. If TTL is zero or negative, results never expire.
+	TTL time.Duration
+}
+
+// Memoize is equivalent to MemoizeWith(f, MemoOptions{}).
+func Memoize[K comparable, V any](f func(K) (V, error)) func(K) (V, error) {
+	return MemoizeWith(f, MemoOptions{})
+}
+
+// MemoizeWith returns a function which behaves like f, except that successful
+// results are cached and returned by subsequent calls with the same argument
+// without calling f again. Errors are never cached, so a failed call is retried
+// the next time the same argument is given.
+//
+// If the returned function is called concurrently with the same argument while
+// a call to f for that argument is in progress, f is not called again; instead,
+// each caller waits for and receives the result of the call in progress.
+//
+// The returned function is safe for concurrent use by multiple goroutines.
+func MemoizeWith[K comparable, V any](f func(K) (V, error), opts MemoOptions) func(K) (V, error) {
+	m := &memo[K, V]{
+		f:       f,
+		opts:    opts,
+		entries: make(map[K]*list.Element),
+		lru:     list.New(),
+		calls:   make(map[K]*memoCall[V]),
+	}
+	return m.get
+}
+
+type memo[K comparable, V any] struct {
+	f       func(K) (V, error)
+	opts    MemoOptions
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	lru     *list.List
+	calls   map[K]*memoCall[V]
+}
+
+type memoEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+type memoCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func (m *memo[K, V]) get(k K) (V, error) {
+	m.mu.Lock()
+	if elem, ok := m.entries[k]; ok {
+		entry := elem.Value.(*memoEntry[K, V])
+		if m.opts.TTL <= 0 || time.Now().Before(entry.expires) {
+			m.lru.MoveToFront(elem)
+			m.mu.Unlock()
+			return entry.value, nil
+		}
+		m.lru.Remove(elem)
+		delete(m.entries, k)
+	}
+	if c, ok := m.calls[k]; ok {
+		m.mu.Unlock()
+		<-c.done
+		return c.value, c.err
+	}
+	c := &memoCall[V]{
+		done: make(chan struct{}),
+		err:  errors.New(nil, "memoized function panicked"),
+	}
+	m.calls[k] = c
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.calls, k)
+		if c.err == nil {
+			m.store(k, c.value)
+		}
+		m.mu.Unlock()
+		close(c.done)
+	}()
+	c.value, c.err = m.f(k)
+	return c.value, c.err
+}
+
+// store adds a result to the cache. The caller must hold m.mu.
+func (m *memo[K, V]) store(k K, v V) {
+	entry := &memoEntry[K, V]{key: k, value: v}
+	if m.opts.TTL > 0 {
+		entry.expires = time.Now().Add(m.opts.TTL)
+	}
+	m.entries[k] = m.lru.PushFront(entry)
+	if m.opts.MaxEntries > 0 && m.lru.Len() > m.opts.MaxEntries {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoEntry[K, V]).key)
+	}
+}
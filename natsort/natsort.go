@@ -0,0 +1,153 @@
+// Package natsort implements natural ordering of strings, which orders the
+// runs of digits within strings by their numeric values, as people expect:
+//
+//	files := []string{"file10.txt", "file2.txt", "File1.txt"}
+//	natsort.Sort(files) // File1.txt file2.txt file10.txt
+//
+// CompareFold, LessFold and SortFold also ignore the case of letters.
+package natsort
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Compare returns -1 if a orders before b, 0 if they are equal, and +1 if a
+// orders after b, in natural order. Strings are compared run by run: runs of
+// the ASCII digits 0-9 compare by their numeric values, whatever their length,
+// and other runes compare by their values, a digit ordering before any other
+// rune. Of numbers of equal value, one written with fewer leading zeros orders
+// first, so that strings differing only in leading zeros are not equal.
+func Compare(a, b string) int {
+	return compare(a, b, false)
+}
+
+// CompareFold is like Compare, but compares letters ignoring their case, as
+// by simple Unicode case folding, so that "File1" and "file1" are equal.
+func CompareFold(a, b string) int {
+	return compare(a, b, true)
+}
+
+// Less reports whether a orders before b, as by Compare.
+func Less(a, b string) bool {
+	return compare(a, b, false) < 0
+}
+
+// LessFold reports whether a orders before b, as by CompareFold.
+func LessFold(a, b string) bool {
+	return compare(a, b, true) < 0
+}
+
+// Sort sorts s in natural order, as by Compare.
+func Sort(s []string) {
+	sort.SliceStable(s, func(i, j int) bool {
+		return compare(s[i], s[j], false) < 0
+	})
+}
+
+// SortFold sorts s in natural order, ignoring case, as by CompareFold.
+// Strings which compare as equal keep their original order.
+func SortFold(s []string) {
+	sort.SliceStable(s, func(i, j int) bool {
+		return compare(s[i], s[j], true) < 0
+	})
+}
+
+func compare(a, b string, fold bool) int {
+	// zeros breaks the tie between numbers of equal value but different
+	// leading zeros, should the strings be otherwise equal.
+	zeros := 0
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			x, y := digitRun(a), digitRun(b)
+			if c := compareNumbers(a[:x], b[:y]); c != 0 {
+				return c
+			}
+			if zeros == 0 && x != y {
+				zeros = sign(x - y)
+			}
+			a, b = a[x:], b[y:]
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(a)
+		s, m := utf8.DecodeRuneInString(b)
+		if fold {
+			r, s = foldRune(r), foldRune(s)
+		}
+		if r != s {
+			switch {
+			case isDigit(a[0]):
+				return -1
+			case isDigit(b[0]):
+				return 1
+			}
+			return sign(int(r) - int(s))
+		}
+		a, b = a[n:], b[m:]
+	}
+	switch {
+	case a != "":
+		return 1
+	case b != "":
+		return -1
+	}
+	return zeros
+}
+
+// compareNumbers compares the runs of digits x and y by their values.
+func compareNumbers(x, y string) int {
+	x, y = trimZeros(x), trimZeros(y)
+	if len(x) != len(y) {
+		return sign(len(x) - len(y))
+	}
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// digitRun returns the length of the run of digits at the start of s.
+func digitRun(s string) int {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// foldRune returns the least rune equivalent to r under simple case folding,
+// so that equivalent runes fold to the same rune.
+func foldRune(r rune) rune {
+	least := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < least {
+			least = f
+		}
+	}
+	return least
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func trimZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
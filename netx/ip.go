@@ -0,0 +1,62 @@
+package netx
+
+import (
+	"net"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// LocalIPs returns the unicast addresses, other than loopback and link-local
+// addresses, of the network interfaces of the host which are up, IPv4
+// addresses first. Returns error if the interfaces cannot be listed.
+func LocalIPs() ([]net.IP, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, errors.New(err, "cannot list network interfaces")
+	}
+	var v4, v6 []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, errors.New(err, "cannot list addresses of %s", iface.Name)
+		}
+		for _, addr := range addrs {
+			ip := addrIP(addr)
+			switch {
+			case ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || !ip.IsGlobalUnicast():
+			case ip.To4() != nil:
+				v4 = append(v4, ip.To4())
+			default:
+				v6 = append(v6, ip)
+			}
+		}
+	}
+	return append(v4, v6...), nil
+}
+
+// LocalIPv4 returns the first IPv4 address found by LocalIPs. Returns error if
+// the interfaces cannot be listed, or the host has no such address.
+func LocalIPv4() (net.IP, error) {
+	ips, err := LocalIPs()
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 || ips[0].To4() == nil {
+		return nil, errors.New(nil, "no non-loopback IPv4 address")
+	}
+	return ips[0], nil
+}
+
+// addrIP returns the IP address of addr, or nil if it has none.
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPNet:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
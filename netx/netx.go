@@ -0,0 +1,124 @@
+// Package netx implements networking utilities for servers and the programs
+// which test them.
+//
+// FreePort finds a port on which a test server may listen, and WaitForPort
+// waits for a server to start accepting connections:
+//
+//	port, err := netx.FreePort()
+//	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+//	go serve(addr)
+//	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+//	defer cancel()
+//	if err := netx.WaitForPort(ctx, addr); err != nil {
+//		return err
+//	}
+//
+// LocalIPv4 and LocalIPs find the addresses of the host, such as to advertise
+// a service to its peers:
+//
+//	ip, err := netx.LocalIPv4()
+package netx
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/retry"
+)
+
+// DefaultDialer is the dialer used by Dial and WaitForPort. It gives up on a
+// connection after 10s, and sends TCP keep-alive probes every 30s.
+var DefaultDialer = &net.Dialer{
+	Timeout:   10 * time.Second,
+	KeepAlive: 30 * time.Second,
+}
+
+// Dial connects to addr on the named network, as by net.Dialer.DialContext,
+// using DefaultDialer, so that the connection attempt is bounded even if ctx
+// has no deadline. Returns error if the connection cannot be made.
+func Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := DefaultDialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, errors.New(err, "cannot dial %s", addr)
+	}
+	return conn, nil
+}
+
+// FreePort returns a TCP port on the loopback interface which is free to be
+// listened on. Another program may take the port before it is used, so a
+// listener should be opened on it promptly. Returns error if no port can be
+// found.
+func FreePort() (int, error) {
+	ports, err := FreePorts(1)
+	if err != nil {
+		return 0, err
+	}
+	return ports[0], nil
+}
+
+// FreePorts returns n distinct TCP ports on the loopback interface which are
+// free to be listened on, as described for FreePort. Returns error if n is
+// negative, or if n ports cannot be found.
+func FreePorts(n int) ([]int, error) {
+	if n < 0 {
+		return nil, errors.New(nil, "invalid number of ports %d", n)
+	}
+	ports := make([]int, n)
+	// Each listener is held open until every port is found, so that no
+	// port is returned twice.
+	for i := range ports {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, errors.New(err, "cannot find free port")
+		}
+		defer l.Close()
+		ports[i] = l.Addr().(*net.TCPAddr).Port
+	}
+	return ports, nil
+}
+
+// SplitHostPort splits addr, of the form "host:port", "[host]:port" or
+// ":port", into its host and its numeric port. Returns error if addr is
+// malformed or its port is not a number from 0 to 65535.
+func SplitHostPort(addr string) (host string, port int, err error) {
+	host, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, errors.New(err, "invalid address %q", addr)
+	}
+	n, err := strconv.ParseUint(p, 10, 16)
+	if err != nil {
+		return "", 0, errors.New(nil, "invalid address %q: invalid port %q", addr, p)
+	}
+	return host, int(n), nil
+}
+
+// WaitForPort waits until a TCP connection can be made to addr, dialling it
+// repeatedly with exponential backoff, from 50ms up to 1s between attempts.
+// Each connection made is closed at once. Returns error if ctx is done before
+// a connection is made, wrapping the error of the last attempt.
+func WaitForPort(ctx context.Context, addr string) error {
+	var last error
+	err := retry.Do(ctx, func(ctx context.Context) error {
+		conn, err := DefaultDialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			last = err
+			return err
+		}
+		conn.Close()
+		return nil
+	},
+		retry.Attempts(0),
+		retry.Backoff(50*time.Millisecond, time.Second),
+		retry.Timeout(time.Second),
+	)
+	if err == nil {
+		return nil
+	}
+	if last == nil {
+		last = ctx.Err()
+	}
+	return errors.New(last, "cannot reach %s", addr)
+}
@@ -0,0 +1,146 @@
+package pathx
+
+import (
+	"os"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Expand replaces references to variables in s with their values, as given by
+// lookup, which reports whether a variable is set. The references are those of
+// the POSIX shell:
+//
+//	$name, ${name}  the value of name, or "" if it is unset
+//	${name:-word}   the value of name, or word if it is unset or empty
+//	${name-word}    the value of name, or word if it is unset
+//	${name:+word}   word if name is set and not empty, or else ""
+//	${name:?word}   the value of name; error with message word if it is unset
+//	                or empty
+//
+// The word may itself hold references. A name is a letter or underscore
+// followed by letters, digits and underscores. $$ expands to a single dollar
+// sign, and a dollar sign followed by no name is kept as it is. Returns error
+// if a brace is unterminated, a name is malformed, or a variable is required.
+func Expand(s string, lookup func(name string) (string, bool)) (string, error) {
+	var sb strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+		sb.WriteString(s[:i])
+		s = s[i+1:]
+		switch {
+		case strings.HasPrefix(s, "$"):
+			sb.WriteByte('$')
+			s = s[1:]
+		case strings.HasPrefix(s, "{"):
+			end := closingBrace(s)
+			if end < 0 {
+				return "", errors.New(nil, "unterminated reference $%s", s)
+			}
+			v, err := expandBraced(s[1:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(v)
+			s = s[end+1:]
+		default:
+			n := nameLen(s)
+			if n == 0 {
+				sb.WriteByte('$')
+				continue
+			}
+			v, _ := lookup(s[:n])
+			sb.WriteString(v)
+			s = s[n:]
+		}
+	}
+}
+
+// ExpandEnv replaces references to environment variables in s, as by Expand.
+func ExpandEnv(s string) (string, error) {
+	return Expand(s, os.LookupEnv)
+}
+
+// expandBraced returns the expansion of the reference ${ref}.
+func expandBraced(ref string, lookup func(string) (string, bool)) (string, error) {
+	n := nameLen(ref)
+	if n == 0 {
+		return "", errors.New(nil, "invalid variable name in ${%s}", ref)
+	}
+	name, op := ref[:n], ref[n:]
+	v, set := lookup(name)
+	if op == "" {
+		return v, nil
+	}
+	colon := strings.HasPrefix(op, ":")
+	if colon {
+		op = op[1:]
+	}
+	if op == "" {
+		return "", errors.New(nil, "invalid reference ${%s}", ref)
+	}
+	// empty reports whether the variable counts as unset for the operator.
+	empty := !set || colon && v == ""
+	word := func() (string, error) {
+		return Expand(op[1:], lookup)
+	}
+	switch op[0] {
+	case '-':
+		if empty {
+			return word()
+		}
+		return v, nil
+	case '+':
+		if empty {
+			return "", nil
+		}
+		return word()
+	case '?':
+		if !empty {
+			return v, nil
+		}
+		msg, err := word()
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			msg = "is not set"
+		}
+		return "", errors.New(nil, "%s: %s", name, msg)
+	}
+	return "", errors.New(nil, "invalid reference ${%s}", ref)
+}
+
+// closingBrace returns the index of the brace closing the brace at the start
+// of s, or -1 if there is none.
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// nameLen returns the length of the variable name at the start of s.
+func nameLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9' {
+			continue
+		}
+		return i
+	}
+	return len(s)
+}
@@ -0,0 +1,124 @@
+// Package pathx implements the expansion of file paths, and their joining
+// without traversal outside a root directory.
+//
+// ExpandUser and ExpandEnv expand paths as a shell would:
+//
+//	dir, err := pathx.ExpandUser("~/.cache/app")
+//	path, err := pathx.ExpandEnv("${XDG_CONFIG_HOME:-$HOME/.config}/app")
+//
+// Join joins untrusted paths, such as the names of files in an archive, to a
+// root directory, returning error rather than a path outside the root:
+//
+//	dst, err := pathx.Join(destDir, hdr.Name)
+//	if err != nil {
+//		return err // such as for "../../etc/passwd"
+//	}
+package pathx
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// ContainsDotDot reports whether p has an element "..", separated by slashes,
+// or by backslashes on Windows. Such a path may refer outside the directory
+// against which it is resolved.
+func ContainsDotDot(p string) bool {
+	for _, elem := range strings.FieldsFunc(p, isSeparator) {
+		if elem == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandUser replaces a leading "~" in path with the home directory of the
+// current user, and a leading "~name" with the home directory of the user
+// name. A path not starting with "~", or in which the "~" is followed by
+// other than a separator or a user name, is returned unchanged. Returns error
+// if the home directory cannot be found.
+func ExpandUser(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	name, rest := path[1:], ""
+	if i := strings.IndexFunc(name, isSeparator); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	var home string
+	if name == "" {
+		var err error
+		home, err = os.UserHomeDir()
+		if err != nil {
+			return "", errors.New(err, "cannot expand %s", path)
+		}
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", errors.New(err, "cannot expand %s", path)
+		}
+		home = u.HomeDir
+	}
+	return home + rest, nil
+}
+
+// Join joins elems to root, as by filepath.Join, treating each element as
+// relative to root, even if it is absolute. Returns error if the result lies
+// outside root, such as if an element holds "..". The check is lexical, so
+// does not detect symbolic links within root which lead outside it.
+func Join(root string, elems ...string) (string, error) {
+	path := filepath.Join(append([]string{root}, elems...)...)
+	if !WithinRoot(root, path) {
+		return "", errors.New(nil, "path %s escapes %s", filepath.Join(elems...), root)
+	}
+	return path, nil
+}
+
+// MustRel is like Rel, but panics if target does not lie within base. It is
+// intended for paths known to lie within base, such as those found by walking
+// it:
+//
+//	rel := pathx.MustRel(root, path)
+func MustRel(base, target string) string {
+	return errors.Must(Rel(base, target))
+}
+
+// Rel returns the path of target relative to base, as by filepath.Rel.
+// Returns error if target does not lie within base, such that the relative
+// path would start with "..", or if it cannot be made relative to base.
+func Rel(base, target string) (string, error) {
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return "", errors.New(err, "cannot make %s relative to %s", target, base)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New(nil, "%s is not within %s", target, base)
+	}
+	return rel, nil
+}
+
+// WithinRoot reports whether path is root or lies below it, once both are
+// cleaned, as by filepath.Clean. The check is lexical, so relative paths are
+// only comparable with relative paths, and symbolic links are not followed.
+func WithinRoot(root, path string) bool {
+	root, path = filepath.Clean(root), filepath.Clean(path)
+	if root == "." {
+		return !filepath.IsAbs(path) && path != ".." &&
+			!strings.HasPrefix(path, ".."+string(filepath.Separator))
+	}
+	if path == root {
+		return true
+	}
+	if !strings.HasSuffix(root, string(filepath.Separator)) {
+		root += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, root)
+}
+
+func isSeparator(r rune) bool {
+	return r == '/' || r == filepath.Separator
+}
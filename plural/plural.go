@@ -0,0 +1,59 @@
+// Package plural implements the plural forms of words and the formatting of
+// counts, for messages which read naturally whatever the count.
+//
+// Pluralize selects the English singular or plural form of a word, and Format
+// also prefixes the count:
+//
+//	fmt.Printf("copied %s\n", plural.Format(n, "file", "files")) // copied 1 file
+//	fmt.Printf("%d %s changed\n", n, plural.Pluralize(n, "line", "lines"))
+//
+// Other languages have more plural forms, chosen by rules of their own. Select
+// chooses among them by the plural categories of the Unicode CLDR:
+//
+//	msg := plural.Select("ru", n, plural.Forms{
+//		plural.One:   "%d файл",
+//		plural.Few:   "%d файла",
+//		plural.Many:  "%d файлов",
+//		plural.Other: "%d файла",
+//	})
+//
+// Ordinal formats English ordinal numbers, such as "1st" and "22nd".
+package plural
+
+import "strconv"
+
+// Format returns n followed by the English singular form of a word if n is 1
+// or -1, or its plural form otherwise, such as "1 file" or "0 files".
+func Format(n int, singular, plural string) string {
+	return strconv.Itoa(n) + " " + Pluralize(n, singular, plural)
+}
+
+// Ordinal returns n as an English ordinal number, such as "1st", "2nd", "3rd",
+// "4th", "11th" or "101st".
+func Ordinal(n int) string {
+	suffix := "th"
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	if abs%100 < 11 || abs%100 > 13 {
+		switch abs % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// Pluralize returns the English singular form of a word if n is 1 or -1, or
+// its plural form otherwise.
+func Pluralize(n int, singular, plural string) string {
+	if n == 1 || n == -1 {
+		return singular
+	}
+	return plural
+}
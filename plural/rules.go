@@ -0,0 +1,152 @@
+package plural
+
+import "strings"
+
+// Category is a plural category, as defined by the Unicode CLDR. The
+// categories a language uses, and the counts each covers, depend on the
+// language; English uses only One and Other.
+type Category int
+
+// The plural categories of the CLDR. Other is the zero value, as every
+// language uses it.
+const (
+	Other Category = iota
+	Zero
+	One
+	Two
+	Few
+	Many
+)
+
+// String returns the CLDR name of c, such as "few".
+func (c Category) String() string {
+	switch c {
+	case Zero:
+		return "zero"
+	case One:
+		return "one"
+	case Two:
+		return "two"
+	case Few:
+		return "few"
+	case Many:
+		return "many"
+	}
+	return "other"
+}
+
+// Forms holds the forms of a message for each plural category. The form for
+// Other should always be given, as the fallback for categories without forms.
+type Forms map[Category]string
+
+// Of returns the plural category of the count n in the language lang, given
+// as a BCP 47 tag such as "en" or "pt-BR", of which only the primary language
+// is considered. The rules are those of the CLDR for integers, for the
+// languages:
+//
+//	ar                      zero, one, two, few, many, other
+//	cs, sk                  one, few, other
+//	fr, pt                  one (0 and 1), other
+//	he                      one, two, other
+//	ja, ko, zh, id, th, vi  other
+//	lt, ro                  one, few, other
+//	pl, ru, uk, be          one, few, many
+//
+// Every other language, including the Germanic and other Romance languages,
+// takes the rule of English: One for 1, and Other otherwise. Negative counts
+// are categorised by their absolute values.
+func Of(lang string, n int) Category {
+	if n < 0 {
+		n = -n
+	}
+	mod10, mod100 := n%10, n%100
+	switch primary(lang) {
+	case "ar":
+		switch {
+		case n == 0:
+			return Zero
+		case n == 1:
+			return One
+		case n == 2:
+			return Two
+		case mod100 >= 3 && mod100 <= 10:
+			return Few
+		case mod100 >= 11:
+			return Many
+		}
+	case "cs", "sk":
+		switch {
+		case n == 1:
+			return One
+		case n >= 2 && n <= 4:
+			return Few
+		}
+	case "fr", "pt":
+		if n <= 1 {
+			return One
+		}
+	case "he", "iw":
+		switch n {
+		case 1:
+			return One
+		case 2:
+			return Two
+		}
+	case "ja", "ko", "zh", "id", "th", "vi":
+	case "lt":
+		switch {
+		case mod100 >= 11 && mod100 <= 19:
+		case mod10 == 1:
+			return One
+		case mod10 >= 2:
+			return Few
+		}
+	case "ro":
+		switch {
+		case n == 1:
+			return One
+		case n == 0 || mod100 >= 2 && mod100 <= 19:
+			return Few
+		}
+	case "pl":
+		switch {
+		case n == 1:
+			return One
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return Few
+		}
+		return Many
+	case "ru", "uk", "be":
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return One
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return Few
+		}
+		return Many
+	default:
+		if n == 1 {
+			return One
+		}
+	}
+	return Other
+}
+
+// Select returns the form of forms for the plural category of n in the
+// language lang, as by Of. If forms has no form for the category, the form for
+// Other is returned. A form is returned as it is; it may be formatted with n by
+// fmt.Sprintf.
+func Select(lang string, n int, forms Forms) string {
+	if s, ok := forms[Of(lang, n)]; ok {
+		return s
+	}
+	return forms[Other]
+}
+
+// primary returns the primary language subtag of the tag lang, in lower case.
+func primary(lang string) string {
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return strings.ToLower(lang)
+}
@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// BufferOptions configures a BufferPool. A nil *BufferOptions is equivalent to
+// a zero BufferOptions, in which every field takes its default.
+type BufferOptions struct {
+	// MinSize is the capacity of the smallest buffers held; the capacity of
+	// each larger size class is double that of the one below. It defaults to
+	// 64 bytes.
+	MinSize int
+
+	// MaxSize is the greatest capacity of the buffers held. Larger buffers
+	// are discarded when returned, so that a rare large request does not
+	// keep its memory in use. It defaults to 64 KiB.
+	MaxSize int
+}
+
+// BufferPool is a pool of byte buffers, binned into size classes by their
+// capacity, which may be used by multiple goroutines at once.
+type BufferPool struct {
+	min, max int
+	classes  []sync.Pool
+}
+
+// NewBufferPool returns an empty buffer pool.
+func NewBufferPool(opts *BufferOptions) *BufferPool {
+	min, max := 64, 64<<10
+	if opts != nil {
+		if opts.MinSize > 0 {
+			min = opts.MinSize
+		}
+		if opts.MaxSize > 0 {
+			max = opts.MaxSize
+		}
+	}
+	n := 1
+	for size := min; size < max; size *= 2 {
+		n++
+	}
+	return &BufferPool{min: min, max: max, classes: make([]sync.Pool, n)}
+}
+
+// Get returns an empty buffer with a capacity of at least size bytes, taken
+// from the smallest size class holding such buffers, or newly created.
+func (p *BufferPool) Get(size int) *bytes.Buffer {
+	i := 0
+	for c := p.min; c < size; c *= 2 {
+		i++
+	}
+	if i >= len(p.classes) {
+		return bytes.NewBuffer(make([]byte, 0, size))
+	}
+	if b, ok := p.classes[i].Get().(*bytes.Buffer); ok {
+		return b
+	}
+	return bytes.NewBuffer(make([]byte, 0, p.min<<i))
+}
+
+// Put empties b and returns it to p, in the largest size class whose capacity
+// it meets. A buffer smaller than the smallest class, or larger than the
+// maximum size, is discarded. The caller must not use b afterwards.
+func (p *BufferPool) Put(b *bytes.Buffer) {
+	c := b.Cap()
+	if c < p.min || c > p.max {
+		return
+	}
+	i := 0
+	for size := p.min * 2; size <= c && i+1 < len(p.classes); size *= 2 {
+		i++
+	}
+	b.Reset()
+	p.classes[i].Put(b)
+}
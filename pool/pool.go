@@ -0,0 +1,54 @@
+// Package pool implements typed pools of reusable values, which reduce the
+// allocation of short-lived objects in busy programs.
+//
+// A Pool holds values of any type, created and reset by the given functions:
+//
+//	var encoders = pool.New(
+//		func() *Encoder { return NewEncoder() },
+//		func(e *Encoder) { e.Reset() },
+//	)
+//
+//	e := encoders.Get()
+//	defer encoders.Put(e)
+//
+// A BufferPool holds byte buffers, binned by capacity, so that a request for a
+// small buffer is not served by a large one, and large buffers are not kept:
+//
+//	buffers := pool.NewBufferPool(nil)
+//	b := buffers.Get(512)
+//	defer buffers.Put(b)
+package pool
+
+import "sync"
+
+// Pool is a pool of values of type T, which may be used by multiple
+// goroutines at once. As with sync.Pool, values held by a Pool may be
+// discarded at any time.
+type Pool[T any] struct {
+	p     sync.Pool
+	reset func(T)
+}
+
+// New returns a pool which creates values with newFn when it is empty, and
+// resets values with reset, if not nil, as they are returned to it.
+func New[T any](newFn func() T, reset func(T)) *Pool[T] {
+	p := &Pool[T]{reset: reset}
+	p.p.New = func() any {
+		return newFn()
+	}
+	return p
+}
+
+// Get removes a value from p and returns it, or creates a new value if p is
+// empty.
+func (p *Pool[T]) Get() T {
+	return p.p.Get().(T)
+}
+
+// Put resets v and returns it to p. The caller must not use v afterwards.
+func (p *Pool[T]) Put(v T) {
+	if p.reset != nil {
+		p.reset(v)
+	}
+	p.p.Put(v)
+}
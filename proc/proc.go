@@ -0,0 +1,102 @@
+// Package proc implements the management of process lifecycles, for daemons
+// and the programs which start them.
+//
+// A PID file records the process ID of a running daemon, and prevents a
+// second instance from starting:
+//
+//	if err := proc.WritePIDFile("/run/mydaemon.pid"); err != nil {
+//		return err
+//	}
+//	defer proc.RemovePIDFile("/run/mydaemon.pid")
+//
+// Supervise runs a child process, restarting it with increasing delays
+// whenever it fails, until its context is done:
+//
+//	err := proc.Supervise(ctx, []string{"./worker", "-queue", "jobs"},
+//		&proc.SuperviseOptions{MaxBackoff: 30 * time.Second},
+//	)
+//
+// A process running as PID 1, such as in a container, must reap the children
+// orphaned by other processes; RunReaper does so until its context is done:
+//
+//	go proc.RunReaper(ctx)
+//
+// Reexec replaces the running program with a fresh copy of its executable,
+// such as after the executable is upgraded.
+package proc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// ReadPIDFile returns the process ID recorded in the PID file at path, and
+// reports whether a process with that ID is running. Returns error if the file
+// cannot be read or does not hold a process ID.
+func ReadPIDFile(path string) (pid int, running bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false, errors.New(err, "cannot read PID file %s", path)
+	}
+	pid, err = strconv.Atoi(string(bytes.TrimSpace(data)))
+	if err != nil || pid <= 0 {
+		return 0, false, errors.New(nil, "invalid PID file %s", path)
+	}
+	return pid, alive(pid), nil
+}
+
+// RemovePIDFile removes the PID file at path if it records the current
+// process, so that a process never removes the PID file of another. Returns
+// error if the file records the current process but cannot be removed.
+func RemovePIDFile(path string) error {
+	pid, _, err := ReadPIDFile(path)
+	if err != nil || pid != os.Getpid() {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return errors.New(err, "cannot remove PID file %s", path)
+	}
+	return nil
+}
+
+// WritePIDFile records the ID of the current process in a PID file at path,
+// creating the file's directory if needed. A PID file left behind by a process
+// which is no longer running is replaced. Returns error if the file records
+// another running process, or if it cannot be written.
+func WritePIDFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.New(err, "cannot write PID file %s", path)
+	}
+	data := []byte(strconv.Itoa(os.Getpid()) + "\n")
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, err = f.Write(data)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return errors.New(err, "cannot write PID file %s", path)
+			}
+			return nil
+		}
+		if !os.IsExist(err) || attempt > 0 {
+			return errors.New(err, "cannot write PID file %s", path)
+		}
+		pid, running, rerr := ReadPIDFile(path)
+		if rerr == nil && pid == os.Getpid() {
+			return nil
+		} else if rerr == nil && running {
+			return errors.New(nil, "cannot write PID file %s: already running as process %d", path, pid)
+		}
+		// The file is stale or corrupt.
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.New(err, "cannot remove stale PID file %s", path)
+		}
+	}
+}
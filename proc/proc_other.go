@@ -0,0 +1,35 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package proc
+
+import (
+	"context"
+	"os"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// stopSignal is sent to a supervised child to ask it to stop.
+var stopSignal = os.Interrupt
+
+// Reap does nothing on this platform, and returns zero.
+func Reap() int {
+	return 0
+}
+
+// RunReaper does nothing until ctx is done. See Reap.
+func RunReaper(ctx context.Context) {
+	<-ctx.Done()
+}
+
+// Reexec is not supported on this platform, and always returns error.
+func Reexec(env ...string) error {
+	return errors.New(nil, "cannot re-execute: unsupported on this platform")
+}
+
+// alive reports whether a process with ID pid is running. Without a portable
+// means of checking, every process is assumed to be running, so that a PID
+// file is never replaced wrongly.
+func alive(pid int) bool {
+	return true
+}
@@ -0,0 +1,74 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package proc
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// stopSignal is sent to a supervised child to ask it to stop.
+var stopSignal os.Signal = syscall.SIGTERM
+
+// Reap waits for every child process which has exited without having been
+// waited for, and returns the number of processes reaped. Reap does not
+// block.
+//
+// Reap may collect children started with package os/exec, whose Wait methods
+// then fail, so it should only be used by processes which reap orphans, such
+// as PID 1 in a container.
+func Reap() int {
+	n := 0
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || pid <= 0 {
+			return n
+		}
+		n++
+	}
+}
+
+// RunReaper calls Reap whenever a child process exits, until ctx is done. See
+// Reap for when it should be used.
+func RunReaper(ctx context.Context) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGCHLD)
+	defer signal.Stop(sigs)
+	Reap()
+	for {
+		select {
+		case <-sigs:
+			Reap()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Reexec replaces the current process with a new run of its executable, with
+// the same arguments and environment, and env added to the environment as by
+// execx.Env. The process keeps its ID and open files not marked
+// close-on-exec. Reexec only returns if the executable cannot be run.
+func Reexec(env ...string) error {
+	path, err := os.Executable()
+	if err != nil {
+		return errors.New(err, "cannot re-execute")
+	}
+	if err := syscall.Exec(path, os.Args, append(os.Environ(), env...)); err != nil {
+		return errors.New(os.NewSyscallError("exec", err), "cannot re-execute %s", path)
+	}
+	return nil
+}
+
+func alive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
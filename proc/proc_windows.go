@@ -0,0 +1,63 @@
+package proc
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// stopSignal is sent to a supervised child to ask it to stop. Windows offers
+// no signal which asks a process to stop, so the child is killed.
+var stopSignal = os.Kill
+
+// stillActive is the exit code of a process which has not exited.
+const stillActive = 259
+
+// Reap does nothing, since Windows does not keep exited processes for their
+// parents, and returns zero.
+func Reap() int {
+	return 0
+}
+
+// RunReaper does nothing until ctx is done. See Reap.
+func RunReaper(ctx context.Context) {
+	<-ctx.Done()
+}
+
+// Reexec starts a new run of the current executable, with the same arguments,
+// standard streams and environment, and env added to the environment as by
+// execx.Env. Windows cannot replace a running process, so the current process
+// then exits with status zero, without running deferred functions. Reexec
+// only returns if the executable cannot be run.
+func Reexec(env ...string) error {
+	path, err := os.Executable()
+	if err != nil {
+		return errors.New(err, "cannot re-execute")
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), env...)
+	if err := cmd.Start(); err != nil {
+		return errors.New(err, "cannot re-execute %s", path)
+	}
+	os.Exit(0)
+	return nil
+}
+
+func alive(pid int) bool {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
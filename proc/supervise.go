@@ -0,0 +1,146 @@
+package proc
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// SuperviseOptions configures Supervise. A nil *SuperviseOptions is
+// equivalent to a zero SuperviseOptions, in which every field takes its
+// default.
+type SuperviseOptions struct {
+	// MinBackoff is the delay before restarting a child which failed, and
+	// defaults to one second. The delay doubles after each consecutive
+	// failure.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the longest delay before restarting a child, and
+	// defaults to one minute.
+	MaxBackoff time.Duration
+
+	// ResetAfter is the time for which a child must run for its failure not
+	// to count as consecutive, resetting the delay to MinBackoff. It
+	// defaults to MaxBackoff.
+	ResetAfter time.Duration
+
+	// MaxRestarts is the number of times a child is restarted before
+	// Supervise gives up. If MaxRestarts is zero or negative, the child is
+	// restarted indefinitely.
+	MaxRestarts int
+
+	// StopTimeout is the time a child is given to exit after being asked to
+	// stop, after which it is killed. It defaults to ten seconds.
+	StopTimeout time.Duration
+
+	// Dir is the working directory of the child. If empty, the child runs in
+	// the current directory.
+	Dir string
+
+	// Env is added to the environment inherited by the child, as by
+	// execx.Env.
+	Env []string
+
+	// Stdout and Stderr receive the output of the child. If nil, the
+	// output of the current process is used.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// OnExit, if not nil, is called after each run of the child, with nil if
+	// the child exited successfully.
+	OnExit func(err error)
+}
+
+// Supervise runs the command described by cmd, whose first element is the
+// program to run and whose remaining elements are its arguments, restarting it
+// whenever it fails. Consecutive failures are spaced by increasing delays.
+//
+// When ctx is done, the child is asked to stop with SIGTERM, or killed on
+// Windows, and is killed if it has not exited after the stop timeout.
+//
+// Returns nil when the child exits successfully or ctx is done. Returns error
+// if the child cannot be started, or if it fails after the maximum number of
+// restarts.
+func Supervise(ctx context.Context, cmd []string, opts *SuperviseOptions) error {
+	if len(cmd) == 0 {
+		return errors.New(nil, "cannot supervise empty command")
+	}
+	var o SuperviseOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Minute
+	}
+	if o.MaxBackoff < o.MinBackoff {
+		o.MaxBackoff = o.MinBackoff
+	}
+	if o.ResetAfter <= 0 {
+		o.ResetAfter = o.MaxBackoff
+	}
+	if o.StopTimeout <= 0 {
+		o.StopTimeout = 10 * time.Second
+	}
+	if o.Stdout == nil {
+		o.Stdout = os.Stdout
+	}
+	if o.Stderr == nil {
+		o.Stderr = os.Stderr
+	}
+	delay := o.MinBackoff
+	for restarts := 0; ; restarts++ {
+		start := time.Now()
+		err := runChild(ctx, cmd, &o)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if o.OnExit != nil {
+			o.OnExit(err)
+		}
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(*exec.ExitError); !ok {
+			return errors.New(err, "cannot supervise %s", cmd[0])
+		}
+		if o.MaxRestarts > 0 && restarts >= o.MaxRestarts {
+			return errors.New(err, "%s failed after %d restarts", cmd[0], restarts)
+		}
+		if time.Since(start) >= o.ResetAfter {
+			delay = o.MinBackoff
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+		delay *= 2
+		if delay > o.MaxBackoff {
+			delay = o.MaxBackoff
+		}
+	}
+}
+
+func runChild(ctx context.Context, cmd []string, o *SuperviseOptions) error {
+	c := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	c.Dir = o.Dir
+	c.Stdout = o.Stdout
+	c.Stderr = o.Stderr
+	if len(o.Env) > 0 {
+		c.Env = append(os.Environ(), o.Env...)
+	}
+	c.Cancel = func() error {
+		return c.Process.Signal(stopSignal)
+	}
+	c.WaitDelay = o.StopTimeout
+	return c.Run()
+}
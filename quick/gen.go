@@ -0,0 +1,240 @@
+package quick
+
+import (
+	"math"
+	"math/rand"
+	"unicode/utf8"
+)
+
+// Bool returns a generator of booleans, which shrink to false.
+func Bool() Gen[bool] {
+	return Gen[bool]{
+		Generate: func(r *rand.Rand, size int) bool {
+			return r.Intn(2) == 1
+		},
+		Shrink: func(v bool) []bool {
+			if v {
+				return []bool{false}
+			}
+			return nil
+		},
+	}
+}
+
+// Float returns a generator of floating-point numbers in the interval
+// [lo, hi), which shrink towards the number in the interval nearest zero. If
+// lo is greater than hi, the two are swapped.
+func Float(lo, hi float64) Gen[float64] {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	target := math.Max(lo, math.Min(hi, 0))
+	return Gen[float64]{
+		Generate: func(r *rand.Rand, size int) float64 {
+			return lo + r.Float64()*(hi-lo)
+		},
+		Shrink: func(v float64) []float64 {
+			return shrinkFloat(v, target)
+		},
+	}
+}
+
+// Int returns a generator of integers in the interval [lo, hi], which shrink
+// towards the integer in the interval nearest zero. If lo is greater than hi,
+// the two are swapped.
+func Int(lo, hi int) Gen[int] {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	target := lo
+	if lo < 0 && hi >= 0 {
+		target = 0
+	} else if hi < 0 {
+		target = hi
+	}
+	return Gen[int]{
+		Generate: func(r *rand.Rand, size int) int {
+			span := uint64(int64(hi) - int64(lo))
+			if span < math.MaxInt64 {
+				return int(int64(lo) + r.Int63n(int64(span)+1))
+			}
+			// The span exceeds the range of Int63n, and is at least half
+			// the range of Uint64, so that few values are rejected.
+			v := r.Uint64()
+			for v > span {
+				v = r.Uint64()
+			}
+			return int(int64(lo) + int64(v))
+		},
+		Shrink: func(v int) []int {
+			var s []int
+			for _, c := range shrinkInt(int64(v), int64(target)) {
+				s = append(s, int(c))
+			}
+			return s
+		},
+	}
+}
+
+// Map returns a generator of the values of g transformed by f. The values
+// generated cannot be shrunk.
+func Map[T, U any](g Gen[T], f func(T) U) Gen[U] {
+	return Gen[U]{
+		Generate: func(r *rand.Rand, size int) U {
+			return f(g.Generate(r, size))
+		},
+	}
+}
+
+// OneOf returns a generator choosing uniformly between vals. The values
+// generated cannot be shrunk. If vals is empty, the generator returns the zero
+// value.
+func OneOf[T any](vals ...T) Gen[T] {
+	index := Int(0, len(vals)-1)
+	return Gen[T]{
+		Generate: func(r *rand.Rand, size int) T {
+			var zero T
+			if len(vals) == 0 {
+				return zero
+			}
+			return vals[index.Generate(r, size)]
+		},
+	}
+}
+
+// Slice returns a generator of slices of up to size elements generated by
+// elem. Slices shrink by removing elements, and then by shrinking elements.
+func Slice[T any](elem Gen[T]) Gen[[]T] {
+	return Gen[[]T]{
+		Generate: func(r *rand.Rand, size int) []T {
+			s := make([]T, r.Intn(size+1))
+			for i := range s {
+				s[i] = elem.Generate(r, size)
+			}
+			return s
+		},
+		Shrink: func(v []T) [][]T {
+			return shrinkSlice(v, elem.Shrink)
+		},
+	}
+}
+
+// String returns a generator of strings of up to size characters, mostly
+// printable ASCII with occasional other Unicode characters. Strings shrink by
+// removing characters, and then by replacing characters with 'a'.
+func String() Gen[string] {
+	return Gen[string]{
+		Generate: func(r *rand.Rand, size int) string {
+			s := make([]rune, r.Intn(size+1))
+			for i := range s {
+				s[i] = randRune(r)
+			}
+			return string(s)
+		},
+		Shrink: shrinkString,
+	}
+}
+
+// StringOf returns a generator of strings of up to size characters chosen
+// from chars. Strings shrink by removing characters, and then by replacing
+// characters with the first character of chars.
+func StringOf(chars string) Gen[string] {
+	runes := []rune(chars)
+	return Gen[string]{
+		Generate: func(r *rand.Rand, size int) string {
+			if len(runes) == 0 {
+				return ""
+			}
+			s := make([]rune, r.Intn(size+1))
+			for i := range s {
+				s[i] = runes[r.Intn(len(runes))]
+			}
+			return string(s)
+		},
+		Shrink: func(v string) []string {
+			if len(runes) == 0 {
+				return nil
+			}
+			return shrinkRunes(v, runes[0])
+		},
+	}
+}
+
+func randRune(r *rand.Rand) rune {
+	if r.Intn(10) > 0 {
+		return rune(' ' + r.Intn('~'-' '+1))
+	}
+	for {
+		c := rune(r.Intn(0x10000))
+		if utf8.ValidRune(c) && c >= ' ' {
+			return c
+		}
+	}
+}
+
+func shrinkFloat(v, target float64) []float64 {
+	if v == target || math.IsNaN(v) {
+		return nil
+	}
+	s := []float64{target}
+	if t := math.Trunc(v); t != v && t != target {
+		s = append(s, t)
+	}
+	for i, d := 0, (v-target)/2; i < 16 && d != 0; i, d = i+1, d/2 {
+		if c := v - d; c != v {
+			s = append(s, c)
+		}
+	}
+	return s
+}
+
+func shrinkInt(v, target int64) []int64 {
+	var s []int64
+	for d := v - target; d != 0; d /= 2 {
+		s = append(s, v-d)
+	}
+	return s
+}
+
+func shrinkRunes(v string, min rune) []string {
+	runes := []rune(v)
+	var s []string
+	for _, c := range shrinkSlice(runes, nil) {
+		s = append(s, string(c))
+	}
+	for i, c := range runes {
+		if c != min {
+			t := append([]rune(nil), runes...)
+			t[i] = min
+			s = append(s, string(t))
+		}
+	}
+	return s
+}
+
+func shrinkSlice[T any](v []T, shrink func(T) []T) [][]T {
+	var s [][]T
+	for n := len(v); n > 0; n /= 2 {
+		for i := 0; i+n <= len(v); i += n {
+			t := make([]T, 0, len(v)-n)
+			t = append(t, v[:i]...)
+			t = append(t, v[i+n:]...)
+			s = append(s, t)
+		}
+	}
+	if shrink == nil {
+		return s
+	}
+	for i, e := range v {
+		for _, c := range shrink(e) {
+			t := append([]T(nil), v...)
+			t[i] = c
+			s = append(s, t)
+		}
+	}
+	return s
+}
+
+func shrinkString(v string) []string {
+	return shrinkRunes(v, 'a')
+}
@@ -0,0 +1,136 @@
+// Package quick implements property-based testing.
+//
+// A property is a function reporting whether some statement holds for a given
+// input. Check calls a property with many generated inputs, and when it finds
+// one for which the property fails, shrinks that input to a minimal
+// counterexample before reporting it:
+//
+//	func TestReverse(t *testing.T) {
+//		quick.Check(t, func(s []int) bool {
+//			return reflect.DeepEqual(reverse(reverse(s)), s)
+//		})
+//	}
+//
+// Inputs are produced by generators. Check derives a generator from the type
+// of the property's input using Any, which supports booleans, numbers,
+// strings, and pointers, slices, arrays, maps and structs of these. A property
+// of several inputs can take them as the fields of a struct. Generators for
+// particular ranges of values are built with functions such as Int, String,
+// and Slice, and passed to CheckGen:
+//
+//	quick.CheckGen(t, quick.Slice(quick.Int(1, 6)), func(rolls []int) bool {
+//		return sum(rolls) <= 6*len(rolls)
+//	}, nil)
+//
+// A property also fails if it panics. Every failure reports the seed used,
+// which can be set in a Config to reproduce it.
+package quick
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// Config controls the checking of a property. A nil *Config is equivalent to
+// a zero Config, and the zero value of each field selects its default.
+type Config struct {
+	// Runs is the number of inputs to try. The default is 100.
+	Runs int
+	// MaxSize is the size of the last inputs generated, with sizes growing
+	// steadily from one. The default is 100.
+	MaxSize int
+	// MaxShrinks limits the steps taken to shrink a failing input. The
+	// default is 1000.
+	MaxShrinks int
+	// Seed seeds the source of randomness. The default is a seed derived from
+	// the current time.
+	Seed int64
+}
+
+// Gen represents a generator of values of type T.
+type Gen[T any] struct {
+	// Generate returns a random value. Size is a hint of how large the value
+	// should be, such as the length of a slice or the magnitude of a number.
+	Generate func(r *rand.Rand, size int) T
+	// Shrink returns smaller candidates for v, from most to least
+	// aggressively shrunk. It may be nil if values cannot be shrunk.
+	Shrink func(v T) []T
+}
+
+// Check checks the property prop using inputs generated by Any[T], and reports
+// a failure with t.Errorf if a counterexample is found. Returns whether the
+// property held for every input.
+func Check[T any](t testing.TB, prop func(T) bool) bool {
+	t.Helper()
+	return CheckGen(t, Any[T](), prop, nil)
+}
+
+// CheckGen checks the property prop using inputs generated by gen, configured
+// by cfg, and reports a failure with t.Errorf if a counterexample is found.
+// Returns whether the property held for every input.
+func CheckGen[T any](t testing.TB, gen Gen[T], prop func(T) bool, cfg *Config) bool {
+	t.Helper()
+	var c Config
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.Runs <= 0 {
+		c.Runs = 100
+	}
+	if c.MaxSize <= 0 {
+		c.MaxSize = 100
+	}
+	if c.MaxShrinks <= 0 {
+		c.MaxShrinks = 1000
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	r := rand.New(rand.NewSource(c.Seed))
+	for i := 0; i < c.Runs; i++ {
+		size := 1 + i*(c.MaxSize-1)/c.Runs
+		v := gen.Generate(r, size)
+		ok, msg := holds(prop, v)
+		if ok {
+			continue
+		}
+		orig := v
+		steps := 0
+		if gen.Shrink != nil {
+		shrink:
+			for steps < c.MaxShrinks {
+				for _, s := range gen.Shrink(v) {
+					if ok, m := holds(prop, s); !ok {
+						v, msg = s, m
+						steps++
+						continue shrink
+					}
+				}
+				break
+			}
+		}
+		text := fmt.Sprintf("property failed on run %d (seed %d)\ncounterexample: %#v", i+1, c.Seed, v)
+		if steps > 0 {
+			text += fmt.Sprintf("\nshrunk in %d steps from: %#v", steps, orig)
+		}
+		if msg != "" {
+			text += "\n" + msg
+		}
+		t.Errorf("%s", text)
+		return false
+	}
+	return true
+}
+
+// holds reports whether prop holds for v, treating a panic as a failure
+// described by msg.
+func holds[T any](prop func(T) bool, v T) (ok bool, msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok, msg = false, fmt.Sprintf("panic: %v", r)
+		}
+	}()
+	return prop(v), ""
+}
@@ -0,0 +1,203 @@
+package quick
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+)
+
+// Any returns a generator of values of type T, derived from its type. Numbers
+// have magnitudes up to size, and strings, slices and maps have up to size
+// elements. Pointers are occasionally nil. Only the exported fields of structs
+// are generated. Values of other types, such as functions and channels, are
+// always the zero value.
+//
+// Values shrink towards zero: numbers towards zero, strings, slices and maps by
+// removing elements, pointers to nil, and structs and arrays by shrinking each
+// of their fields or elements in turn.
+func Any[T any]() Gen[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	return Gen[T]{
+		Generate: func(r *rand.Rand, size int) T {
+			v := reflect.New(t).Elem()
+			generate(r, v, size)
+			x, _ := v.Interface().(T) // nil, if T is an interface type
+			return x
+		},
+		Shrink: func(v T) []T {
+			var s []T
+			for _, c := range shrink(reflect.ValueOf(&v).Elem()) {
+				x, _ := c.Interface().(T)
+				s = append(s, x)
+			}
+			return s
+		},
+	}
+}
+
+// generate sets v, which must be settable, to a random value of its type.
+func generate(r *rand.Rand, v reflect.Value, size int) {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := v.Type().Bits()
+		n := int64(r.Intn(2*size+1) - size)
+		max := int64(1)<<(bits-1) - 1
+		if n > max {
+			n = max
+		} else if n < -max-1 {
+			n = -max - 1
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := uint64(r.Intn(size + 1))
+		if max := uint64(1)<<v.Type().Bits() - 1; v.Type().Bits() < 64 && n > max {
+			n = max
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f := r.NormFloat64() * float64(size)
+		if v.Kind() == reflect.Float32 {
+			f = math.Max(-math.MaxFloat32, math.Min(math.MaxFloat32, f))
+		}
+		v.SetFloat(f)
+	case reflect.Complex64, reflect.Complex128:
+		v.SetComplex(complex(r.NormFloat64()*float64(size), r.NormFloat64()*float64(size)))
+	case reflect.String:
+		v.SetString(String().Generate(r, size))
+	case reflect.Pointer:
+		if r.Intn(10) == 0 {
+			return
+		}
+		p := reflect.New(v.Type().Elem())
+		generate(r, p.Elem(), size)
+		v.Set(p)
+	case reflect.Slice:
+		n := r.Intn(size + 1)
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			generate(r, s.Index(i), size)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			generate(r, v.Index(i), size)
+		}
+	case reflect.Map:
+		n := r.Intn(size + 1)
+		m := reflect.MakeMapWithSize(v.Type(), n)
+		for i := 0; i < n; i++ {
+			k := reflect.New(v.Type().Key()).Elem()
+			e := reflect.New(v.Type().Elem()).Elem()
+			generate(r, k, size)
+			generate(r, e, size)
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				generate(r, v.Field(i), size)
+			}
+		}
+	}
+}
+
+// shrink returns smaller candidates for v.
+func shrink(v reflect.Value) []reflect.Value {
+	t := v.Type()
+	var s []reflect.Value
+	add := func(f func(c reflect.Value)) {
+		c := reflect.New(t).Elem()
+		c.Set(v)
+		f(c)
+		s = append(s, c)
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			add(func(c reflect.Value) { c.SetBool(false) })
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		for _, n := range shrinkInt(v.Int(), 0) {
+			n := n
+			add(func(c reflect.Value) { c.SetInt(n) })
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		for d := v.Uint(); d != 0; d /= 2 {
+			n := v.Uint() - d
+			add(func(c reflect.Value) { c.SetUint(n) })
+		}
+	case reflect.Float32, reflect.Float64:
+		for _, f := range shrinkFloat(v.Float(), 0) {
+			f := f
+			add(func(c reflect.Value) { c.SetFloat(f) })
+		}
+	case reflect.String:
+		for _, str := range shrinkString(v.String()) {
+			str := str
+			add(func(c reflect.Value) { c.SetString(str) })
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			break
+		}
+		add(func(c reflect.Value) { c.Set(reflect.Zero(t)) })
+		for _, e := range shrink(v.Elem()) {
+			e := e
+			add(func(c reflect.Value) {
+				p := reflect.New(t.Elem())
+				p.Elem().Set(e)
+				c.Set(p)
+			})
+		}
+	case reflect.Slice:
+		elems := make([]reflect.Value, v.Len())
+		for i := range elems {
+			elems[i] = v.Index(i)
+		}
+		for _, es := range shrinkSlice(elems, shrink) {
+			es := es
+			add(func(c reflect.Value) {
+				sl := reflect.MakeSlice(t, len(es), len(es))
+				for i, e := range es {
+					sl.Index(i).Set(e)
+				}
+				c.Set(sl)
+			})
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			for _, e := range shrink(v.Index(i)) {
+				i, e := i, e
+				add(func(c reflect.Value) { c.Index(i).Set(e) })
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		for i := range keys {
+			i := i
+			add(func(c reflect.Value) {
+				m := reflect.MakeMapWithSize(t, len(keys)-1)
+				for j, k := range keys {
+					if j != i {
+						m.SetMapIndex(k, v.MapIndex(k))
+					}
+				}
+				c.Set(m)
+			})
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			for _, f := range shrink(v.Field(i)) {
+				i, f := i, f
+				add(func(c reflect.Value) { c.Field(i).Set(f) })
+			}
+		}
+	}
+	return s
+}
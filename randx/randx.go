@@ -0,0 +1,175 @@
+// Package randx implements the generation of random identifiers, tokens and
+// numbers from a cryptographically secure source.
+//
+// The package-level functions draw from crypto/rand, and are suitable for
+// secrets such as session tokens and password reset codes:
+//
+//	token, err := randx.Token(32)
+//	code, err := randx.String(6, randx.Digits)
+//	n, err := randx.Int(1, 7)
+//
+// Tests which need reproducible values can draw from a seeded source, which
+// must never be used for secrets:
+//
+//	r := randx.NewSeeded(1)
+//	id, err := r.String(8, randx.Alphanumeric)
+package randx
+
+import (
+	crand "crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"sync"
+	"unicode/utf8"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Alphabets for use with String.
+const (
+	Alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	Digits       = "0123456789"
+	Hex          = "0123456789abcdef"
+	Lower        = "abcdefghijklmnopqrstuvwxyz"
+	Upper        = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// Unambiguous omits characters easily mistaken for one another, such as
+	// 0 and O or 1 and l, for codes which people read and type.
+	Unambiguous = "23456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz"
+)
+
+// Default is the secure source used by the package-level functions.
+var Default = New(crand.Reader)
+
+// Rand generates random values from a source of random bytes. A Rand is safe
+// for concurrent use.
+type Rand struct {
+	mu sync.Mutex
+	r  io.Reader
+}
+
+// New returns a Rand drawing from r.
+func New(r io.Reader) *Rand {
+	return &Rand{r: r}
+}
+
+// NewSeeded returns a Rand which generates the same values for the same seed.
+// Its values are predictable, so it must only be used where reproducibility
+// matters more than security, such as in tests.
+func NewSeeded(seed int64) *Rand {
+	return New(rand.New(rand.NewSource(seed)))
+}
+
+// Bytes returns n random bytes. Returns error if the source fails.
+func (r *Rand) Bytes(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, errors.New(nil, "invalid length %d", n)
+	}
+	b := make([]byte, n)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return nil, errors.New(err, "cannot read random bytes")
+	}
+	return b, nil
+}
+
+// Int returns a uniformly distributed random integer in the half-open range
+// [lo, hi). Returns error if hi is not greater than lo, or if the source
+// fails.
+func (r *Rand) Int(lo, hi int64) (int64, error) {
+	if hi <= lo {
+		return 0, errors.New(nil, "invalid range [%d, %d)", lo, hi)
+	}
+	n, err := r.uniform(uint64(hi - lo))
+	if err != nil {
+		return 0, err
+	}
+	return lo + int64(n), nil
+}
+
+// String returns a string of n characters drawn uniformly from alphabet.
+// Characters repeated in alphabet are proportionally more likely. Returns
+// error if alphabet is empty or not valid UTF-8, or if the source fails.
+func (r *Rand) String(n int, alphabet string) (string, error) {
+	if n < 0 {
+		return "", errors.New(nil, "invalid length %d", n)
+	}
+	if alphabet == "" || !utf8.ValidString(alphabet) {
+		return "", errors.New(nil, "invalid alphabet %q", alphabet)
+	}
+	chars := []rune(alphabet)
+	out := make([]rune, n)
+	for i := range out {
+		j, err := r.uniform(uint64(len(chars)))
+		if err != nil {
+			return "", err
+		}
+		out[i] = chars[j]
+	}
+	return string(out), nil
+}
+
+// Token returns n random bytes encoded as unpadded URL-safe base64, suitable
+// for use in URLs, cookies and file names. A token of 16 or more bytes is
+// infeasible to guess. Returns error if the source fails.
+func (r *Rand) Token(n int) (string, error) {
+	b, err := r.Bytes(n)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Uint64 returns a random 64-bit integer. Returns error if the source fails.
+func (r *Rand) Uint64() (uint64, error) {
+	b, err := r.Bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// uniform returns a uniformly distributed random integer in [0, n), which must
+// be positive. Values which would make some results more likely than others
+// are rejected and redrawn.
+func (r *Rand) uniform(n uint64) (uint64, error) {
+	// 2^64 mod n values must be rejected for the rest to divide evenly.
+	threshold := -n % n
+	for {
+		v, err := r.Uint64()
+		if err != nil {
+			return 0, err
+		}
+		if v >= threshold {
+			return v % n, nil
+		}
+	}
+}
+
+// Bytes returns n random bytes from Default.
+func Bytes(n int) ([]byte, error) {
+	return Default.Bytes(n)
+}
+
+// Int returns a uniformly distributed random integer in [lo, hi) from Default.
+func Int(lo, hi int64) (int64, error) {
+	return Default.Int(lo, hi)
+}
+
+// String returns a string of n characters drawn uniformly from alphabet by
+// Default.
+func String(n int, alphabet string) (string, error) {
+	return Default.String(n, alphabet)
+}
+
+// Token returns a URL-safe token of n random bytes from Default.
+func Token(n int) (string, error) {
+	return Default.Token(n)
+}
+
+// Uint64 returns a random 64-bit integer from Default.
+func Uint64() (uint64, error) {
+	return Default.Uint64()
+}
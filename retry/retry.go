@@ -0,0 +1,148 @@
+// Package retry implements the retrying of operations which may fail
+// transiently.
+//
+// Do calls a function until it succeeds, waiting between attempts with
+// exponential backoff:
+//
+//	err := retry.Do(ctx, func(ctx context.Context) error {
+//		return upload(ctx, file)
+//	},
+//		retry.Attempts(5),
+//		retry.Backoff(200*time.Millisecond, 10*time.Second),
+//		retry.If(errors.IsTemporary),
+//		retry.Timeout(30*time.Second),
+//	)
+//
+// Without options, Do makes up to 3 attempts, waiting 100ms after the first
+// and doubling the delay after each further attempt, up to 10s, with 20%
+// jitter. Every error is retried unless a predicate is given with If.
+//
+// When every attempt fails, Do returns an error recording the failure of each
+// attempt.
+package retry
+
+import (
+	"context"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/backoff"
+)
+
+// Option configures Do.
+type Option func(*config)
+
+type config struct {
+	attempts   int
+	initial    time.Duration
+	max        time.Duration
+	multiplier float64
+	jitter     float64
+	retryIf    func(error) bool
+	timeout    time.Duration
+}
+
+// Attempts sets the maximum number of attempts made, including the first. If
+// n is less than one, Do attempts until it succeeds or its context is done.
+func Attempts(n int) Option {
+	return func(c *config) {
+		c.attempts = n
+	}
+}
+
+// Backoff sets the delay after the first failed attempt to initial, and the
+// longest delay between attempts to max.
+func Backoff(initial, max time.Duration) Option {
+	return func(c *config) {
+		c.initial = initial
+		c.max = max
+	}
+}
+
+// If sets a predicate deciding whether the error of an attempt should be
+// retried. When it returns false, Do returns without further attempts.
+func If(retryIf func(err error) bool) Option {
+	return func(c *config) {
+		c.retryIf = retryIf
+	}
+}
+
+// Jitter randomises each delay by up to the given fraction of it, in either
+// direction, so that many clients failing together do not retry in step. A
+// fraction of zero disables jitter.
+func Jitter(fraction float64) Option {
+	return func(c *config) {
+		c.jitter = fraction
+	}
+}
+
+// Multiplier sets the factor by which the delay grows after each failed
+// attempt.
+func Multiplier(f float64) Option {
+	return func(c *config) {
+		c.multiplier = f
+	}
+}
+
+// Timeout limits the duration of each attempt. The context passed to the
+// function is cancelled when the attempt exceeds d.
+func Timeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+// Do calls f until it returns nil, the maximum number of attempts is reached,
+// the error returned is not to be retried, or ctx is done. Returns nil if an
+// attempt succeeded, and otherwise an error whose text records the number of
+// attempts made, such as "failed after 3 attempts", and whose parent is the
+// error of the only attempt, or else joins the errors of every attempt, in
+// order. If Do was stopped by ctx, the error of ctx is joined last. The origin
+// of the error is the caller of Do.
+func Do(ctx context.Context, f func(ctx context.Context) error, opts ...Option) error {
+	c := config{
+		attempts:   3,
+		initial:    100 * time.Millisecond,
+		max:        10 * time.Second,
+		multiplier: 2,
+		jitter:     0.2,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	errs, n := backoff.Do(ctx, backoff.Config{
+		Attempts:   c.attempts,
+		Initial:    c.initial,
+		Max:        c.max,
+		Multiplier: c.multiplier,
+		Jitter:     c.jitter,
+		RetryIf:    c.retryIf,
+	}, func() error {
+		return try(ctx, f, c.timeout)
+	})
+	if errs == nil {
+		return nil
+	}
+	return failed(errs, n)
+}
+
+// failed returns the error of Do after n attempts failed with errs.
+func failed(errs []error, n int) error {
+	parent := errs[0]
+	if len(errs) > 1 {
+		parent = errors.Join(errs...)
+	}
+	if n == 1 {
+		return errors.NewSkip(parent, 2, "failed after 1 attempt")
+	}
+	return errors.NewSkip(parent, 2, "failed after %d attempts", n)
+}
+
+func try(ctx context.Context, f func(ctx context.Context) error, timeout time.Duration) error {
+	if timeout <= 0 {
+		return f(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return f(ctx)
+}
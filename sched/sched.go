@@ -0,0 +1,298 @@
+// Package sched implements an in-process scheduler for recurring and delayed
+// jobs.
+//
+// A Scheduler runs jobs on schedules given by fixed intervals, cron
+// expressions or single times, until its context is done:
+//
+//	s := sched.New(nil)
+//	s.Add("cleanup", sched.Every(10*time.Minute), cleanup, nil)
+//	nightly, err := sched.ParseCron("30 2 * * *")
+//	if err != nil {
+//		return err
+//	}
+//	s.Add("report", nightly, report, &sched.JobOptions{
+//		Overlap: sched.Skip,
+//		Jitter:  time.Minute,
+//	})
+//	s.Add("warmup", sched.After(5*time.Second), warmup, nil)
+//	err = s.Run(ctx)
+//
+// Jobs which fail or panic are reported to the scheduler's error handler, and
+// continue to run on their schedules.
+package sched
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/log"
+)
+
+// Overlap is a policy for a job whose next run is due while a previous run is
+// still in progress.
+type Overlap int
+
+const (
+	// Skip skips the run which is due.
+	Skip Overlap = iota
+	// Queue delays the run which is due until the previous run finishes.
+	// At most one run is queued at a time.
+	Queue
+	// Allow starts the run which is due alongside the previous run.
+	Allow
+)
+
+// Options configures a Scheduler. A nil *Options is equivalent to a zero
+// Options.
+type Options struct {
+	// OnError is called with the name of a job and the error it returned or
+	// the panic it raised. If nil, errors are logged to log.Default.
+	OnError func(name string, err error)
+}
+
+// JobOptions configures a job. A nil *JobOptions is equivalent to a zero
+// JobOptions, which skips overlapping runs and adds no jitter.
+type JobOptions struct {
+	// Overlap determines what happens when a run is due while a previous
+	// run is in progress.
+	Overlap Overlap
+
+	// Jitter delays each run by a random duration of up to Jitter, so that
+	// jobs scheduled for the same time, possibly in many processes, do not
+	// all run at once.
+	Jitter time.Duration
+
+	// Timeout, if positive, limits the duration of each run. The context
+	// passed to the job is cancelled when a run exceeds Timeout.
+	Timeout time.Duration
+}
+
+// Scheduler runs jobs on schedules. A Scheduler must be created with New, and
+// is safe for concurrent use.
+type Scheduler struct {
+	onError func(name string, err error)
+	mu      sync.Mutex
+	jobs    map[string]*job
+	wake    chan struct{}
+	running bool
+	wg      sync.WaitGroup
+}
+
+type job struct {
+	name  string
+	sch   Schedule
+	fn    func(ctx context.Context) error
+	opts  JobOptions
+	base  time.Time // the time the next run is scheduled for, before jitter
+	next  time.Time // the time the next run starts
+	runs  int       // the number of runs in progress
+	queue bool      // whether a run is queued
+}
+
+// New returns a scheduler with no jobs.
+func New(opts *Options) *Scheduler {
+	s := &Scheduler{
+		jobs: make(map[string]*job),
+		wake: make(chan struct{}, 1),
+	}
+	if opts != nil {
+		s.onError = opts.OnError
+	}
+	return s
+}
+
+// Add adds a job with the given name, running f on schedule sch. A job may be
+// added before or while the scheduler runs. Returns error if a job with the
+// same name exists, or if sch has no future runs.
+func (s *Scheduler) Add(name string, sch Schedule, f func(ctx context.Context) error, opts *JobOptions) error {
+	j := &job{name: name, sch: sch, fn: f}
+	if opts != nil {
+		j.opts = *opts
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return errors.New(nil, "cannot add job %s: already exists", name)
+	}
+	if !j.schedule(time.Now()) {
+		return errors.New(nil, "cannot add job %s: schedule has no future runs", name)
+	}
+	s.jobs[name] = j
+	s.notify()
+	return nil
+}
+
+// Next returns the time at which the job with the given name next runs.
+// Returns error if no such job exists.
+func (s *Scheduler) Next(name string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[name]
+	if !ok {
+		return time.Time{}, errors.New(nil, "no such job %s", name)
+	}
+	return j.next, nil
+}
+
+// Remove removes the job with the given name, so that it does not run again.
+// A run in progress is not stopped. Returns error if no such job exists.
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; !ok {
+		return errors.New(nil, "cannot remove job %s: no such job", name)
+	}
+	delete(s.jobs, name)
+	s.notify()
+	return nil
+}
+
+// Run runs jobs as they fall due, until ctx is done. Each run receives a
+// context derived from ctx. When ctx is done, no further runs start, and Run
+// waits for runs in progress to return before returning nil. Returns error if
+// the scheduler is already running.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return errors.New(nil, "scheduler is already running")
+	}
+	s.running = true
+	s.mu.Unlock()
+	defer func() {
+		s.wg.Wait()
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		now := time.Now()
+		s.mu.Lock()
+		var first time.Time
+		for name, j := range s.jobs {
+			if !j.next.After(now) {
+				s.start(ctx, j)
+				if !j.schedule(now) {
+					delete(s.jobs, name)
+					continue
+				}
+			}
+			if first.IsZero() || j.next.Before(first) {
+				first = j.next
+			}
+		}
+		s.mu.Unlock()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		var fire <-chan time.Time
+		if !first.IsZero() {
+			timer.Reset(time.Until(first))
+			fire = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-fire:
+		case <-s.wake:
+		}
+	}
+}
+
+// notify wakes Run to reconsider the next run. The caller must hold s.mu.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// start starts a run of j, subject to its overlap policy. The caller must hold
+// s.mu.
+func (s *Scheduler) start(ctx context.Context, j *job) {
+	if j.runs > 0 {
+		switch j.opts.Overlap {
+		case Skip:
+			return
+		case Queue:
+			j.queue = true
+			return
+		}
+	}
+	j.runs++
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			if err := s.call(ctx, j); err != nil {
+				s.report(j.name, err)
+			}
+			s.mu.Lock()
+			if !j.queue || ctx.Err() != nil {
+				j.runs--
+				j.queue = false
+				s.mu.Unlock()
+				return
+			}
+			j.queue = false
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// call runs j once, converting a panic into an error.
+func (s *Scheduler) call(ctx context.Context, j *job) (err error) {
+	if j.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.opts.Timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(nil, "job %s panicked: %v", j.name, r)
+		}
+	}()
+	if err := j.fn(ctx); err != nil {
+		return errors.New(err, "job %s failed", j.name)
+	}
+	return nil
+}
+
+func (s *Scheduler) report(name string, err error) {
+	if s.onError != nil {
+		s.onError(name, err)
+		return
+	}
+	log.Error("scheduled job failed", "job", name, "err", err)
+}
+
+// schedule sets the time of the next run of j after now, and reports whether
+// there is one.
+func (j *job) schedule(now time.Time) bool {
+	base := time.Time{}
+	if !j.base.IsZero() {
+		base = j.sch.Next(j.base)
+	}
+	if base.IsZero() || !base.After(now) {
+		// The job is new, or runs were missed, such as while the system
+		// was suspended; missed runs are not made up.
+		base = j.sch.Next(now)
+	}
+	if base.IsZero() {
+		return false
+	}
+	j.base = base
+	j.next = base
+	if j.opts.Jitter > 0 {
+		j.next = base.Add(time.Duration(rand.Int63n(int64(j.opts.Jitter))))
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package sched
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Schedule describes when a job runs.
+type Schedule interface {
+	// Next returns the first time after t at which the job runs, or the
+	// zero time if the job never runs again.
+	Next(t time.Time) time.Time
+}
+
+// After returns a schedule running a job once, d after After is called.
+func After(d time.Duration) Schedule {
+	return At(time.Now().Add(d))
+}
+
+// At returns a schedule running a job once, at t.
+func At(t time.Time) Schedule {
+	return at(t)
+}
+
+// Every returns a schedule running a job repeatedly, every d, beginning d
+// after the job is added. If d is zero or negative, the job never runs.
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+type at time.Time
+
+func (a at) Next(t time.Time) time.Time {
+	if t.Before(time.Time(a)) {
+		return time.Time(a)
+	}
+	return time.Time{}
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	if e <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(e))
+}
+
+// cron is a schedule described by a cron expression. Each field is a bit set
+// of the values matched.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record whether the day fields are unrestricted,
+	// which determines how they combine.
+	domStar, dowStar bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseCron parses a cron expression of five fields separated by spaces:
+// minute (0-59), hour (0-23), day of month (1-31), month (1-12 or JAN-DEC) and
+// day of week (0-7 or SUN-SAT, where both 0 and 7 are Sunday). Each field is
+// "*", a value, a range "a-b", or a comma-separated list of these, and values
+// and ranges may be followed by a step "/n". As in cron, if both day fields
+// are restricted, a day matching either is matched.
+//
+// The macros @yearly, @annually, @monthly, @weekly, @daily, @midnight and
+// @hourly are also accepted. Times are matched in the location of the time
+// given to Next.
+//
+// Returns error if expr is not a valid cron expression.
+func ParseCron(expr string) (Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if m, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.New(nil, "invalid cron expression %q: expected 5 fields", expr)
+	}
+	var c cron
+	var err error
+	for i, f := range []struct {
+		set    *uint64
+		lo, hi int
+		names  map[string]int
+		name   string
+	}{
+		{&c.minute, 0, 59, nil, "minute"},
+		{&c.hour, 0, 23, nil, "hour"},
+		{&c.dom, 1, 31, nil, "day of month"},
+		{&c.month, 1, 12, monthNames, "month"},
+		{&c.dow, 0, 7, dayNames, "day of week"},
+	} {
+		*f.set, err = parseField(fields[i], f.lo, f.hi, f.names)
+		if err != nil {
+			return nil, errors.New(err, "invalid %s in cron expression %q", f.name, expr)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = fields[2][0] == '*'
+	c.dowStar = fields[4][0] == '*'
+	return &c, nil
+}
+
+func (c *cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Every combination of fields recurs within a few years, so a schedule
+	// which has not matched after five years, such as one for February 30,
+	// never matches.
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			if !next.After(t) {
+				// The hour is repeated or skipped by a change in daylight
+				// saving time.
+				next = t.Add(time.Hour).Truncate(time.Hour)
+			}
+			t = next
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseField parses a field of a cron expression into a bit set of the values
+// it matches, which lie between lo and hi.
+func parseField(field string, lo, hi int, names map[string]int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step <= 0 {
+				return 0, errors.New(nil, "invalid step %q", stepText)
+			}
+		}
+		start, end := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			start, err = parseValue(a, lo, hi, names)
+			if err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				end, err = parseValue(b, lo, hi, names)
+				if err != nil {
+					return 0, err
+				}
+				if end < start {
+					return 0, errors.New(nil, "invalid range %q", rng)
+				}
+			} else if hasStep {
+				end = hi
+			}
+		}
+		for v := start; v <= end; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, lo, hi int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.New(nil, "invalid value %q", s)
+	}
+	if v < lo || v > hi {
+		return 0, errors.New(nil, "value %d out of range [%d, %d]", v, lo, hi)
+	}
+	return v, nil
+}
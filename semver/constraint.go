@@ -0,0 +1,268 @@
+package semver
+
+import (
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Constraint is a requirement on versions, such as ">=1.2 <2.0" or "^1.4".
+type Constraint struct {
+	text string
+	sets [][]comparator
+}
+
+// comparator compares versions with a version using op, which is one of "=",
+// "!=", "<", "<=", ">" and ">=".
+type comparator struct {
+	op string
+	v  Version
+}
+
+// ParseConstraint parses a constraint. A constraint is a list of alternatives
+// separated by "||", any of which may be satisfied. Each alternative is a list
+// of comparisons separated by spaces or commas, all of which must be satisfied.
+// A comparison is a version preceded by an operator:
+//
+//	=1.2.3, 1.2.3  exactly 1.2.3
+//	!=1.2.3        any version but 1.2.3
+//	>1.2.3         greater than 1.2.3, and similarly for >=, < and <=
+//	~1.2.3         at least 1.2.3, with the same major and minor numbers
+//	^1.2.3         at least 1.2.3, with the same major number, or with the
+//	               same minor number if the major number is 0, or the same
+//	               patch number if both are 0
+//
+// Versions in comparisons may be partial, such as "1.2", or end in a wildcard
+// "x", "X" or "*", such as "1.2.x", in which case the missing numbers match
+// any value: "1.2" matches any 1.2.z, "<=1.2" any version up to and including
+// 1.2.z, and "^0.2" any 0.2.z. A range "1.2 - 1.4" matches the versions from
+// 1.2.0 to any 1.4.z, inclusive, and "*" matches any version.
+//
+// Prerelease versions only satisfy an alternative which mentions a prerelease
+// of the same major, minor and patch numbers, so that ">=1.2.3-beta" matches
+// 1.2.3-rc.1 but not 1.3.0-beta.
+//
+// Returns error if s is not a valid constraint.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{text: strings.TrimSpace(s)}
+	for _, alt := range strings.Split(s, "||") {
+		set, err := parseAlternative(alt)
+		if err != nil {
+			return nil, errors.New(err, "invalid constraint %q", s)
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+// Check reports whether v satisfies c.
+func (c *Constraint) Check(v Version) bool {
+	for _, set := range c.sets {
+		if matches(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the text from which c was parsed.
+func (c *Constraint) String() string {
+	return c.text
+}
+
+func matches(set []comparator, v Version) bool {
+	allowPre := v.Prerelease == ""
+	for _, cmp := range set {
+		if !cmp.check(v) {
+			return false
+		}
+		w := cmp.v
+		if w.Prerelease != "" && w.Major == v.Major && w.Minor == v.Minor && w.Patch == v.Patch {
+			allowPre = true
+		}
+	}
+	return allowPre
+}
+
+func (c comparator) check(v Version) bool {
+	n := Compare(v, c.v)
+	switch c.op {
+	case "=":
+		return n == 0
+	case "!=":
+		return n != 0
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	}
+	return false
+}
+
+func parseAlternative(s string) ([]comparator, error) {
+	fields := strings.Fields(strings.ReplaceAll(s, ",", " "))
+	if len(fields) == 0 {
+		return nil, errors.New(nil, "empty alternative")
+	}
+	if len(fields) == 3 && fields[1] == "-" {
+		lo, _, err := parsePartial(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		hi, n, err := parsePartial(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		set := []comparator{{">=", lo}}
+		return append(set, upTo(hi, n)...), nil
+	}
+	var set []comparator
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		// Allow whitespace between an operator and its version.
+		if strings.Trim(f, "=!<>^~") == "" && i+1 < len(fields) {
+			i++
+			f += fields[i]
+		}
+		cmps, err := parseComparison(f)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, cmps...)
+	}
+	return set, nil
+}
+
+// parseComparison parses a comparison into the primitive comparisons it
+// stands for.
+func parseComparison(s string) ([]comparator, error) {
+	op := ""
+	for _, o := range []string{"!=", ">=", "<=", "=", ">", "<", "^", "~"} {
+		if strings.HasPrefix(s, o) {
+			op = o
+			break
+		}
+	}
+	v, n, err := parsePartial(s[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 && op != "<" && op != ">" && op != "!=" {
+		// A wildcard matches any version.
+		return nil, nil
+	}
+	switch op {
+	case "", "=":
+		if n == 3 {
+			return []comparator{{"=", v}}, nil
+		}
+		return []comparator{{">=", v}, {"<", bump(v, n)}}, nil
+	case "!=":
+		if n < 3 {
+			return nil, errors.New(nil, "%q requires a full version", s)
+		}
+		return []comparator{{"!=", v}}, nil
+	case ">":
+		if n == 0 {
+			return []comparator{{"<", Version{}}}, nil
+		} else if n == 3 {
+			return []comparator{{">", v}}, nil
+		}
+		return []comparator{{">=", bump(v, n)}}, nil
+	case ">=":
+		return []comparator{{">=", v}}, nil
+	case "<":
+		if n == 0 {
+			return []comparator{{"<", Version{}}}, nil
+		}
+		return []comparator{{"<", v}}, nil
+	case "<=":
+		return upTo(v, n), nil
+	case "~":
+		if n == 3 {
+			n = 2
+		}
+		return []comparator{{">=", v}, {"<", bump(v, n)}}, nil
+	case "^":
+		switch {
+		case v.Major > 0 || n == 1:
+			n = 1
+		case v.Minor > 0 || n == 2:
+			n = 2
+		}
+		return []comparator{{">=", v}, {"<", bump(v, n)}}, nil
+	}
+	return nil, errors.New(nil, "invalid comparison %q", s)
+}
+
+// upTo returns the comparisons matching versions up to and including v, of
+// which n numbers are given.
+func upTo(v Version, n int) []comparator {
+	if n == 3 {
+		return []comparator{{"<=", v}}
+	}
+	if n == 0 {
+		return nil
+	}
+	return []comparator{{"<", bump(v, n)}}
+}
+
+// bump returns the lowest version greater than every version matching the
+// first n numbers of v.
+func bump(v Version, n int) Version {
+	switch n {
+	case 1:
+		return Version{Major: v.Major + 1}
+	case 2:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+// parsePartial parses a version of which only some numbers may be given, and
+// returns it with the missing numbers zero, along with the number of numbers
+// given.
+func parsePartial(s string) (Version, int, error) {
+	s = strings.TrimPrefix(s, "v")
+	if s == "" {
+		return Version{}, 0, errors.New(nil, "missing version")
+	}
+	core, _, _ := strings.Cut(s, "+")
+	core, _, _ = strings.Cut(core, "-")
+	parts := strings.Split(core, ".")
+	n := 0
+	for _, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			break
+		}
+		n++
+	}
+	if n == 3 {
+		v, err := parse(s)
+		if err != nil {
+			return Version{}, 0, errors.New(err, "invalid version %q", s)
+		}
+		return v, 3, nil
+	}
+	if len(parts) > 3 || core != s {
+		return Version{}, 0, errors.New(nil, "invalid version %q", s)
+	}
+	for _, p := range parts[n:] {
+		if p != "x" && p != "X" && p != "*" {
+			return Version{}, 0, errors.New(nil, "invalid version %q", s)
+		}
+	}
+	var v Version
+	for i, p := range []*uint64{&v.Major, &v.Minor, &v.Patch}[:n] {
+		num, err := parseNumber(parts[i])
+		if err != nil {
+			return Version{}, 0, errors.New(err, "invalid version %q", s)
+		}
+		*p = num
+	}
+	return v, n, nil
+}
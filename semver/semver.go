@@ -0,0 +1,220 @@
+// Package semver implements semantic versions, as described by Semantic
+// Versioning 2.0.0, and constraints on them.
+//
+// Versions are parsed, compared and sorted by precedence:
+//
+//	v, err := semver.Parse("v1.4.0-rc.1+build.5")
+//	if semver.Compare(v, current) > 0 {
+//		upgrade(v)
+//	}
+//
+// A Constraint matches versions against requirements such as those found in
+// package manifests:
+//
+//	c, err := semver.ParseConstraint(">=2.0 <3.0 || ^3.1")
+//	if c.Check(v) {
+//		...
+//	}
+package semver
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Version is a semantic version.
+type Version struct {
+	Major uint64
+	Minor uint64
+	Patch uint64
+	// Prerelease holds the dot-separated prerelease identifiers, such as
+	// "rc.1", or is empty for a release.
+	Prerelease string
+	// Build holds the dot-separated build metadata, such as "build.5",
+	// which does not affect precedence.
+	Build string
+}
+
+// Compare returns -1 if a has lower precedence than b, 0 if they have equal
+// precedence, and +1 if a has higher precedence than b. Versions are compared
+// by their major, minor and patch numbers, and then by their prerelease
+// identifiers, numeric identifiers comparing numerically and others
+// lexically; a prerelease has lower precedence than the release. Build
+// metadata is ignored.
+func Compare(a, b Version) int {
+	if c := compareUint(a.Major, b.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(a.Minor, b.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(a.Prerelease, b.Prerelease)
+}
+
+// Parse parses s as a semantic version of the form
+// MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD], optionally preceded by "v". Returns
+// error if s is not a valid semantic version.
+func Parse(s string) (Version, error) {
+	v, err := parse(strings.TrimPrefix(s, "v"))
+	if err != nil {
+		return Version{}, errors.New(err, "invalid version %q", s)
+	}
+	return v, nil
+}
+
+// Sort sorts versions in increasing order of precedence. Versions of equal
+// precedence keep their original order.
+func Sort(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return Compare(versions[i], versions[j]) < 0
+	})
+}
+
+// Less reports whether v has lower precedence than w.
+func (v Version) Less(w Version) bool {
+	return Compare(v, w) < 0
+}
+
+// MarshalText encodes v as by String.
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// String returns v in the form MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD], without
+// a leading "v".
+func (v Version) String() string {
+	s := strconv.FormatUint(v.Major, 10) + "." +
+		strconv.FormatUint(v.Minor, 10) + "." +
+		strconv.FormatUint(v.Patch, 10)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// UnmarshalText parses a version as by Parse.
+func (v *Version) UnmarshalText(text []byte) error {
+	p, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*v = p
+	return nil
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, xerr := strconv.ParseUint(as[i], 10, 64)
+		y, yerr := strconv.ParseUint(bs[i], 10, 64)
+		var c int
+		switch {
+		case xerr == nil && yerr == nil:
+			c = compareUint(x, y)
+		case xerr == nil:
+			c = -1
+		case yerr == nil:
+			c = 1
+		default:
+			c = strings.Compare(as[i], bs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(as)), uint64(len(bs)))
+}
+
+func parse(s string) (Version, error) {
+	var v Version
+	s, build, hasBuild := strings.Cut(s, "+")
+	if hasBuild {
+		if err := checkIdentifiers(build, false); err != nil {
+			return v, errors.New(err, "invalid build metadata")
+		}
+		v.Build = build
+	}
+	s, pre, hasPre := strings.Cut(s, "-")
+	if hasPre {
+		if err := checkIdentifiers(pre, true); err != nil {
+			return v, errors.New(err, "invalid prerelease")
+		}
+		v.Prerelease = pre
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, errors.New(nil, "expected MAJOR.MINOR.PATCH")
+	}
+	for i, p := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		n, err := parseNumber(parts[i])
+		if err != nil {
+			return v, err
+		}
+		*p = n
+	}
+	return v, nil
+}
+
+// parseNumber parses a version number, which must not have leading zeros.
+func parseNumber(s string) (uint64, error) {
+	if len(s) > 1 && s[0] == '0' {
+		return 0, errors.New(nil, "number %q has leading zero", s)
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, errors.New(nil, "invalid number %q", s)
+	}
+	return n, nil
+}
+
+// checkIdentifiers checks that s is a dot-separated list of non-empty
+// identifiers of ASCII letters, digits and hyphens. If numeric is true,
+// numeric identifiers must not have leading zeros.
+func checkIdentifiers(s string, numeric bool) error {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return errors.New(nil, "empty identifier")
+		}
+		digits := true
+		for _, c := range id {
+			switch {
+			case c >= '0' && c <= '9':
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-':
+				digits = false
+			default:
+				return errors.New(nil, "invalid character %q in identifier %q", c, id)
+			}
+		}
+		if numeric && digits && len(id) > 1 && id[0] == '0' {
+			return errors.New(nil, "identifier %q has leading zero", id)
+		}
+	}
+	return nil
+}
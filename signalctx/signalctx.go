@@ -0,0 +1,188 @@
+// Package signalctx implements contexts cancelled by signals, and orderly
+// shutdown of a program's subsystems.
+//
+// WithSignals returns a context which is cancelled when the program receives
+// an interrupt or termination signal, recording which signal arrived:
+//
+//	ctx, stop := signalctx.WithSignals(context.Background())
+//	defer stop()
+//	err := srv.Serve(ctx)
+//	if sig := signalctx.Signal(ctx); sig != nil {
+//		log.Info("received %s", sig)
+//	}
+//
+// A Shutdown coordinates the cleanup of subsystems when the program is asked
+// to stop. Subsystems register cleanup functions as they start, and the main
+// goroutine waits for shutdown:
+//
+//	s := signalctx.New(context.Background())
+//	db := openDB()
+//	s.Register("database", 5*time.Second, func(context.Context) error {
+//		return db.Close()
+//	})
+//	go srv.Serve(s.Context())
+//	s.Register("server", 10*time.Second, srv.Shutdown)
+//	if err := s.Wait(); err != nil {
+//		log.Error("%s", err)
+//		os.Exit(1)
+//	}
+package signalctx
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/ctxutil"
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// SignalError is the cause of a context cancelled by a signal, as returned by
+// context.Cause.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e *SignalError) Error() string {
+	return "received signal " + e.Signal.String()
+}
+
+// Signal returns the signal which cancelled ctx, or nil if ctx was not
+// cancelled by a signal.
+func Signal(ctx context.Context) os.Signal {
+	if e, ok := context.Cause(ctx).(*SignalError); ok {
+		return e.Signal
+	}
+	return nil
+}
+
+// WithSignals returns a copy of parent which is cancelled when the program
+// receives one of sigs, when stop is called, or when parent is done, whichever
+// happens first. If no signals are given, it defaults to os.Interrupt and
+// syscall.SIGTERM. The cause of the cancellation, as returned by
+// context.Cause, is a *SignalError if a signal arrived.
+//
+// Once a signal arrives or stop is called, the signals are no longer handled,
+// so that a second interrupt terminates the program as usual. Call stop as
+// soon as the context is no longer needed.
+func WithSignals(parent context.Context, sigs ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case sig := <-ch:
+			cancel(&SignalError{sig})
+		case <-ctx.Done():
+		}
+		signal.Stop(ch)
+	}()
+	return ctx, func() { cancel(nil) }
+}
+
+// Shutdown cancels a root context when the program receives a signal, and
+// then runs the cleanup functions registered by the program's subsystems.
+type Shutdown struct {
+	ctx   context.Context
+	stop  context.CancelFunc
+	mu    sync.Mutex
+	steps []step
+}
+
+type step struct {
+	name    string
+	timeout time.Duration
+	fn      func(ctx context.Context) error
+}
+
+// New returns a Shutdown whose root context is derived from parent, and is
+// cancelled as by WithSignals.
+func New(parent context.Context, sigs ...os.Signal) *Shutdown {
+	ctx, stop := WithSignals(parent, sigs...)
+	return &Shutdown{ctx: ctx, stop: stop}
+}
+
+// Context returns the root context, which is cancelled when shutdown begins.
+func (s *Shutdown) Context() context.Context {
+	return s.ctx
+}
+
+// Register adds a cleanup function named name to be run on shutdown. Cleanup
+// functions are run one at a time, in the reverse of the order in which they
+// were registered, so that a subsystem is stopped before those it was started
+// after. The context given to fn carries the values of the root context, but
+// is not cancelled with it; if timeout is positive, the context expires after
+// timeout, and shutdown moves on to the next function if fn has not returned
+// by then. A function may be registered while shutdown is in progress, and is
+// then run next.
+func (s *Shutdown) Register(name string, timeout time.Duration, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = append(s.steps, step{name, timeout, fn})
+}
+
+// Signal returns the signal which began shutdown, or nil if shutdown has not
+// begun or was begun by other means.
+func (s *Shutdown) Signal() os.Signal {
+	return Signal(s.ctx)
+}
+
+// Trigger begins shutdown, as if the program had received a signal.
+func (s *Shutdown) Trigger() {
+	s.stop()
+}
+
+// Wait waits for shutdown to begin, whether by a signal, by Trigger, or by the
+// parent context ending, and then runs the registered cleanup functions.
+// Returns error if any cleanup function fails, panics or times out, naming
+// each function which did.
+func (s *Shutdown) Wait() error {
+	<-s.ctx.Done()
+	s.stop()
+	var errs []error
+	for {
+		s.mu.Lock()
+		if len(s.steps) == 0 {
+			s.mu.Unlock()
+			break
+		}
+		st := s.steps[len(s.steps)-1]
+		s.steps = s.steps[:len(s.steps)-1]
+		s.mu.Unlock()
+		if err := s.run(st); err != nil {
+			errs = append(errs, errors.New(nil, "%s: %s", st.name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(errors.Join(errs...), "shutdown failed")
+	}
+	return nil
+}
+
+// run runs a single cleanup function under its timeout.
+func (s *Shutdown) run(st step) error {
+	ctx := ctxutil.Detach(s.ctx)
+	if st.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, st.timeout)
+		defer cancel()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- errors.Catch(func() error {
+			return st.fn(ctx)
+		})
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.New(nil, "timed out after %s", st.timeout)
+	}
+}
@@ -0,0 +1,213 @@
+package slices
+
+import (
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Op represents the kind of operation performed by an Edit.
+type Op int
+
+const (
+	// Keep indicates an element present in both the old and new slices.
+	Keep Op = iota
+	// Insert indicates an element present only in the new slice.
+	Insert
+	// Delete indicates an element present only in the old slice.
+	Delete
+)
+
+func (op Op) String() string {
+	switch op {
+	case Keep:
+		return "keep"
+	case Insert:
+		return "insert"
+	case Delete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// Edit represents a single operation in the transformation of one slice into
+// another. Old and New hold the index of Value within the old and new slices
+// respectively, or -1 if Value is not present in that slice.
+type Edit[T any] struct {
+	Op    Op
+	Old   int
+	New   int
+	Value T
+}
+
+// Apply transforms slice old by applying edits in order, and returns the
+// resulting slice. The edits are typically those returned by Diff. Returns
+// error if the edits do not describe old, that is, if the Keep and Delete edits
+// do not match the elements of old exactly, in order.
+func Apply[T comparable](old []T, edits []Edit[T]) ([]T, error) {
+	var applied []T
+	i := 0
+	for _, e := range edits {
+		switch e.Op {
+		case Keep, Delete:
+			if i >= len(old) || old[i] != e.Value {
+				return nil, errors.New(nil,
+					"%s of %v does not match element %d of old slice",
+					e.Op, e.Value, i,
+				)
+			}
+			if e.Op == Keep {
+				applied = append(applied, e.Value)
+			}
+			i++
+		case Insert:
+			applied = append(applied, e.Value)
+		default:
+			return nil, errors.New(nil, "invalid edit operation %d", e.Op)
+		}
+	}
+	if i != len(old) {
+		return nil, errors.New(nil,
+			"edits cover %d of %d elements of old slice", i, len(old),
+		)
+	}
+	return applied, nil
+}
+
+// Diff returns a minimal sequence of edits which transforms slice old into
+// slice new, using the Myers difference algorithm. Applying the Keep and Insert
+// edits in order yields new, and applying the Keep and Delete edits in order
+// yields old. Where an element is both deleted and inserted at the same
+// position, the deletion comes first.
+//
+// Diff runs in O((N+M)D) time and O(N+M) space, where N and M are the lengths
+// of the two slices and D is the number of inserted and deleted elements.
+func Diff[T comparable](old, new []T) []Edit[T] {
+	size := len(old) + len(new)
+	d := differ[T]{
+		old: old,
+		new: new,
+		fwd: make([]int, 2*size+3),
+		bwd: make([]int, 2*size+3),
+		off: size + 1,
+	}
+	d.diff(0, len(old), 0, len(new))
+	// Move the deletions of each run of changes before its insertions.
+	edits := d.edits
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Keep {
+			i++
+			continue
+		}
+		j := i
+		for j < len(edits) && edits[j].Op != Keep {
+			j++
+		}
+		run := make([]Edit[T], 0, j-i)
+		for _, e := range edits[i:j] {
+			if e.Op == Delete {
+				run = append(run, e)
+			}
+		}
+		for _, e := range edits[i:j] {
+			if e.Op == Insert {
+				run = append(run, e)
+			}
+		}
+		copy(edits[i:j], run)
+		i = j
+	}
+	return edits
+}
+
+// differ holds the state of Diff, whose vectors fwd and bwd hold the furthest
+// reaching paths of the forward and backward searches, indexed by diagonal
+// plus off.
+type differ[T comparable] struct {
+	old, new []T
+	fwd, bwd []int
+	off      int
+	edits    []Edit[T]
+}
+
+// diff appends the edits which transform old[a0:a1] into new[b0:b1], dividing
+// the problem at the middle snake of its edit graph, so that only linear space
+// is needed.
+func (d *differ[T]) diff(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && d.old[a0] == d.new[b0] {
+		d.edits = append(d.edits, Edit[T]{Keep, a0, b0, d.old[a0]})
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a0 < a1-suffix && b0 < b1-suffix && d.old[a1-suffix-1] == d.new[b1-suffix-1] {
+		suffix++
+	}
+	a1, b1 = a1-suffix, b1-suffix
+	switch {
+	case a0 == a1:
+		for ; b0 < b1; b0++ {
+			d.edits = append(d.edits, Edit[T]{Insert, -1, b0, d.new[b0]})
+		}
+	case b0 == b1:
+		for ; a0 < a1; a0++ {
+			d.edits = append(d.edits, Edit[T]{Delete, a0, -1, d.old[a0]})
+		}
+	default:
+		x, y, u, v := d.middle(a0, a1, b0, b1)
+		d.diff(a0, a0+x, b0, b0+y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.edits = append(d.edits, Edit[T]{Keep, a0 + x, b0 + y, d.old[a0+x]})
+		}
+		d.diff(a0+u, a1, b0+v, b1)
+	}
+	for i := 0; i < suffix; i++ {
+		d.edits = append(d.edits, Edit[T]{Keep, a1 + i, b1 + i, d.old[a1+i]})
+	}
+}
+
+// middle returns the start (x, y) and end (u, v) of the middle snake of the
+// edit graph of old[a0:a1] and new[b0:b1], relative to a0 and b0, found by
+// searching forward from the start and backward from the end at once.
+func (d *differ[T]) middle(a0, a1, b0, b1 int) (x, y, u, v int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta&1 != 0
+	fwd, bwd, off := d.fwd, d.bwd, d.off
+	fwd[off+1], bwd[off+1] = 0, 0
+	for depth := 0; depth <= (n+m+1)/2; depth++ {
+		for k := -depth; k <= depth; k += 2 {
+			if k == -depth || k != depth && fwd[off+k-1] < fwd[off+k+1] {
+				x = fwd[off+k+1]
+			} else {
+				x = fwd[off+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && d.old[a0+u] == d.new[b0+v] {
+				u++
+				v++
+			}
+			fwd[off+k] = u
+			if r := delta - k; odd && r >= -(depth-1) && r <= depth-1 && u+bwd[off+r] >= n {
+				return x, y, u, v
+			}
+		}
+		for r := -depth; r <= depth; r += 2 {
+			if r == -depth || r != depth && bwd[off+r-1] < bwd[off+r+1] {
+				x = bwd[off+r+1]
+			} else {
+				x = bwd[off+r-1] + 1
+			}
+			y = x - r
+			u, v = x, y
+			for u < n && v < m && d.old[a1-u-1] == d.new[b1-v-1] {
+				u++
+				v++
+			}
+			bwd[off+r] = u
+			if k := delta - r; !odd && k >= -depth && k <= depth && u+fwd[off+k] >= n {
+				return n - u, m - v, n - x, m - y
+			}
+		}
+	}
+	panic("unreachable")
+}
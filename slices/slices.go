@@ -1,23 +1,181 @@
 package slices
 
 import (
+	"fmt"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std"
 	"git.sr.ht/~kvo/go-std/errors"
 )
 
-// Get returns the nth element of slice s. Returns error if slice s does not
-// have an element at index n.
+// Clone returns a shallow copy of slice s. The elements are copied using
+// assignment, so the copy shares any memory referenced by the elements of s.
+// If s is nil, Clone returns nil.
+func Clone[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// Combinations returns a sequence of every combination of k elements of slice
+// s. Combinations are yielded in lexicographic order of the indices of their
+// elements within s, and elements within each combination retain their order
+// in s. Elements at different indices are treated as distinct, even if they
+// are equal. If k is greater than len(s), the sequence is empty. Returns error
+// if k is negative.
+//
+// Combinations are generated on demand. Each yielded slice is newly allocated
+// and may be retained by the caller.
+func Combinations[T any](s []T, k int) (std.Seq[[]T], error) {
+	if k < 0 {
+		return nil, errors.New(nil, "invalid combination length %d", k)
+	}
+	return func(yield func([]T) bool) {
+		n := len(s)
+		if k > n {
+			return
+		}
+		idx := make([]int, k)
+		for i := range idx {
+			idx[i] = i
+		}
+		for {
+			comb := make([]T, k)
+			for i, j := range idx {
+				comb[i] = s[j]
+			}
+			if !yield(comb) {
+				return
+			}
+			i := k - 1
+			for i >= 0 && idx[i] == n-k+i {
+				i--
+			}
+			if i < 0 {
+				return
+			}
+			idx[i]++
+			for j := i + 1; j < k; j++ {
+				idx[j] = idx[j-1] + 1
+			}
+		}
+	}, nil
+}
+
+// Count returns the number of occurrences of element elem in slice s.
+func Count[T comparable](s []T, elem T) int {
+	return CountFunc(s, func(v T) bool {
+		return v == elem
+	})
+}
+
+// CountFunc returns the number of elements of slice s for which pred returns
+// true.
+func CountFunc[T any](s []T, pred func(T) bool) int {
+	n := 0
+	for _, v := range s {
+		if pred(v) {
+			n++
+		}
+	}
+	return n
+}
+
+// Difference returns the elements of a which are not present in b. Each
+// element appears at most once in the result, in the order of its first
+// occurrence in a.
+func Difference[T comparable](a, b []T) []T {
+	exclude := make(map[T]struct{}, len(b))
+	for _, v := range b {
+		exclude[v] = struct{}{}
+	}
+	var diff []T
+	for _, v := range a {
+		if _, ok := exclude[v]; !ok {
+			diff = append(diff, v)
+			exclude[v] = struct{}{}
+		}
+	}
+	return diff
+}
+
+// Find returns the first element of slice s for which pred returns true.
+// Returns error if no element of s satisfies pred.
+func Find[T any](s []T, pred func(T) bool) (T, error) {
+	for _, v := range s {
+		if pred(v) {
+			return v, nil
+		}
+	}
+	var none T
+	return none, errors.New(nil, "no matching element")
+}
+
+// FindLast returns the last element of slice s for which pred returns true.
+// Returns error if no element of s satisfies pred.
+func FindLast[T any](s []T, pred func(T) bool) (T, error) {
+	for i := len(s) - 1; i >= 0; i-- {
+		if pred(s[i]) {
+			return s[i], nil
+		}
+	}
+	var none T
+	return none, errors.New(nil, "no matching element")
+}
+
+// Get returns the nth element of slice s. If n is negative, it counts from the
+// end of s, so that Get(s, -1) returns the last element. Returns error if slice
+// s does not have an element at index n.
 //
 // For most access attempts on strings, []rune(str) will be a more appropriate
 // choice than []byte(str) for the parameter s, as no individual byte in
 // []byte(str) is guaranteed to hold a single Unicode code point.
 func Get[T any](s []T, n int) (T, error) {
 	var none T
-	if n > len(s)-1 || n < 0 {
+	i := n
+	if i < 0 {
+		i += len(s)
+	}
+	if i > len(s)-1 || i < 0 {
 		return none, errors.New(nil,
 			"index out of range [%d] with length %d", n, len(s),
 		)
 	}
-	return s[n], nil
+	return s[i], nil
+}
+
+// GetAll returns the elements of slice s at each of the indices ns, in the
+// order given. As with Get, negative indices count from the end of s. Returns
+// error if slice s does not have an element at any of the indices ns; the error
+// lists every offending index.
+func GetAll[T any](s []T, ns ...int) ([]T, error) {
+	elems := make([]T, len(ns))
+	var bad []string
+	for k, n := range ns {
+		i := n
+		if i < 0 {
+			i += len(s)
+		}
+		if i > len(s)-1 || i < 0 {
+			bad = append(bad, fmt.Sprint(n))
+			continue
+		}
+		elems[k] = s[i]
+	}
+	switch len(bad) {
+	case 0:
+		return elems, nil
+	case 1:
+		return nil, errors.New(nil,
+			"index out of range [%s] with length %d", bad[0], len(s),
+		)
+	default:
+		return nil, errors.New(nil,
+			"indices out of range [%s] with length %d",
+			strings.Join(bad, " "), len(s),
+		)
+	}
 }
 
 // Has checks slice s for the existence of an element elem.
@@ -40,13 +198,236 @@ func HasOnly[T comparable](s []T, elem T) bool {
 	return true
 }
 
-// Remove attempts to remove element elem from slice s and return the resulting
-// slice. If elem is not present in s, s is returned unchanged.
+// Intersect returns the elements of a which are also present in b. Each element
+// appears at most once in the result, in the order of its first occurrence in
+// a.
+func Intersect[T comparable](a, b []T) []T {
+	include := make(map[T]bool, len(b))
+	for _, v := range b {
+		include[v] = true
+	}
+	var inter []T
+	for _, v := range a {
+		if include[v] {
+			inter = append(inter, v)
+			include[v] = false
+		}
+	}
+	return inter
+}
+
+// MaxBy returns the element of slice s for which key returns the greatest value.
+// If several elements share the greatest key, the first of them is returned.
+// Returns error if s is empty.
+func MaxBy[T any, K std.Ordered](s []T, key func(T) K) (T, error) {
+	return extremeBy(s, key, func(a, b K) bool {
+		return a > b
+	})
+}
+
+// MinBy returns the element of slice s for which key returns the least value.
+// If several elements share the least key, the first of them is returned.
+// Returns error if s is empty.
+func MinBy[T any, K std.Ordered](s []T, key func(T) K) (T, error) {
+	return extremeBy(s, key, func(a, b K) bool {
+		return a < b
+	})
+}
+
+func extremeBy[T any, K std.Ordered](s []T, key func(T) K, better func(a, b K) bool) (T, error) {
+	if len(s) == 0 {
+		var none T
+		return none, errors.New(nil, "empty slice")
+	}
+	best, bestKey := s[0], key(s[0])
+	for _, v := range s[1:] {
+		if k := key(v); better(k, bestKey) {
+			best, bestKey = v, k
+		}
+	}
+	return best, nil
+}
+
+// Permutations returns a sequence of every permutation of slice s. Permutations
+// are yielded in lexicographic order of the indices of their elements within s,
+// starting with s itself. Elements at different indices are treated as
+// distinct, even if they are equal.
+//
+// A slice of length n has n! permutations, which are generated on demand. Each
+// yielded slice is newly allocated and may be retained by the caller.
+func Permutations[T any](s []T) std.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		n := len(s)
+		idx := make([]int, n)
+		for i := range idx {
+			idx[i] = i
+		}
+		for {
+			perm := make([]T, n)
+			for i, j := range idx {
+				perm[i] = s[j]
+			}
+			if !yield(perm) {
+				return
+			}
+			i := n - 2
+			for i >= 0 && idx[i] > idx[i+1] {
+				i--
+			}
+			if i < 0 {
+				return
+			}
+			j := n - 1
+			for idx[j] < idx[i] {
+				j--
+			}
+			idx[i], idx[j] = idx[j], idx[i]
+			for l, r := i+1, n-1; l < r; l, r = l+1, r-1 {
+				idx[l], idx[r] = idx[r], idx[l]
+			}
+		}
+	}
+}
+
+// Remove returns a copy of slice s with the first occurrence of element elem
+// removed. If elem is not present in s, an unmodified copy of s is returned. The
+// underlying array of s is never modified.
 func Remove[T comparable](s []T, elem T) []T {
 	for i, v := range s {
 		if v == elem {
-			return append(s[:i], s[i+1:]...)
+			return removeAt(s, i)
+		}
+	}
+	return append([]T(nil), s...)
+}
+
+// RemoveAll returns a copy of slice s with every occurrence of element elem
+// removed. The underlying array of s is never modified.
+func RemoveAll[T comparable](s []T, elem T) []T {
+	return RemoveFunc(s, func(v T) bool {
+		return v == elem
+	})
+}
+
+// RemoveAt returns a copy of slice s with the element at index i removed.
+// Returns error if slice s does not have an element at index i. The underlying
+// array of s is never modified.
+func RemoveAt[T any](s []T, i int) ([]T, error) {
+	if i > len(s)-1 || i < 0 {
+		return nil, errors.New(nil,
+			"index out of range [%d] with length %d", i, len(s),
+		)
+	}
+	return removeAt(s, i), nil
+}
+
+// RemoveFunc returns a copy of slice s with every element for which pred
+// returns true removed. The underlying array of s is never modified.
+func RemoveFunc[T any](s []T, pred func(T) bool) []T {
+	var kept []T
+	for _, v := range s {
+		if !pred(v) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func removeAt[T any](s []T, i int) []T {
+	removed := make([]T, 0, len(s)-1)
+	removed = append(removed, s[:i]...)
+	return append(removed, s[i+1:]...)
+}
+
+// Scan returns the running accumulation of slice s. The ith element of the
+// result is f applied to the (i-1)th element of the result and the ith element
+// of s, with init taking the place of the element preceding the first. The
+// result has the same length as s, and its last element is the final
+// accumulated value.
+//
+// For example, the prefix sums of a slice of integers can be found with:
+//
+//	sums := slices.Scan(s, 0, func(sum, v int) int { return sum + v })
+func Scan[T, U any](s []T, init U, f func(U, T) U) []U {
+	acc := make([]U, len(s))
+	for i, v := range s {
+		init = f(init, v)
+		acc[i] = init
+	}
+	return acc
+}
+
+// Split divides slice s into n consecutive groups whose lengths differ by at
+// most one, such that the longer groups come first. Exactly n groups are
+// returned; if n is greater than len(s), the trailing groups are empty.
+// Returns error if n is not positive.
+//
+// Each group shares its underlying array with s.
+func Split[T any](s []T, n int) ([][]T, error) {
+	if n <= 0 {
+		return nil, errors.New(nil, "invalid group count %d", n)
+	}
+	groups := make([][]T, n)
+	size, extra := len(s)/n, len(s)%n
+	start := 0
+	for i := range groups {
+		end := start + size
+		if i < extra {
+			end++
 		}
+		groups[i] = s[start:end:end]
+		start = end
 	}
-	return s
+	return groups, nil
+}
+
+// Union returns the elements present in either a or b. Each element appears at
+// most once in the result. Elements of a come first, in the order of their
+// first occurrence in a, followed by the remaining elements of b in the order of
+// their first occurrence in b.
+func Union[T comparable](a, b []T) []T {
+	seen := make(map[T]struct{}, len(a)+len(b))
+	var union []T
+	for _, s := range [][]T{a, b} {
+		for _, v := range s {
+			if _, ok := seen[v]; !ok {
+				union = append(union, v)
+				seen[v] = struct{}{}
+			}
+		}
+	}
+	return union
+}
+
+// Windows returns all overlapping windows of length size over slice s, in order.
+// A slice of length n has n-size+1 windows; if size is greater than n, no
+// windows are returned. Returns error if size is not positive.
+//
+// Each window shares its underlying array with s.
+func Windows[T any](s []T, size int) ([][]T, error) {
+	seq, err := WindowsSeq(s, size)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	var windows [][]T
+	seq(func(w []T) bool {
+		windows = append(windows, w)
+		return true
+	})
+	return windows, nil
+}
+
+// WindowsSeq is like Windows, but returns the windows lazily as a sequence
+// instead of collecting them into a slice.
+func WindowsSeq[T any](s []T, size int) (std.Seq[[]T], error) {
+	if size <= 0 {
+		return nil, errors.New(nil, "invalid window size %d", size)
+	}
+	return func(yield func([]T) bool) {
+		for i := 0; i+size <= len(s); i++ {
+			if !yield(s[i : i+size : i+size]) {
+				return
+			}
+		}
+	}, nil
 }
@@ -0,0 +1,83 @@
+package stats
+
+import "math"
+
+// Accumulator summarises a stream of values, as they are added, without
+// storing them, by Welford's online algorithm. The zero value of an
+// Accumulator is ready to use, and holds no values.
+type Accumulator struct {
+	n        int
+	mean     float64
+	m2       float64 // sum of squared deviations from the mean
+	min, max float64
+}
+
+// Add adds x to the values summarised by a.
+func (a *Accumulator) Add(x float64) {
+	a.n++
+	if a.n == 1 {
+		a.min, a.max = x, x
+	} else if x < a.min {
+		a.min = x
+	} else if x > a.max {
+		a.max = x
+	}
+	delta := x - a.mean
+	a.mean += delta / float64(a.n)
+	a.m2 += delta * (x - a.mean)
+}
+
+// Count returns the number of values added to a.
+func (a *Accumulator) Count() int {
+	return a.n
+}
+
+// Max returns the greatest value added to a, or 0 if there are none.
+func (a *Accumulator) Max() float64 {
+	return a.max
+}
+
+// Mean returns the arithmetic mean of the values added to a, or 0 if there
+// are none.
+func (a *Accumulator) Mean() float64 {
+	return a.mean
+}
+
+// Merge adds the values summarised by b to those summarised by a, such as to
+// combine the summaries of several goroutines.
+func (a *Accumulator) Merge(b *Accumulator) {
+	switch {
+	case b.n == 0:
+		return
+	case a.n == 0:
+		*a = *b
+		return
+	}
+	n := a.n + b.n
+	delta := b.mean - a.mean
+	a.m2 += b.m2 + delta*delta*float64(a.n)*float64(b.n)/float64(n)
+	a.mean += delta * float64(b.n) / float64(n)
+	a.n = n
+	a.min = math.Min(a.min, b.min)
+	a.max = math.Max(a.max, b.max)
+}
+
+// Min returns the least value added to a, or 0 if there are none.
+func (a *Accumulator) Min() float64 {
+	return a.min
+}
+
+// StdDev returns the sample standard deviation of the values added to a, or 0
+// if there are fewer than two.
+func (a *Accumulator) StdDev() float64 {
+	return math.Sqrt(a.Variance())
+}
+
+// Variance returns the sample variance of the values added to a, as described
+// for the package-level function Variance, or 0 if there are fewer than two.
+func (a *Accumulator) Variance() float64 {
+	if a.n < 2 {
+		return 0
+	}
+	return a.m2 / float64(a.n-1)
+}
@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Histogram counts values in buckets of equal width.
+type Histogram struct {
+	min, max  float64
+	width     float64
+	counts    []int
+	underflow int
+	overflow  int
+}
+
+// Bucket describes a bucket of a Histogram.
+type Bucket struct {
+	// Low and High bound the values counted by the bucket: a value x is
+	// counted if Low <= x < High.
+	Low, High float64
+	// Count is the number of values counted.
+	Count int
+}
+
+// NewHistogram returns a histogram dividing the range from min to max into n
+// buckets of equal width. Returns error if min is not less than max, or n is
+// less than one.
+func NewHistogram(min, max float64, n int) (*Histogram, error) {
+	if !(min < max) {
+		return nil, errors.New(nil, "invalid histogram range [%v, %v)", min, max)
+	}
+	if n < 1 {
+		return nil, errors.New(nil, "invalid histogram bucket count %d", n)
+	}
+	return &Histogram{
+		min:    min,
+		max:    max,
+		width:  (max - min) / float64(n),
+		counts: make([]int, n),
+	}, nil
+}
+
+// Add counts x in its bucket. A value less than the histogram's minimum is
+// counted as an underflow, and a value not less than its maximum, or NaN, as an
+// overflow.
+func (h *Histogram) Add(x float64) {
+	switch {
+	case x < h.min:
+		h.underflow++
+	case !(x < h.max):
+		h.overflow++
+	default:
+		i := int((x - h.min) / h.width)
+		if i >= len(h.counts) {
+			// x is just below max, but rounding places it beyond the last
+			// bucket.
+			i = len(h.counts) - 1
+		}
+		h.counts[i]++
+	}
+}
+
+// Buckets returns the buckets of h, in ascending order.
+func (h *Histogram) Buckets() []Bucket {
+	bs := make([]Bucket, len(h.counts))
+	for i, n := range h.counts {
+		bs[i] = Bucket{
+			Low:   h.min + float64(i)*h.width,
+			High:  h.min + float64(i+1)*h.width,
+			Count: n,
+		}
+	}
+	bs[len(bs)-1].High = h.max
+	return bs
+}
+
+// Count returns the number of values added to h, including underflows and
+// overflows.
+func (h *Histogram) Count() int {
+	n := h.underflow + h.overflow
+	for _, c := range h.counts {
+		n += c
+	}
+	return n
+}
+
+// Overflow returns the number of values added to h which were not less than
+// its maximum.
+func (h *Histogram) Overflow() int {
+	return h.overflow
+}
+
+// Underflow returns the number of values added to h which were less than its
+// minimum.
+func (h *Histogram) Underflow() int {
+	return h.underflow
+}
+
+// String returns a chart of h, with a line for each bucket giving its range,
+// its count, and a bar of length proportional to its count, such as:
+//
+//	[0, 10)    4  ################
+//	[10, 20)  10  ########################################
+//
+// Lines for underflows and overflows are included if there are any.
+func (h *Histogram) String() string {
+	type line struct {
+		label string
+		count int
+	}
+	var lines []line
+	if h.underflow > 0 {
+		lines = append(lines, line{fmt.Sprintf("< %.6g", h.min), h.underflow})
+	}
+	for _, b := range h.Buckets() {
+		lines = append(lines, line{fmt.Sprintf("[%.6g, %.6g)", b.Low, b.High), b.Count})
+	}
+	if h.overflow > 0 {
+		lines = append(lines, line{fmt.Sprintf(">= %.6g", h.max), h.overflow})
+	}
+	labelw, countw, most := 0, 0, 0
+	for _, l := range lines {
+		if len(l.label) > labelw {
+			labelw = len(l.label)
+		}
+		if n := len(fmt.Sprint(l.count)); n > countw {
+			countw = n
+		}
+		if l.count > most {
+			most = l.count
+		}
+	}
+	const barw = 40
+	var sb strings.Builder
+	for _, l := range lines {
+		bar := 0
+		if most > 0 {
+			bar = (l.count*barw + most - 1) / most
+		}
+		fmt.Fprintf(&sb, "%-*s  %*d  %s\n", labelw, l.label, countw, l.count, strings.Repeat("#", bar))
+	}
+	return sb.String()
+}
@@ -0,0 +1,145 @@
+// Package stats implements descriptive statistics, such as for summarising
+// benchmark timings and metrics.
+//
+// The functions of the package summarise a slice of numbers:
+//
+//	mean, err := stats.Mean(timings)
+//	p99, err := stats.Percentile(timings, 99)
+//
+// An Accumulator summarises a stream of numbers without storing them, and a
+// Histogram counts them in fixed buckets:
+//
+//	var acc stats.Accumulator
+//	h, err := stats.NewHistogram(0, 100, 10)
+//	for _, v := range samples {
+//		acc.Add(v)
+//		h.Add(v)
+//	}
+//	fmt.Printf("mean %.2f, stddev %.2f\n", acc.Mean(), acc.StdDev())
+//	fmt.Print(h)
+package stats
+
+import (
+	"math"
+	"sort"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/mathx"
+)
+
+// Number is a constraint satisfied by any integer or floating-point type.
+type Number interface {
+	mathx.Integer | ~float32 | ~float64
+}
+
+// Mean returns the arithmetic mean of xs. Returns error if xs is empty.
+func Mean[T Number](xs []T) (float64, error) {
+	if len(xs) == 0 {
+		return 0, errors.New(nil, "mean of no values")
+	}
+	// The mean is accumulated incrementally, so that the sum of large
+	// values cannot overflow.
+	var mean float64
+	for i, x := range xs {
+		mean += (float64(x) - mean) / float64(i+1)
+	}
+	return mean, nil
+}
+
+// Median returns the median of xs: its middle value once sorted, or the mean
+// of its two middle values if it has an even number of values. Returns error
+// if xs is empty.
+func Median[T Number](xs []T) (float64, error) {
+	if len(xs) == 0 {
+		return 0, errors.New(nil, "median of no values")
+	}
+	return quantile(sorted(xs), 0.5), nil
+}
+
+// Mode returns the most frequent value of xs, or the least such value if
+// several are equally frequent. Returns error if xs is empty.
+func Mode[T Number](xs []T) (T, error) {
+	if len(xs) == 0 {
+		return 0, errors.New(nil, "mode of no values")
+	}
+	counts := make(map[T]int)
+	var mode T
+	best := 0
+	for _, x := range xs {
+		counts[x]++
+		n := counts[x]
+		if n > best || n == best && x < mode {
+			mode, best = x, n
+		}
+	}
+	return mode, nil
+}
+
+// Percentile returns the p-th percentile of xs, for p from 0 to 100, as by
+// Quantile(xs, p/100).
+func Percentile[T Number](xs []T, p float64) (float64, error) {
+	if !(p >= 0 && p <= 100) {
+		return 0, errors.New(nil, "invalid percentile %v", p)
+	}
+	return Quantile(xs, p/100)
+}
+
+// Quantile returns the q-quantile of xs, for q from 0 to 1, interpolating
+// linearly between the two values of xs nearest to it once sorted. The
+// 0-quantile is the least value, the 0.5-quantile the median, and the
+// 1-quantile the greatest value. Returns error if xs is empty or q is out of
+// range.
+func Quantile[T Number](xs []T, q float64) (float64, error) {
+	if !(q >= 0 && q <= 1) {
+		return 0, errors.New(nil, "invalid quantile %v", q)
+	}
+	if len(xs) == 0 {
+		return 0, errors.New(nil, "quantile of no values")
+	}
+	return quantile(sorted(xs), q), nil
+}
+
+// StdDev returns the sample standard deviation of xs, the square root of its
+// Variance. Returns error if xs has fewer than two values.
+func StdDev[T Number](xs []T) (float64, error) {
+	v, err := Variance(xs)
+	if err != nil {
+		return 0, errors.New(nil, "standard deviation of fewer than two values")
+	}
+	return math.Sqrt(v), nil
+}
+
+// Variance returns the sample variance of xs, the sum of the squared
+// deviations of its values from their mean, divided by one less than their
+// number. Returns error if xs has fewer than two values.
+func Variance[T Number](xs []T) (float64, error) {
+	if len(xs) < 2 {
+		return 0, errors.New(nil, "variance of fewer than two values")
+	}
+	var acc Accumulator
+	for _, x := range xs {
+		acc.Add(float64(x))
+	}
+	return acc.Variance(), nil
+}
+
+// quantile returns the q-quantile of the sorted, non-empty slice xs.
+func quantile(xs []float64, q float64) float64 {
+	pos := q * float64(len(xs)-1)
+	i := int(pos)
+	if i >= len(xs)-1 {
+		return xs[len(xs)-1]
+	}
+	frac := pos - float64(i)
+	return xs[i] + frac*(xs[i+1]-xs[i])
+}
+
+// sorted returns the values of xs as floats, in ascending order.
+func sorted[T Number](xs []T) []float64 {
+	fs := make([]float64, len(xs))
+	for i, x := range xs {
+		fs[i] = float64(x)
+	}
+	sort.Float64s(fs)
+	return fs
+}
@@ -0,0 +1,119 @@
+package std
+
+import (
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Ordered is a constraint satisfied by any type supporting the operators <, <=,
+// >= and >.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// Seq is a lazy sequence of values. A Seq calls yield with each successive
+// value, stopping early if yield returns false. Seq has the same form as the
+// iter.Seq type introduced in Go 1.23, so a Seq may be used directly in a
+// range-over-func loop where supported:
+//
+//	for v := range seq {
+//		...
+//	}
+type Seq[V any] func(yield func(V) bool)
+
+// Clamp returns v limited to the inclusive range [lo, hi]. Returns error if lo
+// is greater than hi.
+func Clamp[T Ordered](v, lo, hi T) (T, error) {
+	if lo > hi {
+		var none T
+		return none, errors.New(nil, "invalid range [%v, %v]", lo, hi)
+	}
+	if v < lo {
+		return lo, nil
+	}
+	if v > hi {
+		return hi, nil
+	}
+	return v, nil
+}
+
+// Coalesce returns the first of vals which is not the zero value of its type.
+// Returns error if all of vals are zero values, or if vals is empty.
+//
+// Coalesce is useful for falling back to default configuration values:
+//
+//	addr, err := std.Coalesce(flagAddr, os.Getenv("ADDR"), ":8080")
+func Coalesce[T comparable](vals ...T) (T, error) {
+	var zero T
+	for _, v := range vals {
+		if v != zero {
+			return v, nil
+		}
+	}
+	return zero, errors.New(nil, "no non-zero value")
+}
+
+// Deref returns the value pointed to by p. Returns error if p is nil.
+func Deref[T any](p *T) (T, error) {
+	if p == nil {
+		var none T
+		return none, errors.New(nil, "nil pointer dereference")
+	}
+	return *p, nil
+}
+
+// DerefOr returns the value pointed to by p, or def if p is nil.
+func DerefOr[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+// First returns the first element of vals for which pred returns true. Returns
+// error if no such element exists.
+func First[T any](vals []T, pred func(T) bool) (T, error) {
+	for _, v := range vals {
+		if pred(v) {
+			return v, nil
+		}
+	}
+	var none T
+	return none, errors.New(nil, "no matching value")
+}
+
+// If returns a if cond is true, and b otherwise.
+//
+// Both a and b are evaluated before If is called. If either value is expensive
+// to compute, IfFunc should be used instead.
+func If[T any](cond bool, a, b T) T {
+	if cond {
+		return a
+	}
+	return b
+}
+
+// IfFunc returns the result of calling a if cond is true, and the result of
+// calling b otherwise. Only the selected function is called.
+func IfFunc[T any](cond bool, a, b func() T) T {
+	if cond {
+		return a()
+	}
+	return b()
+}
+
+// InRange reports whether v lies within the inclusive range [lo, hi]. If lo is
+// greater than hi, the range is empty and InRange returns false.
+func InRange[T Ordered](v, lo, hi T) bool {
+	return lo <= v && v <= hi
+}
+
+// Ptr returns a pointer to a copy of v. Ptr is useful for populating optional
+// pointer fields from constants and other non-addressable values:
+//
+//	req := Request{Timeout: std.Ptr(30)}
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,226 @@
+// Package strcase implements the conversion of identifiers between naming
+// conventions.
+//
+// Identifiers are split into words at separators, changes of case and the
+// ends of acronyms, and joined again in the requested convention:
+//
+//	strcase.Snake("HTTPServer")     // "http_server"
+//	strcase.Kebab("userID")         // "user-id"
+//	strcase.Pascal("user_id")       // "UserID"
+//	strcase.Camel("Content-Length") // "contentLength"
+//	strcase.ScreamingSnake("maxRetries") // "MAX_RETRIES"
+//
+// Words which are known acronyms are written in upper case, or as given in the
+// acronym table, by Camel and Pascal. The package-level functions use
+// DefaultAcronyms; a Converter can be created with a different table:
+//
+//	c := strcase.NewConverter(append(strcase.DefaultAcronyms, "OAuth", "GraphQL")...)
+//	c.Pascal("oauth_token") // "OAuthToken"
+package strcase
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultAcronyms are the acronyms known to Default.
+var DefaultAcronyms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "CSV", "DNS", "EOF", "GUID", "HTML",
+	"HTTP", "HTTPS", "ID", "IO", "IP", "JSON", "JWT", "OS", "QPS", "RAM",
+	"RPC", "SDK", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID",
+	"URI", "URL", "UTF8", "UUID", "VM", "XML", "YAML",
+}
+
+// Default is the converter used by the package-level functions.
+var Default = NewConverter(DefaultAcronyms...)
+
+// Converter converts identifiers between naming conventions, using a table of
+// acronyms. A Converter is safe for concurrent use.
+type Converter struct {
+	// acronyms maps the lower-case form of each acronym to its form as
+	// given.
+	acronyms map[string]string
+	// forms holds each acronym as given, longest first.
+	forms [][]rune
+}
+
+// NewConverter returns a converter which knows the given acronyms. Each
+// acronym is given as it is written in camel and Pascal case, such as "HTTP"
+// or "OAuth", and is matched case-insensitively.
+func NewConverter(acronyms ...string) *Converter {
+	c := &Converter{acronyms: make(map[string]string, len(acronyms))}
+	for _, a := range acronyms {
+		if a == "" {
+			continue
+		}
+		c.acronyms[strings.ToLower(a)] = a
+		c.forms = append(c.forms, []rune(a))
+	}
+	// Sort longest first, so that the longest acronym is matched.
+	for i := 1; i < len(c.forms); i++ {
+		for j := i; j > 0 && len(c.forms[j]) > len(c.forms[j-1]); j-- {
+			c.forms[j], c.forms[j-1] = c.forms[j-1], c.forms[j]
+		}
+	}
+	return c
+}
+
+// Camel returns s in camel case, such as "httpServer".
+func (c *Converter) Camel(s string) string {
+	words := c.Words(s)
+	for i, w := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(w)
+		} else {
+			words[i] = c.title(w)
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// Kebab returns s in kebab case, such as "http-server".
+func (c *Converter) Kebab(s string) string {
+	return c.join(s, "-", strings.ToLower)
+}
+
+// Pascal returns s in Pascal case, such as "HTTPServer".
+func (c *Converter) Pascal(s string) string {
+	words := c.Words(s)
+	for i, w := range words {
+		words[i] = c.title(w)
+	}
+	return strings.Join(words, "")
+}
+
+// ScreamingSnake returns s in screaming snake case, such as "HTTP_SERVER".
+func (c *Converter) ScreamingSnake(s string) string {
+	return c.join(s, "_", strings.ToUpper)
+}
+
+// Snake returns s in snake case, such as "http_server".
+func (c *Converter) Snake(s string) string {
+	return c.join(s, "_", strings.ToLower)
+}
+
+// Words splits s into words. Words are separated by any character other than
+// a letter or digit, and a word ends before an upper-case letter following a
+// lower-case letter or digit, and before the last of a run of upper-case
+// letters followed by a lower-case letter, so that "HTTPServer" gives "HTTP"
+// and "Server". Known acronyms containing lower-case letters, such as
+// "OAuth", are kept whole. Digits belong to the word they follow.
+func (c *Converter) Words(s string) []string {
+	rs := []rune(s)
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(cur) > 0 && unicode.IsUpper(r) {
+			prev := cur[len(cur)-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1]) {
+				flush()
+			}
+		}
+		if len(cur) == 0 {
+			if n := c.match(rs[i:]); n > 0 {
+				words = append(words, string(rs[i:i+n]))
+				i += n - 1
+				continue
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return words
+}
+
+func (c *Converter) join(s, sep string, f func(string) string) string {
+	words := c.Words(s)
+	for i, w := range words {
+		words[i] = f(w)
+	}
+	return strings.Join(words, sep)
+}
+
+// match returns the length of the mixed-case acronym at the start of rs, or
+// zero if there is none. An acronym only matches if it is not followed by a
+// lower-case letter, which would continue the word.
+func (c *Converter) match(rs []rune) int {
+	for _, form := range c.forms {
+		if len(form) > len(rs) || !hasLower(form) {
+			continue
+		}
+		if string(rs[:len(form)]) != string(form) {
+			continue
+		}
+		if len(form) < len(rs) && unicode.IsLower(rs[len(form)]) {
+			continue
+		}
+		return len(form)
+	}
+	return 0
+}
+
+// title returns w as written in camel and Pascal case: as in the acronym
+// table if w is a known acronym, and otherwise with its first letter in upper
+// case and the rest in lower case.
+func (c *Converter) title(w string) string {
+	lower := strings.ToLower(w)
+	if a, ok := c.acronyms[lower]; ok {
+		return a
+	}
+	rs := []rune(lower)
+	if len(rs) > 0 {
+		rs[0] = unicode.ToUpper(rs[0])
+	}
+	return string(rs)
+}
+
+func hasLower(rs []rune) bool {
+	for _, r := range rs {
+		if unicode.IsLower(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Camel returns s in camel case using Default.
+func Camel(s string) string {
+	return Default.Camel(s)
+}
+
+// Kebab returns s in kebab case using Default.
+func Kebab(s string) string {
+	return Default.Kebab(s)
+}
+
+// Pascal returns s in Pascal case using Default.
+func Pascal(s string) string {
+	return Default.Pascal(s)
+}
+
+// ScreamingSnake returns s in screaming snake case using Default.
+func ScreamingSnake(s string) string {
+	return Default.ScreamingSnake(s)
+}
+
+// Snake returns s in snake case using Default.
+func Snake(s string) string {
+	return Default.Snake(s)
+}
+
+// Words splits s into words using Default.
+func Words(s string) []string {
+	return Default.Words(s)
+}
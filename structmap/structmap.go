@@ -0,0 +1,570 @@
+// Package structmap implements the conversion of structs to and from maps, as
+// produced by decoders of formats such as JSON and YAML.
+//
+// Decode populates a struct from a map, converting values to the types of the
+// struct's fields as needed:
+//
+//	var cfg struct {
+//		Addr    string        `map:"addr"`
+//		Timeout time.Duration `map:"timeout"`
+//		Peers   []struct {
+//			Host string `map:"host"`
+//			Port int    `map:"port"`
+//		} `map:"peers"`
+//	}
+//	m := map[string]any{
+//		"addr":    ":8080",
+//		"timeout": "30s",
+//		"peers":   []any{map[string]any{"host": "a", "port": "7000"}},
+//	}
+//	err := structmap.Decode(m, &cfg, nil)
+//
+// Encode converts a struct into a map, the inverse of Decode:
+//
+//	m, err := structmap.Encode(cfg, nil)
+package structmap
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/convert"
+)
+
+// Options configures Decode and Encode. A nil *Options is equivalent to a zero
+// Options, which uses map tags and ignores unknown keys.
+type Options struct {
+	// Tag is the struct tag naming the key of each field. It defaults to
+	// "map"; "json" allows structs written for package encoding/json to be
+	// used.
+	Tag string
+
+	// Strict causes Decode to report keys which match no field, rather than
+	// ignoring them.
+	Strict bool
+
+	// Hook, if not nil, is called by Decode with each value before it is
+	// stored in a value of type t, and returns the value to be stored in its
+	// place, such as to parse values of a type in a custom format. A hook
+	// which does not handle a value should return it unchanged.
+	Hook func(v any, t reflect.Type) (any, error)
+}
+
+// Decode populates the struct pointed to by v from m. Each field takes the
+// value of the key named by its tag, or failing that, of the key equal to its
+// name ignoring case; a field tagged "-" is ignored. Fields of embedded
+// structs are populated as though they belonged to v. Fields whose keys are
+// absent from m are left unchanged.
+//
+// Values are converted to the types of their fields as needed. Nested maps
+// populate structs and maps, and slices populate slices and arrays, element by
+// element. A string may populate any type accepted by package env, including
+// numbers, booleans, durations and types implementing
+// encoding.TextUnmarshaler. Numbers may populate numbers of any type which
+// holds them exactly, and strings, and booleans may populate strings.
+//
+// Decode does not stop at the first problem, but returns a single error
+// describing every value which cannot be converted, by its path, such as
+// "peers[0].port"; the fields which could be converted are populated
+// nonetheless. Returns error if v is not a non-nil pointer to a struct.
+func Decode(m map[string]any, v any, opts *Options) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New(nil, "cannot decode map into %T", v)
+	}
+	d := decoder{tag: "map"}
+	if opts != nil {
+		if opts.Tag != "" {
+			d.tag = opts.Tag
+		}
+		d.strict = opts.Strict
+		d.hook = opts.Hook
+	}
+	d.decode("", m, rv.Elem())
+	if len(d.errs) > 0 {
+		return errors.New(errors.Join(d.errs...), "cannot decode map into %T", v)
+	}
+	return nil
+}
+
+// Encode returns a map holding the fields of the struct v, or of the struct to
+// which v points, named as described for Decode. Fields of struct types are
+// encoded as nested maps, as are the elements of slices, arrays and maps of
+// structs; other values are stored as they are. Types implementing
+// encoding.TextMarshaler, such as time.Time, are not treated as structs. A
+// field whose tag has the option omitempty, as in `map:"name,omitempty"`, is
+// omitted if it holds its type's zero value.
+//
+// Returns error if v is not a struct or a non-nil pointer to a struct.
+func Encode(v any, opts *Options) (map[string]any, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.New(nil, "cannot encode %T as map", v)
+	}
+	tag := "map"
+	if opts != nil && opts.Tag != "" {
+		tag = opts.Tag
+	}
+	m := make(map[string]any)
+	encodeStruct(rv, tag, m)
+	return m, nil
+}
+
+type decoder struct {
+	tag    string
+	strict bool
+	hook   func(any, reflect.Type) (any, error)
+	errs   []error
+}
+
+// field describes the key of a struct field.
+type field struct {
+	key       string
+	tagged    bool
+	omitempty bool
+	index     []int
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// fields returns the keyed fields of the struct type t, including those of
+// embedded structs.
+func fields(t reflect.Type, tag string) []field {
+	var fs []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		text, tagged := f.Tag.Lookup(tag)
+		name, opts, _ := strings.Cut(text, ",")
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct && !isText(ft) {
+			for _, nested := range fields(ft, tag) {
+				nested.index = append([]int{i}, nested.index...)
+				fs = append(fs, nested)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name, tagged = f.Name, false
+		}
+		fs = append(fs, field{
+			key:       name,
+			tagged:    tagged,
+			omitempty: strings.Contains(","+opts+",", ",omitempty,"),
+			index:     []int{i},
+		})
+	}
+	return fs
+}
+
+func (d *decoder) errorf(path, format string, args ...any) {
+	if path == "" {
+		path = "value"
+	}
+	d.errs = append(d.errs, errors.NewSkip(nil, 1, "%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+// decode stores the value x, found at path, in v.
+func (d *decoder) decode(path string, x any, v reflect.Value) {
+	if d.hook != nil {
+		var err error
+		if x, err = d.hook(x, v.Type()); err != nil {
+			d.errorf(path, "%s", err)
+			return
+		}
+	}
+	if x == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return
+	}
+	xv := reflect.ValueOf(x)
+	if xv.Type().AssignableTo(v.Type()) {
+		v.Set(xv)
+		return
+	}
+	if s, ok := x.(string); ok && reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		if err := convert.Set(v, s, ","); err != nil {
+			d.errorf(path, "%s", err)
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		d.decode(path, x, v.Elem())
+	case reflect.Interface:
+		if !xv.Type().Implements(v.Type()) {
+			d.errorf(path, "cannot use %T as %s", x, v.Type())
+			return
+		}
+		v.Set(xv)
+	case reflect.Struct:
+		m, ok := stringMap(xv)
+		if !ok {
+			d.errorf(path, "expected map, got %T", x)
+			return
+		}
+		d.decodeStruct(path, m, v)
+	case reflect.Map:
+		if xv.Kind() != reflect.Map {
+			d.errorf(path, "expected map, got %T", x)
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), xv.Len()))
+		}
+		kt, et := v.Type().Key(), v.Type().Elem()
+		for _, k := range sortedKeys(xv) {
+			kpath := fmt.Sprintf("%s[%v]", path, k)
+			key := reflect.New(kt).Elem()
+			d.decode(kpath, k.Interface(), key)
+			elem := reflect.New(et).Elem()
+			d.decode(kpath, xv.MapIndex(k).Interface(), elem)
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.Slice, reflect.Array:
+		if xv.Kind() != reflect.Slice && xv.Kind() != reflect.Array {
+			d.errorf(path, "expected list, got %T", x)
+			return
+		}
+		n := xv.Len()
+		if v.Kind() == reflect.Array {
+			if n > v.Len() {
+				d.errorf(path, "expected at most %d elements, got %d", v.Len(), n)
+				return
+			}
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+		}
+		for i := 0; i < n; i++ {
+			d.decode(fmt.Sprintf("%s[%d]", path, i), xv.Index(i).Interface(), v.Index(i))
+		}
+	default:
+		if err := convertScalar(xv, v); err != nil {
+			d.errorf(path, "%s", err)
+		}
+	}
+}
+
+// decodeStruct populates the struct v from m, found at path.
+func (d *decoder) decodeStruct(path string, m map[string]any, v reflect.Value) {
+	fs := fields(v.Type(), d.tag)
+	used := make(map[string]bool, len(m))
+	for _, f := range fs {
+		key, x, ok := lookup(m, f)
+		if !ok {
+			continue
+		}
+		used[key] = true
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			d.errorf(join(path, f.key), "cannot set field of nil embedded pointer to unexported struct")
+			continue
+		}
+		d.decode(join(path, f.key), x, fv)
+	}
+	if d.strict {
+		var unknown []string
+		for k := range m {
+			if !used[k] {
+				unknown = append(unknown, k)
+			}
+		}
+		sort.Strings(unknown)
+		for _, k := range unknown {
+			d.errorf(join(path, k), "unknown key")
+		}
+	}
+}
+
+// lookup returns the key and value of m for the field f: the value of its
+// tagged key, or failing that, of a key equal to its name ignoring case.
+func lookup(m map[string]any, f field) (string, any, bool) {
+	if x, ok := m[f.key]; ok {
+		return f.key, x, true
+	}
+	if f.tagged {
+		return "", nil, false
+	}
+	for k, x := range m {
+		if strings.EqualFold(k, f.key) {
+			return k, x, true
+		}
+	}
+	return "", nil, false
+}
+
+// fieldByIndex returns the field of v with the given index, allocating nil
+// embedded pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// convertScalar converts the scalar value x to the type of v and stores it in
+// v.
+func convertScalar(x, v reflect.Value) error {
+	if s, ok := x.Interface().(string); ok {
+		if v.Kind() == reflect.String {
+			v.SetString(s)
+			return nil
+		}
+		return convert.Set(v, s, ",")
+	}
+	switch {
+	case v.Kind() == reflect.String:
+		switch {
+		case isNumber(x.Kind()) || x.Kind() == reflect.Bool:
+			v.SetString(fmt.Sprint(x.Interface()))
+			return nil
+		}
+	case isNumber(v.Kind()) && isNumber(x.Kind()):
+		return convertNumber(x, v)
+	case v.Kind() == reflect.Bool && x.Kind() == reflect.Bool:
+		v.SetBool(x.Bool())
+		return nil
+	}
+	return errors.New(nil, "cannot use %s as %s", x.Type(), v.Type())
+}
+
+// convertNumber stores the number x in the number v, if v can hold it exactly.
+func convertNumber(x, v reflect.Value) error {
+	var f float64
+	var i int64
+	var u uint64
+	exactInt := true
+	switch x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = x.Int()
+		f, u = float64(i), uint64(i)
+		if i < 0 && isUnsigned(v.Kind()) {
+			return errors.New(nil, "%d overflows %s", i, v.Type())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u = x.Uint()
+		f, i = float64(u), int64(u)
+		if u > math.MaxInt64 && !isUnsigned(v.Kind()) && !isFloat(v.Kind()) {
+			return errors.New(nil, "%d overflows %s", u, v.Type())
+		}
+	default:
+		f = x.Float()
+		exactInt = f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64
+		i = int64(f)
+		if f >= 0 && f < math.MaxUint64 {
+			u = uint64(f)
+		}
+		if f == math.Trunc(f) && !isFloat(v.Kind()) &&
+			(isUnsigned(v.Kind()) && f >= math.MaxUint64 || !isUnsigned(v.Kind()) && !exactInt) {
+			return errors.New(nil, "%v overflows %s", x, v.Type())
+		}
+	}
+	switch {
+	case isFloat(v.Kind()):
+		if v.OverflowFloat(f) {
+			return errors.New(nil, "%v overflows %s", x, v.Type())
+		}
+		v.SetFloat(f)
+	case !exactInt && !isUnsigned(v.Kind()) || isUnsigned(v.Kind()) && (f != math.Trunc(f) || f < 0):
+		return errors.New(nil, "%v is not an integer", x)
+	case isUnsigned(v.Kind()):
+		if v.OverflowUint(u) {
+			return errors.New(nil, "%v overflows %s", x, v.Type())
+		}
+		v.SetUint(u)
+	default:
+		if v.OverflowInt(i) {
+			return errors.New(nil, "%v overflows %s", x, v.Type())
+		}
+		v.SetInt(i)
+	}
+	return nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+func isUnsigned(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+// isText reports whether values of type t are encoded as text, and so are
+// not treated as structs.
+func isText(t reflect.Type) bool {
+	return t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// stringMap returns the map x with its keys as strings, accepting maps with
+// string keys and with keys of interface type, as produced by some YAML
+// decoders.
+func stringMap(x reflect.Value) (map[string]any, bool) {
+	if m, ok := x.Interface().(map[string]any); ok {
+		return m, true
+	}
+	if x.Kind() != reflect.Map {
+		return nil, false
+	}
+	m := make(map[string]any, x.Len())
+	iter := x.MapRange()
+	for iter.Next() {
+		k := iter.Key()
+		if k.Kind() == reflect.Interface {
+			k = k.Elem()
+		}
+		if k.Kind() != reflect.String {
+			return nil, false
+		}
+		m[k.String()] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// sortedKeys returns the keys of the map m, ordered by their formatted value,
+// so that errors are reported in a consistent order.
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// encodeStruct stores the fields of the struct v in m.
+func encodeStruct(v reflect.Value, tag string, m map[string]any) {
+	for _, f := range fields(v.Type(), tag) {
+		fv, ok := fieldValue(v, f.index)
+		if !ok || f.omitempty && fv.IsZero() {
+			continue
+		}
+		m[f.key] = encode(fv, tag)
+	}
+}
+
+// fieldValue returns the field of v with the given index, or false if it is
+// reached through a nil embedded pointer.
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// encode returns the value v, with structs converted to maps.
+func encode(v reflect.Value, tag string) any {
+	if !holdsStruct(v.Type()) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return encode(v.Elem(), tag)
+	case reflect.Struct:
+		m := make(map[string]any)
+		encodeStruct(v, tag, m)
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = encode(v.Index(i), tag)
+		}
+		return s
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[keyString(iter.Key())] = encode(iter.Value(), tag)
+		}
+		return m
+	}
+	return v.Interface()
+}
+
+// holdsStruct reports whether values of type t are or may hold structs to be
+// converted to maps.
+func holdsStruct(t reflect.Type) bool {
+	if isText(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsStruct(t.Elem())
+	}
+	return false
+}
+
+func keyString(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if s, ok := k.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	switch {
+	case k.Kind() >= reflect.Int && k.Kind() <= reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	case isUnsigned(k.Kind()):
+		return strconv.FormatUint(k.Uint(), 10)
+	}
+	return fmt.Sprint(k.Interface())
+}
@@ -0,0 +1,280 @@
+// Package strx implements string functions which account for Unicode text as
+// it is displayed.
+//
+// Truncate and the padding functions measure text in terminal cells and never
+// split a character from its combining marks, so that they suit text in any
+// script:
+//
+//	strx.Truncate("Ünïcödé strings", 10, "…") // "Ünïcödé s…"
+//	strx.PadRight("名前", 6, ' ')               // "名前  "
+//
+// Suggest offers a correction for a mistyped word, such as an unknown command:
+//
+//	if s, ok := strx.Suggest(name, commands); ok {
+//		fmt.Printf("unknown command %q, did you mean %q?\n", name, s)
+//	}
+//
+// Slugify makes text suitable for URLs and file names:
+//
+//	strx.Slugify("Héllo, Wörld!") // "hello-world"
+package strx
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"git.sr.ht/~kvo/go-std/internal/width"
+)
+
+const (
+	zwj        = '\u200d' // zero-width joiner
+	emojiStyle = '\ufe0f' // variation selector requesting emoji presentation
+)
+
+// fold maps letters with diacritics and ligatures to their closest ASCII
+// spelling, for Slugify.
+var fold = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'œ': "oe", 'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss", 'ť': "t", 'ţ': "t",
+	'þ': "th", 'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// Graphemes splits s into the characters a reader perceives, each a base
+// character followed by any combining marks, variation selectors and emoji
+// modifiers, or joined to following characters by zero-width joiners, or a
+// pair of regional indicators forming a flag. This approximates the extended
+// grapheme clusters of Unicode Standard Annex #29, which suffices for most
+// text.
+func Graphemes(s string) []string {
+	var gs []string
+	for s != "" {
+		n := graphemeLen(s)
+		gs = append(gs, s[:n])
+		s = s[n:]
+	}
+	return gs
+}
+
+// Levenshtein returns the edit distance between a and b: the least number of
+// characters which must be inserted, deleted or substituted to turn a into b.
+// Characters are compared as runes.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			next := diag + cost
+			if row[j]+1 < next {
+				next = row[j] + 1
+			}
+			if row[j-1]+1 < next {
+				next = row[j-1] + 1
+			}
+			diag = row[j]
+			row[j] = next
+		}
+	}
+	return row[len(rb)]
+}
+
+// PadLeft returns s preceded by as many copies of pad as needed for it to
+// occupy at least w terminal cells. Copies of a wide pad character which
+// would exceed w are replaced by spaces.
+func PadLeft(s string, w int, pad rune) string {
+	return padding(Width(s), w, pad) + s
+}
+
+// PadRight returns s followed by as many copies of pad as needed for it to
+// occupy at least w terminal cells. Copies of a wide pad character which
+// would exceed w are replaced by spaces.
+func PadRight(s string, w int, pad rune) string {
+	return s + padding(Width(s), w, pad)
+}
+
+// Slugify returns s in a form suitable for URLs and file names: in lower case,
+// with common diacritics removed, and with each run of characters other than
+// letters and digits replaced by a single hyphen. Letters without an ASCII
+// equivalent, such as those of non-Latin scripts, are kept.
+func Slugify(s string) string {
+	var sb strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case fold[r] != "":
+			if hyphen && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			hyphen = false
+			sb.WriteString(fold[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if hyphen && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			hyphen = false
+			sb.WriteRune(r)
+		default:
+			hyphen = true
+		}
+	}
+	return sb.String()
+}
+
+// Suggest returns the candidate most similar to input, for offering a
+// correction such as "did you mean ...?". Candidates are compared with input
+// by their Levenshtein distance, ignoring case, and a candidate is only
+// suggested if the distance is at most a third of the length of input, and at
+// least one. Ties are broken in favour of the earlier candidate. Returns false
+// if no candidate is similar enough, or if input is itself a candidate.
+func Suggest(input string, candidates []string) (string, bool) {
+	in := strings.ToLower(input)
+	limit := utf8.RuneCountInString(in) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	best, bestDist := "", limit+1
+	for _, c := range candidates {
+		d := Levenshtein(in, strings.ToLower(c))
+		if d == 0 && c == input {
+			return "", false
+		}
+		if d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best, best != ""
+}
+
+// Truncate returns the longest prefix of s occupying at most w terminal cells,
+// cut between graphemes. If s must be shortened, tail is appended, and the
+// prefix is shortened further so that the result, including tail, occupies at
+// most w cells. ANSI escape sequences occupy no cells, and those in the part of
+// s cut off are kept after tail, so that styles set in s are still reset.
+func Truncate(s string, w int, tail string) string {
+	if Width(s) <= w {
+		return s
+	}
+	w -= Width(tail)
+	n := 0
+	i := 0
+	for i < len(s) {
+		if s[i] == '\x1b' {
+			i += width.EscapeLen(s[i:])
+			continue
+		}
+		g := graphemeLen(s[i:])
+		gw := graphemeWidth(s[i : i+g])
+		if n+gw > w {
+			break
+		}
+		n += gw
+		i += g
+	}
+	var esc strings.Builder
+	for j := i; j < len(s); {
+		if s[j] == '\x1b' {
+			l := width.EscapeLen(s[j:])
+			esc.WriteString(s[j : j+l])
+			j += l
+		} else {
+			j++
+		}
+	}
+	return s[:i] + tail + esc.String()
+}
+
+// Width returns the number of terminal cells occupied by s. ANSI escape
+// sequences occupy no cells.
+func Width(s string) int {
+	s = width.Strip(s)
+	n := 0
+	for s != "" {
+		g := graphemeLen(s)
+		n += graphemeWidth(s[:g])
+		s = s[g:]
+	}
+	return n
+}
+
+// graphemeLen returns the length in bytes of the grapheme at the start of s,
+// which must not be empty.
+func graphemeLen(s string) int {
+	r, n := utf8.DecodeRuneInString(s)
+	if r == '\r' && len(s) > 1 && s[1] == '\n' {
+		return 2
+	}
+	if isRegional(r) {
+		if r2, n2 := utf8.DecodeRuneInString(s[n:]); isRegional(r2) {
+			n += n2
+		}
+	}
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		switch {
+		case r == zwj:
+			n += size
+			if n < len(s) {
+				_, size = utf8.DecodeRuneInString(s[n:])
+				n += size
+			}
+		case extends(r):
+			n += size
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// graphemeWidth returns the number of terminal cells occupied by the grapheme
+// g: that of its base character, or two for a character shown as an emoji.
+func graphemeWidth(g string) int {
+	r, _ := utf8.DecodeRuneInString(g)
+	w := width.Rune(r)
+	if w < 2 && (isRegional(r) || strings.ContainsRune(g, emojiStyle)) {
+		return 2
+	}
+	return w
+}
+
+// extends reports whether r continues the grapheme before it.
+func extends(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r >= 0x1f3fb && r <= 0x1f3ff // emoji skin tone modifiers
+}
+
+func isRegional(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+func padding(sw, w int, pad rune) string {
+	n := w - sw
+	if n <= 0 {
+		return ""
+	}
+	pw := width.Rune(pad)
+	if pw <= 0 {
+		pad, pw = ' ', 1
+	}
+	return strings.Repeat(string(pad), n/pw) + strings.Repeat(" ", n%pw)
+}
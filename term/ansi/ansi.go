@@ -0,0 +1,311 @@
+// Package ansi implements text styling using ANSI escape sequences.
+//
+// A Style describes the colors and attributes of a piece of text, and is
+// applied by wrapping the text in the appropriate escape sequences:
+//
+//	warn := ansi.Style{Bold: true, FG: ansi.Yellow}
+//	fmt.Println(warn.Wrap("warning:"), msg)
+//
+// Not every terminal supports every color, and escape sequences are unwanted
+// when output is redirected to a file or pipe. A Profile describes the color
+// capabilities of an output, and styles are rendered according to a profile,
+// so that colors are downgraded to the nearest color the output supports, or
+// omitted entirely. The profile of an output is found using Detect, which
+// honours the NO_COLOR convention (https://no-color.org).
+package ansi
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/width"
+	"git.sr.ht/~kvo/go-std/term"
+)
+
+// Profile represents the color capabilities of an output.
+type Profile int
+
+const (
+	// NoColor indicates an output which does not support escape sequences.
+	NoColor Profile = iota
+	// Basic indicates an output supporting the 16 basic colors.
+	Basic
+	// Color256 indicates an output supporting the 256-color palette.
+	Color256
+	// TrueColor indicates an output supporting 24-bit RGB colors.
+	TrueColor
+)
+
+// DefaultProfile is the profile used by Style.Wrap. It is initialized to the
+// profile of the standard output stream, and may be overridden.
+var DefaultProfile = Detect(os.Stdout)
+
+// Detect returns the profile of the output f. Detect returns NoColor if f is not
+// a terminal, or if the NO_COLOR environment variable is set to a non-empty
+// value. Otherwise, the profile is chosen based on the TERM and COLORTERM
+// environment variables.
+//
+// On Windows, Detect enables the processing of escape sequences on f as a side
+// effect, and returns NoColor if this fails.
+func Detect(f *os.File) Profile {
+	fd := int(f.Fd())
+	if os.Getenv("NO_COLOR") != "" || !term.IsTerminal(fd) {
+		return NoColor
+	}
+	if term.EnableVirtualTerminal(fd) != nil {
+		return NoColor
+	}
+	termenv := os.Getenv("TERM")
+	switch colorterm := os.Getenv("COLORTERM"); {
+	case termenv == "dumb":
+		return NoColor
+	case colorterm == "truecolor" || colorterm == "24bit":
+		return TrueColor
+	case strings.Contains(termenv, "256color"):
+		return Color256
+	case termenv == "":
+		// Windows consoles supporting escape sequences set no TERM, but
+		// support 24-bit color.
+		return TrueColor
+	}
+	return Basic
+}
+
+// Wrap returns text wrapped in the escape sequences needed to render it in
+// style s, with colors downgraded as needed to those supported by p. If p is
+// NoColor or s is the zero Style, text is returned unchanged.
+func (p Profile) Wrap(s Style, text string) string {
+	if p == NoColor {
+		return text
+	}
+	var codes []string
+	for _, attr := range []struct {
+		set  bool
+		code string
+	}{
+		{s.Bold, "1"},
+		{s.Dim, "2"},
+		{s.Italic, "3"},
+		{s.Underline, "4"},
+		{s.Blink, "5"},
+		{s.Reverse, "7"},
+		{s.Strikethrough, "9"},
+	} {
+		if attr.set {
+			codes = append(codes, attr.code)
+		}
+	}
+	if code := s.FG.code(p, false); code != "" {
+		codes = append(codes, code)
+	}
+	if code := s.BG.code(p, true); code != "" {
+		codes = append(codes, code)
+	}
+	if len(codes) == 0 {
+		return text
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + text + "\x1b[0m"
+}
+
+// Style represents a combination of text attributes and colors. The zero value
+// of a Style renders text in the terminal's default style.
+type Style struct {
+	Bold          bool
+	Dim           bool
+	Italic        bool
+	Underline     bool
+	Blink         bool
+	Reverse       bool
+	Strikethrough bool
+	FG            Color
+	BG            Color
+}
+
+// Wrap is equivalent to DefaultProfile.Wrap(s, text).
+func (s Style) Wrap(text string) string {
+	return DefaultProfile.Wrap(s, text)
+}
+
+type colorMode uint8
+
+const (
+	modeDefault colorMode = iota
+	modeBasic
+	mode256
+	modeRGB
+)
+
+// Color represents a foreground or background color. The zero value of a Color
+// is the terminal's default color.
+type Color struct {
+	mode    colorMode
+	index   uint8
+	r, g, b uint8
+}
+
+// The 16 basic colors.
+var (
+	Black         = Color{mode: modeBasic, index: 0}
+	Red           = Color{mode: modeBasic, index: 1}
+	Green         = Color{mode: modeBasic, index: 2}
+	Yellow        = Color{mode: modeBasic, index: 3}
+	Blue          = Color{mode: modeBasic, index: 4}
+	Magenta       = Color{mode: modeBasic, index: 5}
+	Cyan          = Color{mode: modeBasic, index: 6}
+	White         = Color{mode: modeBasic, index: 7}
+	BrightBlack   = Color{mode: modeBasic, index: 8}
+	BrightRed     = Color{mode: modeBasic, index: 9}
+	BrightGreen   = Color{mode: modeBasic, index: 10}
+	BrightYellow  = Color{mode: modeBasic, index: 11}
+	BrightBlue    = Color{mode: modeBasic, index: 12}
+	BrightMagenta = Color{mode: modeBasic, index: 13}
+	BrightCyan    = Color{mode: modeBasic, index: 14}
+	BrightWhite   = Color{mode: modeBasic, index: 15}
+)
+
+// Hex returns the 24-bit color described by s, which must be of the form
+// "#rrggbb" or "#rgb". Returns error if s is not of either form.
+func Hex(s string) (Color, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if ok && len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if !ok || len(hex) != 6 {
+		return Color{}, errors.New(nil, "invalid hex color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, errors.New(nil, "invalid hex color %q", s)
+	}
+	return RGB(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+}
+
+// Index returns color n of the 256-color palette. Colors 0 to 15 are the basic
+// colors, colors 16 to 231 form a 6×6×6 color cube, and colors 232 to 255 form
+// a grayscale ramp.
+func Index(n uint8) Color {
+	return Color{mode: mode256, index: n}
+}
+
+// RGB returns the 24-bit color with the given red, green and blue components.
+func RGB(r, g, b uint8) Color {
+	return Color{mode: modeRGB, r: r, g: g, b: b}
+}
+
+// code returns the SGR parameters selecting c as a foreground or background
+// color, downgraded as needed to a color supported by p.
+func (c Color) code(p Profile, bg bool) string {
+	base := 38
+	if bg {
+		base = 48
+	}
+	switch c.mode {
+	case modeDefault:
+		return ""
+	case modeRGB:
+		switch p {
+		case TrueColor:
+			return strconv.Itoa(base) + ";2;" + strconv.Itoa(int(c.r)) + ";" +
+				strconv.Itoa(int(c.g)) + ";" + strconv.Itoa(int(c.b))
+		case Color256:
+			return Color{mode: mode256, index: nearest256(c.r, c.g, c.b)}.code(p, bg)
+		default:
+			return Color{mode: modeBasic, index: nearestBasic(c.r, c.g, c.b)}.code(p, bg)
+		}
+	case mode256:
+		if c.index < 16 {
+			return Color{mode: modeBasic, index: c.index}.code(p, bg)
+		}
+		if p < Color256 {
+			r, g, b := palette(c.index)
+			return Color{mode: modeBasic, index: nearestBasic(r, g, b)}.code(p, bg)
+		}
+		return strconv.Itoa(base) + ";5;" + strconv.Itoa(int(c.index))
+	}
+	n := 30
+	if bg {
+		n = 40
+	}
+	if c.index >= 8 {
+		n += 60
+	}
+	return strconv.Itoa(n + int(c.index%8))
+}
+
+// basic holds the RGB values of the basic colors, as used by xterm.
+var basic = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cube holds the component levels of the 256-color palette's color cube.
+var cube = [6]uint8{0, 95, 135, 175, 215, 255}
+
+func distance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr, dg, db := int(r1)-int(r2), int(g1)-int(g2), int(b1)-int(b2)
+	return dr*dr + dg*dg + db*db
+}
+
+func nearest256(r, g, b uint8) uint8 {
+	level := func(v uint8) int {
+		best := 0
+		for i, l := range cube {
+			if absDiff(v, l) < absDiff(v, cube[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := level(r), level(g), level(b)
+	color := uint8(16 + 36*ri + 6*gi + bi)
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayIndex := (avg - 3) / 10
+	if grayIndex < 0 {
+		grayIndex = 0
+	} else if grayIndex > 23 {
+		grayIndex = 23
+	}
+	gray := uint8(8 + 10*grayIndex)
+	if distance(r, g, b, gray, gray, gray) < distance(r, g, b, cube[ri], cube[gi], cube[bi]) {
+		return uint8(232 + grayIndex)
+	}
+	return color
+}
+
+func nearestBasic(r, g, b uint8) uint8 {
+	best := 0
+	for i, c := range basic {
+		if distance(r, g, b, c[0], c[1], c[2]) < distance(r, g, b, basic[best][0], basic[best][1], basic[best][2]) {
+			best = i
+		}
+	}
+	return uint8(best)
+}
+
+func palette(n uint8) (r, g, b uint8) {
+	switch {
+	case n < 16:
+		return basic[n][0], basic[n][1], basic[n][2]
+	case n < 232:
+		n -= 16
+		return cube[n/36], cube[n/6%6], cube[n%6]
+	}
+	v := 8 + 10*(n-232)
+	return v, v, v
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// Strip returns s with all ANSI escape sequences removed.
+func Strip(s string) string {
+	return width.Strip(s)
+}
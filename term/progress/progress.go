@@ -0,0 +1,355 @@
+// Package progress implements progress bars and spinners.
+//
+// A Progress renders a group of bars to an output, redrawing them in place at
+// a regular interval. Each Bar tracks the progress of a single task, and may
+// be updated from any goroutine:
+//
+//	p := progress.New(os.Stderr)
+//	defer p.Stop()
+//	bar := p.AddBar("download", resp.ContentLength)
+//	_, err := io.Copy(f, bar.Reader(resp.Body))
+//	bar.Done()
+//
+// A bar whose total is unknown is displayed as a spinner, together with the
+// amount of work done so far. Bars display their rate of progress, and, where
+// the total is known, an estimate of the time remaining.
+//
+// If the output is not a terminal, such as when it is redirected to a file or
+// pipe, bars are not drawn. Instead, a single summary line is written for each
+// bar once it is done, so that logs are not filled with redrawn bars.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/internal/width"
+	"git.sr.ht/~kvo/go-std/term"
+)
+
+// interval is the interval at which bars are redrawn.
+const interval = 100 * time.Millisecond
+
+var frames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Progress renders a group of progress bars to an output.
+type Progress struct {
+	w     io.Writer
+	fd    int
+	tty   bool
+	mu    sync.Mutex
+	bars  []*Bar
+	lines int
+	frame int
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+// New returns a Progress which renders bars to w, and starts rendering. If w is
+// not an *os.File referring to a terminal, bars are summarized instead of
+// drawn. Stop must be called once all bars are done.
+func New(w io.Writer) *Progress {
+	p := &Progress{
+		w:    w,
+		fd:   -1,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		p.fd = int(f.Fd())
+		p.tty = true
+	}
+	go p.run()
+	return p
+}
+
+// AddBar adds a bar with the given label, which is complete once total units of
+// work have been done. If total is zero or negative, the bar is displayed as a
+// spinner.
+func (p *Progress) AddBar(label string, total int64) *Bar {
+	b := &Bar{p: p, label: label, start: time.Now()}
+	b.total.Store(total)
+	p.mu.Lock()
+	p.bars = append(p.bars, b)
+	p.mu.Unlock()
+	return b
+}
+
+// AddSpinner adds a spinner with the given label. It is equivalent to
+// AddBar(label, 0).
+func (p *Progress) AddSpinner(label string) *Bar {
+	return p.AddBar(label, 0)
+}
+
+// Stop stops rendering, and draws the final state of every bar. If the output
+// is not a terminal, a summary is written for every bar not yet summarized.
+// Bars should not be updated after Stop is called.
+func (p *Progress) Stop() {
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.tty {
+			p.render(true)
+			return
+		}
+		for _, b := range p.bars {
+			if !b.summarized {
+				p.summarize(b)
+			}
+		}
+	})
+}
+
+func (p *Progress) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+		p.mu.Lock()
+		if p.tty {
+			p.frame = (p.frame + 1) % len(frames)
+			p.render(false)
+		} else {
+			for _, b := range p.bars {
+				if b.done.Load() && !b.summarized {
+					p.summarize(b)
+				}
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// render redraws every bar in place. The caller must hold p.mu.
+func (p *Progress) render(final bool) {
+	cols := 80
+	if size, err := term.GetSize(p.fd); err == nil && size.Width > 0 {
+		cols = size.Width
+	}
+	var sb strings.Builder
+	if p.lines > 0 {
+		fmt.Fprintf(&sb, "\r\x1b[%dA", p.lines)
+	}
+	for _, b := range p.bars {
+		sb.WriteString("\r\x1b[2K")
+		sb.WriteString(b.line(cols-1, frames[p.frame]))
+		sb.WriteString("\n")
+	}
+	if final {
+		sb.WriteString("\x1b[?25h")
+	} else {
+		sb.WriteString("\x1b[?25l")
+	}
+	p.lines = len(p.bars)
+	io.WriteString(p.w, sb.String())
+}
+
+// summarize writes a summary line for b. The caller must hold p.mu.
+func (p *Progress) summarize(b *Bar) {
+	b.summarized = true
+	current, total, elapsed := b.current.Load(), b.total.Load(), b.elapsed()
+	status := "done"
+	if !b.done.Load() {
+		status = "stopped"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s, %s", b.label, status, b.format(current))
+	if total > 0 {
+		fmt.Fprintf(&sb, " of %s", b.format(total))
+	}
+	fmt.Fprintf(&sb, " in %s", round(elapsed))
+	if rate := b.rate(); rate > 0 {
+		fmt.Fprintf(&sb, " (%s/s)", b.format(int64(rate)))
+	}
+	sb.WriteString("\n")
+	io.WriteString(p.w, sb.String())
+}
+
+// Bar tracks the progress of a single task. A Bar is safe for concurrent use
+// by multiple goroutines.
+type Bar struct {
+	p          *Progress
+	label      string
+	start      time.Time
+	current    atomic.Int64
+	total      atomic.Int64
+	bytes      atomic.Bool
+	done       atomic.Bool
+	end        atomic.Int64
+	summarized bool
+}
+
+// Add records that n more units of work have been done.
+func (b *Bar) Add(n int64) {
+	b.current.Add(n)
+}
+
+// Done marks b as complete. A bar's rate and elapsed time stop changing once it
+// is done.
+func (b *Bar) Done() {
+	if b.done.CompareAndSwap(false, true) {
+		b.end.Store(int64(time.Since(b.start)))
+	}
+}
+
+// Reader returns a reader which reads from r and adds the number of bytes read
+// to b. Reader also causes b to display its progress in bytes.
+func (b *Bar) Reader(r io.Reader) io.Reader {
+	b.SetBytes(true)
+	return &reader{r, b}
+}
+
+// Set records that n units of work have been done in total.
+func (b *Bar) Set(n int64) {
+	b.current.Store(n)
+}
+
+// SetBytes sets whether b displays its progress in bytes, using binary unit
+// prefixes, rather than as a plain count.
+func (b *Bar) SetBytes(on bool) {
+	b.bytes.Store(on)
+}
+
+// SetTotal sets the total units of work needed to complete b. If total is zero
+// or negative, b is displayed as a spinner.
+func (b *Bar) SetTotal(total int64) {
+	b.total.Store(total)
+}
+
+// Writer returns a writer which writes to w and adds the number of bytes
+// written to b. Writer also causes b to display its progress in bytes.
+func (b *Bar) Writer(w io.Writer) io.Writer {
+	b.SetBytes(true)
+	return &writer{w, b}
+}
+
+func (b *Bar) elapsed() time.Duration {
+	if b.done.Load() {
+		return time.Duration(b.end.Load())
+	}
+	return time.Since(b.start)
+}
+
+func (b *Bar) format(n int64) string {
+	if !b.bytes.Load() {
+		return fmt.Sprint(n)
+	}
+	const units = "KMGTPE"
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n) / 1024
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f %ciB", v, units[i])
+}
+
+// line returns the rendered form of b, at most cols cells wide.
+func (b *Bar) line(cols int, frame string) string {
+	current, total, elapsed := b.current.Load(), b.total.Load(), b.elapsed()
+	var info []string
+	rate := b.rate()
+	if total > 0 {
+		pct := float64(current) / float64(total)
+		if pct > 1 {
+			pct = 1
+		} else if pct < 0 {
+			pct = 0
+		}
+		info = append(info,
+			fmt.Sprintf("%3.0f%%", pct*100),
+			b.format(current)+"/"+b.format(total),
+		)
+		if rate > 0 {
+			info = append(info, b.format(int64(rate))+"/s")
+		}
+		if b.done.Load() {
+			info = append(info, "in "+round(elapsed).String())
+		} else if rate > 0 && current < total {
+			eta := time.Duration(float64(total-current) / rate * float64(time.Second))
+			info = append(info, "ETA "+round(eta).String())
+		}
+		suffix := " " + strings.Join(info, " ")
+		avail := cols - width.String(b.label) - width.String(suffix) - 3
+		if avail < 10 {
+			return truncate(b.label+suffix, cols)
+		}
+		fill := int(pct * float64(avail))
+		bar := strings.Repeat("=", fill)
+		if fill < avail {
+			bar += ">" + strings.Repeat(" ", avail-fill-1)
+		}
+		return truncate(b.label+" ["+bar+"]"+suffix, cols)
+	}
+	if b.done.Load() {
+		frame = "✓"
+	}
+	info = append(info, frame, b.label)
+	if current > 0 {
+		info = append(info, b.format(current))
+	}
+	if rate > 0 {
+		info = append(info, b.format(int64(rate))+"/s")
+	}
+	info = append(info, round(elapsed).String())
+	return truncate(strings.Join(info, " "), cols)
+}
+
+// rate returns the average number of units of work done per second.
+func (b *Bar) rate() float64 {
+	elapsed := b.elapsed().Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(b.current.Load()) / elapsed
+}
+
+func round(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
+
+// truncate returns the longest prefix of s occupying at most cols cells.
+func truncate(s string, cols int) string {
+	return width.Truncate(s, cols, "")
+}
+
+type reader struct {
+	r io.Reader
+	b *Bar
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.b.Add(int64(n))
+	return n, err
+}
+
+type writer struct {
+	w io.Writer
+	b *Bar
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.b.Add(int64(n))
+	return n, err
+}
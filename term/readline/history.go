@@ -0,0 +1,64 @@
+package readline
+
+import (
+	"bufio"
+	"io"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// History holds a list of previously entered lines, oldest first. The zero
+// value of a History is an empty, unbounded history ready to use.
+type History struct {
+	// Max is the maximum number of entries retained. When the history is
+	// full, the oldest entry is discarded. If Max is zero or negative, the
+	// history is unbounded.
+	Max int
+
+	entries []string
+}
+
+// Add appends line to h. Empty lines, and lines identical to the most recent
+// entry, are not added.
+func (h *History) Add(line string) {
+	if line == "" {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if h.Max > 0 && len(h.entries) > h.Max {
+		h.entries = append([]string(nil), h.entries[len(h.entries)-h.Max:]...)
+	}
+}
+
+// Entries returns a copy of the entries of h, oldest first.
+func (h *History) Entries() []string {
+	return append([]string(nil), h.entries...)
+}
+
+// Load reads newline-separated entries from r and adds them to h.
+func (h *History) Load(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		h.Add(s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return errors.New(err, "cannot load history")
+	}
+	return nil
+}
+
+// Save writes the entries of h to w, one per line, oldest first.
+func (h *History) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, entry := range h.entries {
+		bw.WriteString(entry)
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		return errors.New(err, "cannot save history")
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package readline
+
+import (
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+type keyCode int
+
+const (
+	keyNone keyCode = iota
+	keyRune
+	keyEnter
+	keyInterrupt
+	keyEOF
+	keyBackspace
+	keyDelete
+	keyHome
+	keyEnd
+	keyLeft
+	keyRight
+	keyUp
+	keyDown
+	keyWordLeft
+	keyWordRight
+	keyKillWordLeft
+	keyKillWordRight
+	keyKillEnd
+	keyKillStart
+	keyYank
+	keyTranspose
+	keyClear
+	keyTab
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// control maps control characters to the keys they represent.
+var control = map[rune]keyCode{
+	'\x01': keyHome,
+	'\x02': keyLeft,
+	'\x03': keyInterrupt,
+	'\x04': keyEOF,
+	'\x05': keyEnd,
+	'\x06': keyRight,
+	'\x08': keyBackspace,
+	'\t':   keyTab,
+	'\n':   keyEnter,
+	'\x0b': keyKillEnd,
+	'\x0c': keyClear,
+	'\r':   keyEnter,
+	'\x0e': keyDown,
+	'\x10': keyUp,
+	'\x14': keyTranspose,
+	'\x15': keyKillStart,
+	'\x17': keyKillWordLeft,
+	'\x19': keyYank,
+	'\x7f': keyBackspace,
+}
+
+// sequences maps the parameters and final byte of control sequences to the
+// keys they represent.
+var sequences = map[string]keyCode{
+	"A":  keyUp,
+	"B":  keyDown,
+	"C":  keyRight,
+	"D":  keyLeft,
+	"H":  keyHome,
+	"F":  keyEnd,
+	"1~": keyHome,
+	"3~": keyDelete,
+	"4~": keyEnd,
+	"7~": keyHome,
+	"8~": keyEnd,
+}
+
+func (e *Editor) readKey() (key, error) {
+	r, err := e.readRune()
+	if err != nil {
+		return key{}, err
+	}
+	if r != '\x1b' {
+		if code, ok := control[r]; ok {
+			return key{code: code}, nil
+		}
+		if r < ' ' {
+			return key{}, nil
+		}
+		return key{code: keyRune, r: r}, nil
+	}
+	r, err = e.readRune()
+	if err != nil {
+		return key{}, err
+	}
+	switch r {
+	case '[', 'O':
+		var seq []rune
+		for {
+			r, err := e.readRune()
+			if err != nil {
+				return key{}, err
+			}
+			seq = append(seq, r)
+			if r >= 0x40 && r <= 0x7e {
+				break
+			}
+		}
+		return key{code: sequences[string(seq)]}, nil
+	case 'b', 'B':
+		return key{code: keyWordLeft}, nil
+	case 'f', 'F':
+		return key{code: keyWordRight}, nil
+	case 'd', 'D':
+		return key{code: keyKillWordRight}, nil
+	case '\x7f', '\x08':
+		return key{code: keyKillWordLeft}, nil
+	}
+	return key{}, nil
+}
+
+func (e *Editor) readRune() (rune, error) {
+	r, _, err := e.r.ReadRune()
+	if err != nil {
+		return 0, errors.New(err, "cannot read input")
+	}
+	return r, nil
+}
@@ -0,0 +1,369 @@
+// Package readline implements interactive line editing.
+//
+// An Editor reads lines of input from a terminal, allowing the user to edit
+// each line before it is returned. Editing uses Emacs-style key bindings:
+//
+//	Ctrl-A, Home        move to the start of the line
+//	Ctrl-E, End         move to the end of the line
+//	Ctrl-B, Left        move back one character
+//	Ctrl-F, Right       move forward one character
+//	Alt-B               move back one word
+//	Alt-F               move forward one word
+//	Ctrl-H, Backspace   delete the character before the cursor
+//	Ctrl-D, Delete      delete the character under the cursor
+//	Ctrl-W, Alt-Bksp    cut the word before the cursor
+//	Alt-D               cut the word after the cursor
+//	Ctrl-K              cut from the cursor to the end of the line
+//	Ctrl-U              cut from the start of the line to the cursor
+//	Ctrl-Y              paste the most recently cut text
+//	Ctrl-T              transpose the two characters before the cursor
+//	Ctrl-P, Up          recall the previous history entry
+//	Ctrl-N, Down        recall the next history entry
+//	Ctrl-L              clear the screen
+//	Tab                 complete the word before the cursor
+//
+// Pressing Ctrl-D on an empty line causes ReadLine to return EOF, and pressing
+// Ctrl-C causes ReadLine to return ErrInterrupt. Both errors are raised within
+// ReadLine, so that their context refers to ReadLine rather than to their
+// declaration.
+//
+// If the input of an Editor is not a terminal, lines are read without editing,
+// so that programs built on package readline can also be driven by pipes and
+// scripts.
+//
+// An Editor assumes that each line fits on a single row of the terminal.
+package readline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/width"
+	"git.sr.ht/~kvo/go-std/term"
+)
+
+var (
+	// EOF is returned by ReadLine when no more input is available, or when
+	// the user presses Ctrl-D on an empty line.
+	EOF = errors.New(nil, "EOF")
+
+	// ErrInterrupt is returned by ReadLine when the user presses Ctrl-C.
+	ErrInterrupt = errors.New(nil, "interrupt")
+)
+
+// Completer returns the possible completions of the text before the cursor.
+// The line being edited is given by line, and the cursor lies at byte offset
+// pos within line. The completer returns the byte offset start at which the
+// text to be completed begins, and candidates which may replace
+// line[start:pos].
+type Completer func(line string, pos int) (start int, candidates []string)
+
+// Editor represents an interactive line editor.
+type Editor struct {
+	// Prompt is written before the first line of each input.
+	Prompt string
+
+	// ContinuationPrompt is written before each continuation line of a
+	// multi-line input.
+	ContinuationPrompt string
+
+	// Complete, if not nil, is called when the user presses Tab to find
+	// completions for the text before the cursor. If there is a single
+	// candidate, it is inserted; otherwise the longest common prefix of the
+	// candidates is inserted, and if this adds nothing, all candidates are
+	// listed.
+	Complete Completer
+
+	// Continue, if not nil, is called each time the user enters a line, with
+	// all lines of the input entered so far joined by newlines. If Continue
+	// returns true, the input is incomplete, and another line is read using
+	// ContinuationPrompt.
+	Continue func(input string) bool
+
+	// History holds previously entered lines. If History is nil, entered
+	// lines are not recorded and cannot be recalled.
+	History *History
+
+	in     *os.File
+	out    io.Writer
+	r      *bufio.Reader
+	killed []rune
+}
+
+// New returns an Editor which reads input from in and writes output to out.
+// The returned Editor has an empty History.
+func New(in *os.File, out io.Writer) *Editor {
+	return &Editor{
+		in:      in,
+		out:     out,
+		r:       bufio.NewReader(in),
+		History: new(History),
+	}
+}
+
+// ReadLine reads a single input from the user, which may span multiple lines if
+// e.Continue is set, and returns it without any trailing newline. Each line of
+// the input is added to e.History.
+func (e *Editor) ReadLine() (string, error) {
+	fd := int(e.in.Fd())
+	read := e.readPlain
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return "", errors.Wrap(err)
+		}
+		defer term.Restore(fd, state)
+		read = e.edit
+	}
+	var lines []string
+	prompt := e.Prompt
+	for {
+		line, err := read(prompt)
+		switch {
+		case err == EOF || err == ErrInterrupt:
+			return "", errors.Raise(err)
+		case err != nil:
+			return "", err
+		}
+		if e.History != nil {
+			e.History.Add(line)
+		}
+		lines = append(lines, line)
+		input := strings.Join(lines, "\n")
+		if e.Continue == nil || !e.Continue(input) {
+			return input, nil
+		}
+		prompt = e.ContinuationPrompt
+	}
+}
+
+func (e *Editor) readPlain(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.r.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", EOF
+	} else if err != nil && err != io.EOF {
+		return "", errors.New(err, "cannot read input")
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// line holds the state of a single line being edited.
+type line struct {
+	e      *Editor
+	prompt string
+	buf    []rune
+	pos    int
+	hist   int
+	saved  []rune
+}
+
+func (e *Editor) edit(prompt string) (string, error) {
+	l := &line{e: e, prompt: prompt}
+	if e.History != nil {
+		l.hist = len(e.History.entries)
+	}
+	l.refresh()
+	for {
+		k, err := e.readKey()
+		if err != nil {
+			fmt.Fprint(e.out, "\r\n")
+			return "", err
+		}
+		switch k.code {
+		case keyEnter:
+			fmt.Fprint(e.out, "\r\n")
+			return string(l.buf), nil
+		case keyInterrupt:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupt
+		case keyEOF:
+			if len(l.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", EOF
+			}
+			l.delete(l.pos, l.pos+1)
+		case keyDelete:
+			l.delete(l.pos, l.pos+1)
+		case keyBackspace:
+			l.delete(l.pos-1, l.pos)
+		case keyHome:
+			l.pos = 0
+		case keyEnd:
+			l.pos = len(l.buf)
+		case keyLeft:
+			if l.pos > 0 {
+				l.pos--
+			}
+		case keyRight:
+			if l.pos < len(l.buf) {
+				l.pos++
+			}
+		case keyWordLeft:
+			l.pos = l.wordStart()
+		case keyWordRight:
+			l.pos = l.wordEnd()
+		case keyKillWordLeft:
+			l.kill(l.wordStart(), l.pos)
+		case keyKillWordRight:
+			l.kill(l.pos, l.wordEnd())
+		case keyKillEnd:
+			l.kill(l.pos, len(l.buf))
+		case keyKillStart:
+			l.kill(0, l.pos)
+		case keyYank:
+			l.insert(e.killed...)
+		case keyTranspose:
+			l.transpose()
+		case keyUp:
+			l.recall(-1)
+		case keyDown:
+			l.recall(1)
+		case keyClear:
+			term.ClearScreen(e.out)
+		case keyTab:
+			l.complete()
+		case keyRune:
+			l.insert(k.r)
+		default:
+			continue
+		}
+		l.refresh()
+	}
+}
+
+func (l *line) complete() {
+	if l.e.Complete == nil {
+		fmt.Fprint(l.e.out, "\a")
+		return
+	}
+	text := string(l.buf)
+	pos := len(string(l.buf[:l.pos]))
+	start, candidates := l.e.Complete(text, pos)
+	if start < 0 || start > pos || len(candidates) == 0 {
+		fmt.Fprint(l.e.out, "\a")
+		return
+	}
+	replacement := candidates[0]
+	if len(candidates) > 1 {
+		replacement = commonPrefix(candidates)
+		if replacement == text[start:pos] {
+			fmt.Fprintf(l.e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+			return
+		}
+	}
+	head := text[:start] + replacement
+	l.buf = []rune(head + text[pos:])
+	l.pos = utf8.RuneCountInString(head)
+}
+
+func (l *line) delete(from, to int) {
+	if from < 0 || to > len(l.buf) || from >= to {
+		return
+	}
+	l.buf = append(l.buf[:from], l.buf[to:]...)
+	l.pos = from
+}
+
+func (l *line) insert(r ...rune) {
+	buf := make([]rune, 0, len(l.buf)+len(r))
+	buf = append(buf, l.buf[:l.pos]...)
+	buf = append(buf, r...)
+	l.buf = append(buf, l.buf[l.pos:]...)
+	l.pos += len(r)
+}
+
+func (l *line) kill(from, to int) {
+	if from >= to {
+		return
+	}
+	l.e.killed = append([]rune(nil), l.buf[from:to]...)
+	l.delete(from, to)
+}
+
+func (l *line) recall(dir int) {
+	h := l.e.History
+	if h == nil {
+		return
+	}
+	next := l.hist + dir
+	if next < 0 || next > len(h.entries) {
+		return
+	}
+	if l.hist == len(h.entries) {
+		l.saved = l.buf
+	}
+	l.hist = next
+	if next == len(h.entries) {
+		l.buf = l.saved
+	} else {
+		l.buf = []rune(h.entries[next])
+	}
+	l.pos = len(l.buf)
+}
+
+func (l *line) refresh() {
+	col := width.String(l.prompt) + width.String(string(l.buf[:l.pos]))
+	seq := "\r" + l.prompt + string(l.buf) + "\x1b[K\r"
+	if col > 0 {
+		seq += fmt.Sprintf("\x1b[%dC", col)
+	}
+	fmt.Fprint(l.e.out, seq)
+}
+
+func (l *line) transpose() {
+	if l.pos == 0 || len(l.buf) < 2 {
+		return
+	}
+	if l.pos == len(l.buf) {
+		l.pos--
+	}
+	l.buf[l.pos-1], l.buf[l.pos] = l.buf[l.pos], l.buf[l.pos-1]
+	l.pos++
+}
+
+func (l *line) wordEnd() int {
+	i := l.pos
+	for i < len(l.buf) && !isWord(l.buf[i]) {
+		i++
+	}
+	for i < len(l.buf) && isWord(l.buf[i]) {
+		i++
+	}
+	return i
+}
+
+func (l *line) wordStart() int {
+	i := l.pos
+	for i > 0 && !isWord(l.buf[i-1]) {
+		i--
+	}
+	for i > 0 && isWord(l.buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+func commonPrefix(s []string) string {
+	prefix := []rune(s[0])
+	for _, c := range s[1:] {
+		r := []rune(c)
+		n := 0
+		for n < len(prefix) && n < len(r) && prefix[n] == r[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
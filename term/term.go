@@ -0,0 +1,247 @@
+// Package term implements terminal control.
+//
+// Package term provides the low-level functionality needed to build
+// interactive command-line programs: detecting whether a file descriptor
+// refers to a terminal, switching a terminal into raw mode and back, querying
+// and watching the size of the terminal window, and moving the cursor.
+//
+// Raw mode disables the line editing, echoing and signal generation normally
+// performed by the terminal, so that a program receives each key press as it
+// happens. A program entering raw mode should always restore the previous
+// state before exiting:
+//
+//	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+//	if err != nil {
+//		return err
+//	}
+//	defer term.Restore(int(os.Stdin.Fd()), state)
+//
+// Terminal state is handled natively on Unix systems using termios, and on
+// Windows using the console API. On other systems, functions which act on the
+// terminal return an error.
+//
+// Cursor addressing is performed by writing ANSI escape sequences, which are
+// understood by Unix terminals and by Windows consoles once virtual terminal
+// processing has been enabled with EnableVirtualTerminal.
+package term
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// pollInterval is the interval at which the terminal size is polled on
+// systems which do not notify processes of resizes.
+const pollInterval = 250 * time.Millisecond
+
+// State holds the state of a terminal, as returned by GetState and MakeRaw.
+type State struct {
+	state
+}
+
+// Size represents the dimensions of a terminal window, measured in character
+// cells.
+type Size struct {
+	Width  int
+	Height int
+}
+
+// EnableVirtualTerminal enables the processing of ANSI escape sequences written
+// to the terminal referred to by fd. Unix terminals always process escape
+// sequences, so EnableVirtualTerminal does nothing on Unix systems.
+func EnableVirtualTerminal(fd int) error {
+	return enableVirtualTerminal(fd)
+}
+
+// GetSize returns the dimensions of the terminal referred to by fd.
+func GetSize(fd int) (Size, error) {
+	size, err := getSize(fd)
+	if err != nil {
+		return Size{}, errors.New(err, "cannot get terminal size")
+	}
+	return size, nil
+}
+
+// GetState returns the current state of the terminal referred to by fd, which
+// may later be passed to Restore.
+func GetState(fd int) (*State, error) {
+	st, err := getState(fd)
+	if err != nil {
+		return nil, errors.New(err, "cannot get terminal state")
+	}
+	return &State{st}, nil
+}
+
+// IsTerminal reports whether fd refers to a terminal.
+func IsTerminal(fd int) bool {
+	return isTerminal(fd)
+}
+
+// MakeRaw puts the terminal referred to by fd into raw mode, and returns its
+// previous state, which should later be passed to Restore.
+func MakeRaw(fd int) (*State, error) {
+	st, err := makeRaw(fd)
+	if err != nil {
+		return nil, errors.New(err, "cannot enter raw mode")
+	}
+	return &State{st}, nil
+}
+
+// NotifyResize watches the terminal referred to by fd for changes in size, and
+// sends the new size on the returned channel each time the terminal is
+// resized. Sizes are dropped if the receiver is not ready to receive them, so
+// the most recent size should always be queried with GetSize when a
+// notification is received, if it matters that no resize is missed.
+//
+// The stop function must be called to release the resources associated with
+// the watcher once notifications are no longer needed. After stop returns, no
+// more sizes are sent and the channel is closed.
+//
+// On Unix systems, resizes are detected using the SIGWINCH signal. On other
+// systems, the terminal size is polled.
+func NotifyResize(fd int) (sizes <-chan Size, stop func()) {
+	ch := make(chan Size, 1)
+	done := make(chan struct{})
+	ticks, release := resizeEvents()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		last, _ := getSize(fd)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticks:
+			}
+			size, err := getSize(fd)
+			if err != nil || size == last {
+				continue
+			}
+			last = size
+			select {
+			case ch <- size:
+			default:
+			}
+		}
+	}()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+			release()
+			close(ch)
+		})
+	}
+}
+
+// Restore restores the terminal referred to by fd to the given state.
+func Restore(fd int, state *State) error {
+	if state == nil {
+		return errors.New(nil, "cannot restore nil terminal state")
+	}
+	if err := restore(fd, state.state); err != nil {
+		return errors.New(err, "cannot restore terminal state")
+	}
+	return nil
+}
+
+// ClearLine clears the line on which the cursor lies.
+func ClearLine(w io.Writer) error {
+	return escape(w, "\x1b[2K")
+}
+
+// ClearScreen clears the screen and moves the cursor to the top-left cell.
+func ClearScreen(w io.Writer) error {
+	return escape(w, "\x1b[2J\x1b[H")
+}
+
+// HideCursor makes the cursor invisible.
+func HideCursor(w io.Writer) error {
+	return escape(w, "\x1b[?25l")
+}
+
+// MoveBy moves the cursor dx cells to the right and dy cells down. Negative
+// values move the cursor left and up respectively.
+func MoveBy(w io.Writer, dx, dy int) error {
+	var seq string
+	switch {
+	case dx > 0:
+		seq += fmt.Sprintf("\x1b[%dC", dx)
+	case dx < 0:
+		seq += fmt.Sprintf("\x1b[%dD", -dx)
+	}
+	switch {
+	case dy > 0:
+		seq += fmt.Sprintf("\x1b[%dB", dy)
+	case dy < 0:
+		seq += fmt.Sprintf("\x1b[%dA", -dy)
+	}
+	return escape(w, seq)
+}
+
+// MoveTo moves the cursor to column x and row y, where (0, 0) is the top-left
+// cell of the screen.
+func MoveTo(w io.Writer, x, y int) error {
+	if x < 0 || y < 0 {
+		return errors.New(nil, "invalid cursor position (%d, %d)", x, y)
+	}
+	return escape(w, fmt.Sprintf("\x1b[%d;%dH", y+1, x+1))
+}
+
+// RestoreCursor moves the cursor to the position saved by the last call to
+// SaveCursor.
+func RestoreCursor(w io.Writer) error {
+	return escape(w, "\x1b8")
+}
+
+// SaveCursor saves the current cursor position.
+func SaveCursor(w io.Writer) error {
+	return escape(w, "\x1b7")
+}
+
+// ShowCursor makes the cursor visible.
+func ShowCursor(w io.Writer) error {
+	return escape(w, "\x1b[?25h")
+}
+
+func escape(w io.Writer, seq string) error {
+	if seq == "" {
+		return nil
+	}
+	if _, err := io.WriteString(w, seq); err != nil {
+		return errors.New(err, "cannot write escape sequence")
+	}
+	return nil
+}
+
+// pollEvents returns a channel which receives a value every pollInterval, and
+// a function which stops the events.
+func pollEvents() (<-chan struct{}, func()) {
+	ticker := time.NewTicker(pollInterval)
+	events := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case events <- struct{}{}:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return events, func() {
+		ticker.Stop()
+		close(done)
+	}
+}
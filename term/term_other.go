@@ -0,0 +1,41 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package term
+
+import (
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+type state struct{}
+
+func enableVirtualTerminal(fd int) error {
+	return nil
+}
+
+func getSize(fd int) (Size, error) {
+	return Size{}, unsupported()
+}
+
+func getState(fd int) (state, error) {
+	return state{}, unsupported()
+}
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func makeRaw(fd int) (state, error) {
+	return state{}, unsupported()
+}
+
+func resizeEvents() (<-chan struct{}, func()) {
+	return pollEvents()
+}
+
+func restore(fd int, st state) error {
+	return unsupported()
+}
+
+func unsupported() error {
+	return errors.New(nil, "terminal control not supported on this system")
+}
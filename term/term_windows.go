@@ -0,0 +1,107 @@
+package term
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	enableProcessedInput            = 0x0001
+	enableLineInput                 = 0x0002
+	enableEchoInput                 = 0x0004
+	enableVirtualTerminalInput      = 0x0200
+	enableProcessedOutput           = 0x0001
+	enableVirtualTerminalProcessing = 0x0004
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+)
+
+type state struct {
+	mode uint32
+}
+
+type coord struct {
+	x int16
+	y int16
+}
+
+type smallRect struct {
+	left   int16
+	top    int16
+	right  int16
+	bottom int16
+}
+
+type consoleScreenBufferInfo struct {
+	size              coord
+	cursorPosition    coord
+	attributes        uint16
+	window            smallRect
+	maximumWindowSize coord
+}
+
+func enableVirtualTerminal(fd int) error {
+	st, err := getState(fd)
+	if err != nil {
+		return err
+	}
+	return setConsoleMode(fd, st.mode|enableProcessedOutput|enableVirtualTerminalProcessing)
+}
+
+func getSize(fd int) (Size, error) {
+	var info consoleScreenBufferInfo
+	r, _, err := procGetConsoleScreenBufferInfo.Call(
+		uintptr(fd), uintptr(unsafe.Pointer(&info)),
+	)
+	if r == 0 {
+		return Size{}, err
+	}
+	return Size{
+		int(info.window.right-info.window.left) + 1,
+		int(info.window.bottom-info.window.top) + 1,
+	}, nil
+}
+
+func getState(fd int) (state, error) {
+	var st state
+	err := syscall.GetConsoleMode(syscall.Handle(fd), &st.mode)
+	return st, err
+}
+
+func isTerminal(fd int) bool {
+	_, err := getState(fd)
+	return err == nil
+}
+
+func makeRaw(fd int) (state, error) {
+	old, err := getState(fd)
+	if err != nil {
+		return state{}, err
+	}
+	raw := old.mode &^ (enableEchoInput | enableProcessedInput | enableLineInput | enableProcessedOutput)
+	raw |= enableVirtualTerminalInput
+	if err := setConsoleMode(fd, raw); err != nil {
+		return state{}, err
+	}
+	return old, nil
+}
+
+func resizeEvents() (<-chan struct{}, func()) {
+	return pollEvents()
+}
+
+func restore(fd int, st state) error {
+	return setConsoleMode(fd, st.mode)
+}
+
+func setConsoleMode(fd int, mode uint32) error {
+	r, _, err := procSetConsoleMode.Call(uintptr(fd), uintptr(mode))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
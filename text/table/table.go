@@ -0,0 +1,320 @@
+// Package table implements the rendering of aligned text tables.
+//
+// A Table holds a header row and any number of data rows, and is written in
+// one of several formats. In the plain format, columns are padded to a common
+// width so that they line up when displayed in a terminal:
+//
+//	t := table.New("NAME", "SIZE")
+//	t.AddRow("go.mod", 31)
+//	t.AddRow("README.md", 1024)
+//	t.SetAlign(1, table.Right)
+//	t.Write(os.Stdout, table.Plain)
+//
+// Column widths are measured in terminal cells rather than bytes or runes, so
+// that tables containing East Asian wide characters, emoji or ANSI color
+// sequences still line up.
+//
+// Tables may also be built from a slice of structs using FromStructs, in which
+// case each exported field becomes a column.
+package table
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/internal/width"
+)
+
+// Align represents the alignment of the cells of a column.
+type Align int
+
+const (
+	Left Align = iota
+	Right
+	Center
+)
+
+// Format represents an output format for a table.
+type Format int
+
+const (
+	// Plain formats a table as columns of text padded with spaces.
+	Plain Format = iota
+	// Markdown formats a table as a Markdown pipe table.
+	Markdown
+	// CSV formats a table as comma-separated values, as described in RFC
+	// 4180. Alignment and maximum widths are not applied.
+	CSV
+)
+
+// Table represents a table of text cells.
+type Table struct {
+	headers  []string
+	rows     [][]string
+	align    []Align
+	maxWidth []int
+}
+
+// New returns a table with the given column headers.
+func New(headers ...string) *Table {
+	return &Table{
+		headers:  headers,
+		align:    make([]Align, len(headers)),
+		maxWidth: make([]int, len(headers)),
+	}
+}
+
+// FromStructs returns a table with a row for each element of v, which must be
+// a slice of structs or of pointers to structs. Each exported field of the
+// struct type becomes a column, headed by the field's name. The header of a
+// column can be changed with a struct tag of the form `table:"header"`, and a
+// field is omitted with the tag `table:"-"`. Cells are formatted using
+// fmt.Sprint. Pointer fields are dereferenced, and nil pointers give empty
+// cells.
+//
+// Returns error if v is not a slice of structs or of pointers to structs.
+func FromStructs(v any) (*Table, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, errors.New(nil, "cannot make table from %T", v)
+	}
+	elem := rv.Type().Elem()
+	ptr := elem.Kind() == reflect.Pointer
+	if ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, errors.New(nil, "cannot make table from %T", v)
+	}
+	var fields []int
+	var headers []string
+	for i := 0; i < elem.NumField(); i++ {
+		f := elem.Field(i)
+		tag := f.Tag.Get("table")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		fields = append(fields, i)
+		headers = append(headers, tag)
+	}
+	t := New(headers...)
+	for i := 0; i < rv.Len(); i++ {
+		s := rv.Index(i)
+		row := make([]any, len(fields))
+		if ptr && s.IsNil() {
+			for j := range row {
+				row[j] = ""
+			}
+			t.AddRow(row...)
+			continue
+		}
+		if ptr {
+			s = s.Elem()
+		}
+		for j, f := range fields {
+			fv := s.Field(f)
+			switch {
+			case fv.Kind() != reflect.Pointer:
+				row[j] = fv.Interface()
+			case fv.IsNil():
+				row[j] = ""
+			default:
+				row[j] = fv.Elem().Interface()
+			}
+		}
+		t.AddRow(row...)
+	}
+	return t, nil
+}
+
+// AddRow appends a row to t, formatting each cell using fmt.Sprint. Rows with
+// fewer cells than t has columns are padded with empty cells, and rows with
+// more cells add columns with empty headers.
+func (t *Table) AddRow(cells ...any) {
+	row := make([]string, len(cells))
+	for i, c := range cells {
+		row[i] = fmt.Sprint(c)
+	}
+	for len(t.headers) < len(row) {
+		t.headers = append(t.headers, "")
+		t.align = append(t.align, Left)
+		t.maxWidth = append(t.maxWidth, 0)
+	}
+	t.rows = append(t.rows, row)
+}
+
+// SetAlign sets the alignment of the cells of column col. Returns error if t
+// has no column col.
+func (t *Table) SetAlign(col int, a Align) error {
+	if col < 0 || col >= len(t.headers) {
+		return errors.New(nil,
+			"column out of range [%d] with %d columns", col, len(t.headers),
+		)
+	}
+	t.align[col] = a
+	return nil
+}
+
+// SetMaxWidth sets the maximum width of column col, in terminal cells. Cells
+// wider than w are truncated and end with an ellipsis. If w is zero or
+// negative, the column width is unlimited. Returns error if t has no column
+// col.
+func (t *Table) SetMaxWidth(col int, w int) error {
+	if col < 0 || col >= len(t.headers) {
+		return errors.New(nil,
+			"column out of range [%d] with %d columns", col, len(t.headers),
+		)
+	}
+	t.maxWidth[col] = w
+	return nil
+}
+
+// Write writes t to w in format f.
+func (t *Table) Write(w io.Writer, f Format) error {
+	var err error
+	switch f {
+	case Plain:
+		err = t.writePlain(w)
+	case Markdown:
+		err = t.writeMarkdown(w)
+	case CSV:
+		err = t.writeCSV(w)
+	default:
+		return errors.New(nil, "unknown table format %d", f)
+	}
+	if err != nil {
+		return errors.New(err, "cannot write table")
+	}
+	return nil
+}
+
+// cells returns the header row followed by the data rows, with every row
+// padded to the same length, newlines replaced by spaces, and cells truncated
+// to their column's maximum width.
+func (t *Table) cells() [][]string {
+	all := make([][]string, 0, len(t.rows)+1)
+	for _, row := range append([][]string{t.headers}, t.rows...) {
+		cells := make([]string, len(t.headers))
+		for i := range cells {
+			if i >= len(row) {
+				continue
+			}
+			c := strings.ReplaceAll(row[i], "\r\n", " ")
+			c = strings.ReplaceAll(c, "\n", " ")
+			if max := t.maxWidth[i]; max > 0 {
+				c = width.Truncate(c, max, "…")
+			}
+			cells[i] = c
+		}
+		all = append(all, cells)
+	}
+	return all
+}
+
+func (t *Table) widths(cells [][]string) []int {
+	widths := make([]int, len(t.headers))
+	for _, row := range cells {
+		for i, c := range row {
+			if w := width.String(c); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	return widths
+}
+
+func (t *Table) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.headers); err != nil {
+		return err
+	}
+	for _, row := range t.rows {
+		record := make([]string, len(t.headers))
+		copy(record, row)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (t *Table) writeMarkdown(w io.Writer) error {
+	cells := t.cells()
+	for _, row := range cells {
+		for i, c := range row {
+			row[i] = strings.ReplaceAll(c, "|", `\|`)
+		}
+	}
+	widths := t.widths(cells)
+	var sb strings.Builder
+	writeRow := func(row []string) {
+		sb.WriteString("|")
+		for i, c := range row {
+			sb.WriteString(" " + pad(c, widths[i], t.align[i]) + " |")
+		}
+		sb.WriteString("\n")
+	}
+	writeRow(cells[0])
+	sb.WriteString("|")
+	for i, a := range t.align {
+		n := widths[i]
+		if n < 3 {
+			n = 3
+		}
+		switch a {
+		case Left:
+			sb.WriteString(" " + strings.Repeat("-", n) + " |")
+		case Right:
+			sb.WriteString(" " + strings.Repeat("-", n-1) + ": |")
+		case Center:
+			sb.WriteString(" :" + strings.Repeat("-", n-2) + ": |")
+		}
+	}
+	sb.WriteString("\n")
+	for _, row := range cells[1:] {
+		writeRow(row)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (t *Table) writePlain(w io.Writer) error {
+	cells := t.cells()
+	widths := t.widths(cells)
+	var sb strings.Builder
+	for _, row := range cells {
+		var line strings.Builder
+		for i, c := range row {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(pad(c, widths[i], t.align[i]))
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " "))
+		sb.WriteString("\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func pad(s string, w int, a Align) string {
+	n := w - width.String(s)
+	if n <= 0 {
+		return s
+	}
+	switch a {
+	case Right:
+		return strings.Repeat(" ", n) + s
+	case Center:
+		return strings.Repeat(" ", n/2) + s + strings.Repeat(" ", n-n/2)
+	}
+	return s + strings.Repeat(" ", n)
+}
@@ -0,0 +1,159 @@
+// Package wrap implements word wrapping, indentation and alignment of text.
+//
+// All functions in package wrap measure text in terminal cells, so that East
+// Asian wide characters and emoji are accounted for, and ANSI escape sequences
+// are preserved but treated as occupying no space. Text styled with package
+// term/ansi can therefore be wrapped without corrupting its styling.
+//
+// Dedent is particularly useful for writing help text as raw string literals
+// indented along with the surrounding code:
+//
+//	const usage = `
+//		Usage: tool [flags] file...
+//
+//		Flags:
+//		  -v	verbose output
+//	`
+//	fmt.Print(wrap.Dedent(usage))
+package wrap
+
+import (
+	"strings"
+	"unicode"
+
+	"git.sr.ht/~kvo/go-std/internal/width"
+)
+
+// Center centers each line of s within a column of the given width, by
+// padding it on the left with spaces. Leading and trailing whitespace is
+// removed from each line first. Lines wider than width are left unchanged.
+func Center(s string, w int) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if n := (w - width.String(line)) / 2; n > 0 {
+			line = strings.Repeat(" ", n) + line
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Dedent removes any whitespace prefix common to every line of s. Lines
+// consisting solely of whitespace are ignored when finding the common prefix,
+// and are replaced by empty lines in the result. Tabs and spaces are not
+// considered equal.
+func Dedent(s string) string {
+	lines := strings.Split(s, "\n")
+	prefix := ""
+	first := true
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeftFunc(line, unicode.IsSpace))]
+		if first {
+			prefix = indent
+			first = false
+			continue
+		}
+		n := 0
+		for n < len(prefix) && n < len(indent) && prefix[n] == indent[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, prefix)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Hanging wraps s like Wrap, but begins the first line of the result with
+// first and every subsequent line with rest. The width of each line includes
+// its prefix. Hanging is typically used to format lists and definitions, where
+// rest is a run of spaces as wide as first:
+//
+//	wrap.Hanging(desc, 80, "  -v  ", "      ")
+func Hanging(s string, w int, first, rest string) string {
+	var out []string
+	prefix := first
+	for _, line := range strings.Split(s, "\n") {
+		wrapped := wrapLine(line, w, prefix, rest)
+		out = append(out, wrapped...)
+		prefix = rest
+	}
+	return strings.Join(out, "\n")
+}
+
+// Indent adds prefix to the beginning of every line of s which does not consist
+// solely of whitespace.
+func Indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Wrap wraps s so that no line is wider than w cells, breaking lines between
+// words where possible. Existing line breaks in s are preserved, and runs of
+// whitespace within a line are collapsed into single spaces. Words wider than
+// w are broken across lines. If w is zero or negative, lines are not wrapped.
+func Wrap(s string, w int) string {
+	return Hanging(s, w, "", "")
+}
+
+// wrapLine wraps a single line of text, beginning the first resulting line
+// with first and each subsequent line with rest.
+func wrapLine(line string, w int, first, rest string) []string {
+	var lines []string
+	cur := first
+	curW := width.String(first)
+	empty := true
+	flush := func() {
+		lines = append(lines, strings.TrimRightFunc(cur, unicode.IsSpace))
+		cur = rest
+		curW = width.String(rest)
+		empty = true
+	}
+	for _, word := range strings.Fields(line) {
+		ww := width.String(word)
+		if w > 0 && !empty && curW+1+ww > w {
+			flush()
+		}
+		if !empty {
+			cur += " "
+			curW++
+		}
+		// A word of zero width, such as an escape sequence, is never cut.
+		for w > 0 && ww > 0 && curW+ww > w {
+			head, tail := width.Cut(word, w-curW)
+			if width.String(head) == 0 {
+				if !empty {
+					flush()
+					continue
+				}
+				// The prefix leaves no room for even a single
+				// character, so overflow by one character.
+				head, tail = width.Cut(word, width.Rune([]rune(width.Strip(word))[0]))
+			}
+			cur += head
+			flush()
+			word = tail
+			ww = width.String(word)
+		}
+		if ww > 0 || word != "" {
+			cur += word
+			curW += ww
+			empty = false
+		}
+	}
+	if !empty || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
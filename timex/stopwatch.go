@@ -0,0 +1,149 @@
+package timex
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/text/table"
+)
+
+// Phase is a named interval recorded by a Stopwatch.
+type Phase struct {
+	Name string
+	// Duration is the time since the previous lap, or since the stopwatch
+	// started.
+	Duration time.Duration
+	// Elapsed is the time since the stopwatch started.
+	Elapsed time.Duration
+}
+
+// Stopwatch times the phases of an operation. A Stopwatch must be created with
+// NewStopwatch, and is safe for concurrent use.
+//
+// Lap ends a phase and begins the next, while Split records the time reached
+// without ending the current phase, so that a phase can be timed along with
+// checkpoints within it.
+type Stopwatch struct {
+	mu     sync.Mutex
+	start  time.Time
+	lap    time.Time
+	phases []Phase
+}
+
+// NewStopwatch returns a stopwatch started at the current time.
+func NewStopwatch() *Stopwatch {
+	now := time.Now()
+	return &Stopwatch{start: now, lap: now}
+}
+
+// Elapsed returns the time since s started.
+func (s *Stopwatch) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Since(s.start)
+}
+
+// Lap records a phase with the given name, lasting from the previous lap, or
+// from the start of s, until now, and begins a new phase. Returns the duration
+// of the phase.
+func (s *Stopwatch) Lap(name string) time.Duration {
+	return s.record(name, true)
+}
+
+// Phases returns the phases recorded by s, in the order recorded.
+func (s *Stopwatch) Phases() []Phase {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Phase(nil), s.phases...)
+}
+
+// Report writes a table of the phases recorded by s to w, giving the duration
+// of each phase, its share of the total time, and the total time.
+func (s *Stopwatch) Report(w io.Writer) error {
+	s.mu.Lock()
+	phases := append([]Phase(nil), s.phases...)
+	total := time.Since(s.start)
+	s.mu.Unlock()
+	t := table.New("PHASE", "DURATION", "SHARE")
+	t.SetAlign(1, table.Right)
+	t.SetAlign(2, table.Right)
+	for _, p := range phases {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(p.Duration) / float64(total)
+		}
+		t.AddRow(p.Name, round(p.Duration), fmt.Sprintf("%.1f%%", share))
+	}
+	t.AddRow("total", round(total), "")
+	if err := t.Write(w, table.Plain); err != nil {
+		return errors.New(err, "cannot write report")
+	}
+	return nil
+}
+
+// Reset discards the phases recorded by s, and restarts it.
+func (s *Stopwatch) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start = time.Now()
+	s.lap = s.start
+	s.phases = nil
+}
+
+// Split records a phase with the given name, lasting from the previous lap, or
+// from the start of s, until now, without beginning a new phase. Returns the
+// duration of the phase.
+func (s *Stopwatch) Split(name string) time.Duration {
+	return s.record(name, false)
+}
+
+// String returns the phases recorded by s and the total time on a single line,
+// in a form suited to logs, such as "parse=12.3ms compile=1.21s total=1.23s".
+// Durations are rounded to three significant digits.
+func (s *Stopwatch) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sb strings.Builder
+	for _, p := range s.phases {
+		sb.WriteString(quoteName(p.Name))
+		sb.WriteByte('=')
+		sb.WriteString(round(p.Duration).String())
+		sb.WriteByte(' ')
+	}
+	sb.WriteString("total=")
+	sb.WriteString(round(time.Since(s.start)).String())
+	return sb.String()
+}
+
+func (s *Stopwatch) record(name string, lap bool) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	d := now.Sub(s.lap)
+	s.phases = append(s.phases, Phase{name, d, now.Sub(s.start)})
+	if lap {
+		s.lap = now
+	}
+	return d
+}
+
+// quoteName quotes name if it contains spaces, equals signs or quotes.
+func quoteName(name string) string {
+	if name == "" || strings.ContainsAny(name, " =\"") {
+		return `"` + strings.ReplaceAll(name, `"`, `\"`) + `"`
+	}
+	return name
+}
+
+// round rounds d to three significant digits.
+func round(d time.Duration) time.Duration {
+	r := time.Duration(1)
+	for d/r >= 1000 || -d/r >= 1000 {
+		r *= 10
+	}
+	return d.Round(r)
+}
@@ -0,0 +1,279 @@
+// Package timex implements functions for working with times and durations in
+// forms suited to people.
+//
+// ParseDuration accepts the durations people write, including days and weeks,
+// spelled-out units and fractions:
+//
+//	d, err := timex.ParseDuration("1.5 days")
+//	d, err = timex.ParseDuration("2d6h")
+//	d, err = timex.ParseDuration("1 hour and 30 minutes")
+//
+// Format writes durations compactly, showing as many units as requested:
+//
+//	timex.Format(54*time.Hour+30*time.Minute+12*time.Second, 3) // "2d 6h 30m"
+//
+// RelativeTime describes a time relative to now, as shown in user interfaces
+// and logs:
+//
+//	timex.RelativeTime(created) // "3 minutes ago"
+//
+// A Stopwatch times the phases of an operation, for reports and log lines:
+//
+//	sw := timex.NewStopwatch()
+//	ast, err := parse(src)
+//	sw.Lap("parse")
+//	obj, err := compile(ast)
+//	sw.Lap("compile")
+//	log.Info("built", "timing", sw) // timing="parse=12.3ms compile=1.21s total=1.23s"
+package timex
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Day and Week are the durations of a day and a week, ignoring changes in
+// daylight saving time.
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+var units = map[string]time.Duration{
+	"ns":      time.Nanosecond,
+	"us":      time.Microsecond,
+	"µs":      time.Microsecond, // U+00B5 micro sign
+	"μs":      time.Microsecond, // U+03BC Greek small letter mu
+	"ms":      time.Millisecond,
+	"s":       time.Second,
+	"sec":     time.Second,
+	"secs":    time.Second,
+	"second":  time.Second,
+	"seconds": time.Second,
+	"m":       time.Minute,
+	"min":     time.Minute,
+	"mins":    time.Minute,
+	"minute":  time.Minute,
+	"minutes": time.Minute,
+	"h":       time.Hour,
+	"hr":      time.Hour,
+	"hrs":     time.Hour,
+	"hour":    time.Hour,
+	"hours":   time.Hour,
+	"d":       Day,
+	"day":     Day,
+	"days":    Day,
+	"w":       Week,
+	"wk":      Week,
+	"wks":     Week,
+	"week":    Week,
+	"weeks":   Week,
+}
+
+// formatUnits are the units used by Format, largest first.
+var formatUnits = []struct {
+	d    time.Duration
+	name string
+}{
+	{Day, "d"},
+	{time.Hour, "h"},
+	{time.Minute, "m"},
+	{time.Second, "s"},
+	{time.Millisecond, "ms"},
+	{time.Microsecond, "µs"},
+	{time.Nanosecond, "ns"},
+}
+
+// relativeUnits are the units used by RelativeTo, largest first. Months and
+// years are approximated as 30 and 365 days.
+var relativeUnits = []struct {
+	d    time.Duration
+	name string
+}{
+	{365 * Day, "year"},
+	{30 * Day, "month"},
+	{Week, "week"},
+	{Day, "day"},
+	{time.Hour, "hour"},
+	{time.Minute, "minute"},
+	{time.Second, "second"},
+}
+
+// Format returns a compact representation of d, such as "2d 6h 30m", using
+// days, hours, minutes, seconds, milliseconds, microseconds and nanoseconds.
+// Units with a value of zero are omitted.
+//
+// Precision is the number of units shown, counting from the largest unit
+// present, including those omitted for being zero; smaller units are
+// truncated. If precision is zero or negative, every unit is shown.
+func Format(d time.Duration, precision int) string {
+	if d == 0 {
+		return "0s"
+	}
+	u := uint64(d)
+	if d < 0 {
+		u = -u
+	}
+	var parts []string
+	shown := 0
+	for _, unit := range formatUnits {
+		if precision > 0 && shown == precision {
+			break
+		}
+		n := u / uint64(unit.d)
+		u %= uint64(unit.d)
+		if n == 0 && shown == 0 {
+			continue
+		}
+		shown++
+		if n == 0 {
+			continue
+		}
+		parts = append(parts, strconv.FormatUint(n, 10)+unit.name)
+	}
+	if d < 0 {
+		return "-" + strings.Join(parts, " ")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseDuration parses a duration made of one or more numbers, each followed
+// by a unit, such as "2d6h", "1.5 days" or "90 sec". Numbers may have a
+// fractional part, and the duration may begin with a sign. Whitespace, commas
+// and the word "and" may separate the parts of a duration, and units are
+// case-insensitive.
+//
+// The units are ns; us or µs; ms; s, sec or second; m, min or minute; h, hr or
+// hour; d or day; and w, wk or week, each of which may also be plural. A day
+// is always 24 hours. The duration "0" needs no unit.
+//
+// Returns error if s is not a valid duration, or if the duration overflows.
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	s = strings.ToLower(strings.TrimSpace(s))
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = strings.TrimSpace(s[1:])
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, errors.New(nil, "invalid duration %q", orig)
+	}
+	var total float64
+	var exact int64
+	for parts := 0; ; parts++ {
+		s = skipSeparators(s, parts > 0)
+		if s == "" {
+			break
+		}
+		i := 0
+		dot := false
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.' && !dot) {
+			dot = dot || s[i] == '.'
+			i++
+		}
+		num := s[:i]
+		if num == "" || num == "." {
+			return 0, errors.New(nil, "invalid duration %q", orig)
+		}
+		s = strings.TrimLeft(s[i:], " \t")
+		j := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+		if j < 0 {
+			j = len(s)
+		}
+		name := s[:j]
+		s = s[j:]
+		if name == "" {
+			return 0, errors.New(nil, "missing unit in duration %q", orig)
+		}
+		unit, ok := units[name]
+		if !ok {
+			return 0, errors.New(nil, "unknown unit %q in duration %q", name, orig)
+		}
+		whole, frac, _ := strings.Cut(num, ".")
+		var n int64
+		if whole != "" {
+			var err error
+			n, err = strconv.ParseInt(whole, 10, 64)
+			if err != nil || n > math.MaxInt64/int64(unit) {
+				return 0, errors.New(nil, "duration %q overflows", orig)
+			}
+		}
+		if exact > math.MaxInt64-n*int64(unit) {
+			return 0, errors.New(nil, "duration %q overflows", orig)
+		}
+		exact += n * int64(unit)
+		if frac != "" {
+			f, _ := strconv.ParseFloat("0."+frac, 64)
+			total += f * float64(unit)
+		}
+	}
+	if float64(exact)+total > math.MaxInt64 {
+		return 0, errors.New(nil, "duration %q overflows", orig)
+	}
+	d := time.Duration(exact) + time.Duration(math.Round(total))
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// RelativeTime is equivalent to RelativeTo(t, time.Now()).
+func RelativeTime(t time.Time) string {
+	return RelativeTo(t, time.Now())
+}
+
+// RelativeTo describes t relative to now in the largest whole unit, such as
+// "3 minutes ago" or "in 2 days". Times less than a second from now are
+// described as "now". Months and years are approximated as 30 and 365 days.
+func RelativeTo(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	if d < time.Second {
+		return "now"
+	}
+	var text string
+	for _, unit := range relativeUnits {
+		if d >= unit.d {
+			n := int64(d / unit.d)
+			text = strconv.FormatInt(n, 10) + " " + unit.name
+			if n != 1 {
+				text += "s"
+			}
+			break
+		}
+	}
+	if future {
+		return "in " + text
+	}
+	return text + " ago"
+}
+
+// skipSeparators returns s without leading whitespace and, if between is true,
+// the commas and the word "and" which may separate the parts of a duration.
+func skipSeparators(s string, between bool) string {
+	for {
+		t := strings.TrimLeft(s, " \t")
+		if between {
+			t = strings.TrimLeft(t, ", \t")
+			if rest, ok := strings.CutPrefix(t, "and"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+				t = rest
+			}
+		}
+		if t == s {
+			return s
+		}
+		s = t
+	}
+}
@@ -0,0 +1,264 @@
+// Package tmpl implements the interpolation of values into strings, for
+// messages and formats where package text/template would be excessive.
+//
+// A template holds references of the form ${name}, which are replaced by the
+// values of the same names in a map or struct:
+//
+//	t, err := tmpl.Parse("Hello ${user.name}, you have ${count} messages", nil)
+//	s, err := t.Execute(map[string]any{
+//		"user":  User{Name: "Ann"},
+//		"count": 3,
+//	})
+//
+// Templates do not execute code: a reference can only look up a value. Values
+// may be escaped as they are substituted, such as for HTML, and references to
+// missing values may be reported as errors, replaced with empty strings, or
+// kept as they are:
+//
+//	s, err := tmpl.Render(page, data, &tmpl.Options{
+//		Escape:  html.EscapeString,
+//		Missing: tmpl.MissingKeep,
+//	})
+package tmpl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Missing selects the treatment of references to missing values.
+type Missing int
+
+const (
+	// MissingError reports references to missing values as errors.
+	MissingError Missing = iota
+	// MissingEmpty replaces references to missing values with empty
+	// strings.
+	MissingEmpty
+	// MissingKeep keeps references to missing values as they are written.
+	MissingKeep
+)
+
+// Options configures a template. A nil *Options is equivalent to a zero
+// Options, under which values are not escaped and missing values are errors.
+type Options struct {
+	// Escape, if not nil, is applied to each value substituted, such as
+	// html.EscapeString.
+	Escape func(string) string
+
+	// Missing selects the treatment of references to missing values.
+	Missing Missing
+}
+
+// Template is a parsed template. A Template may be executed by multiple
+// goroutines at once.
+type Template struct {
+	text  []string // text[i] precedes refs[i]; the last follows all refs
+	refs  []ref
+	names []string
+	opts  Options
+}
+
+// ref is a reference to a value.
+type ref struct {
+	raw  string   // the reference as written, such as "${a.b}"
+	path []string // the elements of the name, such as ["a", "b"]
+}
+
+// Parse parses s as a template. A reference ${name} names a value, and the
+// name may be a path of names separated by dots, such as ${user.name}, naming
+// values within values. Names consist of letters, digits, underscores and
+// hyphens. $$ stands for a single dollar sign, and a dollar sign not followed
+// by $ or { is kept as it is. Returns error if a reference is unterminated or
+// its name is malformed.
+func Parse(s string, opts *Options) (*Template, error) {
+	t := &Template{}
+	if opts != nil {
+		t.opts = *opts
+	}
+	var text strings.Builder
+	seen := make(map[string]bool)
+	for rest, off := s, 0; ; {
+		i := strings.IndexByte(rest, '$')
+		if i < 0 || i == len(rest)-1 {
+			text.WriteString(rest)
+			break
+		}
+		text.WriteString(rest[:i])
+		switch rest[i+1] {
+		case '$':
+			text.WriteByte('$')
+			rest, off = rest[i+2:], off+i+2
+			continue
+		case '{':
+		default:
+			text.WriteByte('$')
+			rest, off = rest[i+1:], off+i+1
+			continue
+		}
+		end := strings.IndexByte(rest[i:], '}')
+		if end < 0 {
+			return nil, errors.New(nil, "invalid template: unterminated reference at offset %d", off+i)
+		}
+		raw := rest[i : i+end+1]
+		name := raw[2 : len(raw)-1]
+		path := strings.Split(name, ".")
+		for _, elem := range path {
+			if !validName(elem) {
+				return nil, errors.New(nil, "invalid template: invalid name %q at offset %d", name, off+i)
+			}
+		}
+		t.text = append(t.text, text.String())
+		text.Reset()
+		t.refs = append(t.refs, ref{raw, path})
+		if !seen[name] {
+			seen[name] = true
+			t.names = append(t.names, name)
+		}
+		rest, off = rest[i+end+1:], off+i+end+1
+	}
+	t.text = append(t.text, text.String())
+	return t, nil
+}
+
+// Render parses s as a template and executes it with data, as by Parse and
+// Template.Execute.
+func Render(s string, data any, opts *Options) (string, error) {
+	t, err := Parse(s, opts)
+	if err != nil {
+		return "", err
+	}
+	return t.Execute(data)
+}
+
+// Execute returns the text of t with each reference replaced by the value it
+// names in data, formatted as by fmt.Sprint, with nil formatted as an empty
+// string. Names are looked up in maps with string keys by key, and in structs
+// by the names given by their tmpl tags, or else by their field names ignoring
+// case, including the fields of embedded structs; pointers and interfaces are
+// followed.
+//
+// Returns error listing every reference to a missing value, unless the
+// template's options direct otherwise.
+func (t *Template) Execute(data any) (string, error) {
+	var sb strings.Builder
+	var errs []error
+	for i, r := range t.refs {
+		sb.WriteString(t.text[i])
+		v, ok := lookup(data, r.path)
+		if !ok {
+			switch t.opts.Missing {
+			case MissingError:
+				errs = append(errs, errors.New(nil, "missing value for %s", r.raw))
+			case MissingKeep:
+				sb.WriteString(r.raw)
+			}
+			continue
+		}
+		s := format(v)
+		if t.opts.Escape != nil {
+			s = t.opts.Escape(s)
+		}
+		sb.WriteString(s)
+	}
+	sb.WriteString(t.text[len(t.text)-1])
+	if len(errs) > 0 {
+		return "", errors.New(errors.Join(errs...), "cannot execute template")
+	}
+	return sb.String(), nil
+}
+
+// Names returns the names referenced by t, in the order of their first
+// references, such as to check in advance that they will be provided.
+func (t *Template) Names() []string {
+	return t.names
+}
+
+// format returns v as substituted into a template.
+func format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// lookup returns the value named by path in data.
+func lookup(data any, path []string) (any, bool) {
+	v := data
+	for _, name := range path {
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+			if rv.IsNil() {
+				return nil, false
+			}
+			rv = rv.Elem()
+		}
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			e := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			if !e.IsValid() {
+				return nil, false
+			}
+			v = e.Interface()
+		case reflect.Struct:
+			f, ok := field(rv, name)
+			if !ok {
+				return nil, false
+			}
+			v = f.Interface()
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// field returns the exported field of the struct v named name, by its tmpl
+// tag or else by its field name ignoring case.
+func field(v reflect.Value, name string) (reflect.Value, bool) {
+	var match []int
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		tag, _, _ := strings.Cut(f.Tag.Get("tmpl"), ",")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		if tag == name {
+			match = f.Index
+			break
+		}
+		if tag == "" && match == nil && strings.EqualFold(f.Name, name) {
+			match = f.Index
+		}
+	}
+	if match == nil {
+		return reflect.Value{}, false
+	}
+	f, err := v.FieldByIndexErr(match)
+	if err != nil {
+		// The field lies within a nil embedded pointer.
+		return reflect.Value{}, false
+	}
+	return f, true
+}
+
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r != '_' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
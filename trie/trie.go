@@ -0,0 +1,176 @@
+// Package trie implements a prefix tree keyed by strings.
+//
+// A Trie stores values under string keys, and in addition to the usual lookup
+// operations, supports queries over the prefixes of keys. This makes it a
+// natural fit for request routers, autocompletion and command dispatch tables:
+//
+//	var t trie.Trie[Handler]
+//	t.Insert("/static/", serveStatic)
+//	t.Insert("/api/", serveAPI)
+//	prefix, h, err := t.LongestPrefix(path)
+//
+// Keys are compared byte by byte, and iteration yields keys in lexicographic
+// byte order.
+package trie
+
+import (
+	"sort"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Trie represents a prefix tree mapping string keys to values of type V. The
+// zero value of a Trie is an empty trie ready to use.
+//
+// A Trie is not safe for concurrent use by multiple goroutines.
+type Trie[V any] struct {
+	root node[V]
+	len  int
+}
+
+type node[V any] struct {
+	children map[byte]*node[V]
+	value    V
+	set      bool
+}
+
+// Delete removes key and its value from t, and reports whether key was present.
+func (t *Trie[V]) Delete(key string) bool {
+	path := make([]*node[V], 0, len(key)+1)
+	n := &t.root
+	path = append(path, n)
+	for i := 0; i < len(key); i++ {
+		n = n.children[key[i]]
+		if n == nil {
+			return false
+		}
+		path = append(path, n)
+	}
+	if !n.set {
+		return false
+	}
+	var none V
+	n.value = none
+	n.set = false
+	t.len--
+	for i := len(key); i > 0; i-- {
+		n := path[i]
+		if n.set || len(n.children) > 0 {
+			break
+		}
+		delete(path[i-1].children, key[i-1])
+	}
+	return true
+}
+
+// Get returns the value stored under key. Returns error if key is not present
+// in t.
+func (t *Trie[V]) Get(key string) (V, error) {
+	n := t.find(key)
+	if n == nil || !n.set {
+		var none V
+		return none, errors.New(nil, "key %q not found", key)
+	}
+	return n.value, nil
+}
+
+// Has reports whether key is present in t.
+func (t *Trie[V]) Has(key string) bool {
+	n := t.find(key)
+	return n != nil && n.set
+}
+
+// Insert stores value v under key, replacing any value previously stored under
+// key.
+func (t *Trie[V]) Insert(key string, v V) {
+	n := &t.root
+	for i := 0; i < len(key); i++ {
+		if n.children == nil {
+			n.children = make(map[byte]*node[V])
+		}
+		child := n.children[key[i]]
+		if child == nil {
+			child = new(node[V])
+			n.children[key[i]] = child
+		}
+		n = child
+	}
+	if !n.set {
+		t.len++
+	}
+	n.value = v
+	n.set = true
+}
+
+// Len returns the number of keys in t.
+func (t *Trie[V]) Len() int {
+	return t.len
+}
+
+// LongestPrefix returns the longest key in t which is a prefix of s, along with
+// its value. Returns error if no key in t is a prefix of s.
+func (t *Trie[V]) LongestPrefix(s string) (string, V, error) {
+	var value V
+	found := -1
+	n := &t.root
+	for i := 0; ; i++ {
+		if n.set {
+			found = i
+			value = n.value
+		}
+		if i == len(s) {
+			break
+		}
+		n = n.children[s[i]]
+		if n == nil {
+			break
+		}
+	}
+	if found < 0 {
+		return "", value, errors.New(nil, "no prefix of %q found", s)
+	}
+	return s[:found], value, nil
+}
+
+// Walk calls fn for each key in t and its value, in lexicographic order of key.
+// Iteration stops early if fn returns false.
+func (t *Trie[V]) Walk(fn func(key string, v V) bool) {
+	t.WalkPrefix("", fn)
+}
+
+// WalkPrefix calls fn for each key in t which begins with prefix and its value,
+// in lexicographic order of key. Iteration stops early if fn returns false.
+func (t *Trie[V]) WalkPrefix(prefix string, fn func(key string, v V) bool) {
+	n := t.find(prefix)
+	if n == nil {
+		return
+	}
+	n.walk([]byte(prefix), fn)
+}
+
+func (t *Trie[V]) find(key string) *node[V] {
+	n := &t.root
+	for i := 0; i < len(key) && n != nil; i++ {
+		n = n.children[key[i]]
+	}
+	return n
+}
+
+func (n *node[V]) walk(key []byte, fn func(string, V) bool) bool {
+	if n.set && !fn(string(key), n.value) {
+		return false
+	}
+	edges := make([]byte, 0, len(n.children))
+	for b := range n.children {
+		edges = append(edges, b)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i] < edges[j]
+	})
+	for _, b := range edges {
+		if !n.children[b].walk(append(key, b), fn) {
+			return false
+		}
+	}
+	return true
+}
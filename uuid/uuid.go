@@ -0,0 +1,221 @@
+// Package uuid implements the generation and parsing of UUIDs, as described in
+// RFC 9562.
+//
+// NewV4 generates random UUIDs, and NewV7 generates UUIDs which sort by their
+// time of creation, which makes them efficient database keys:
+//
+//	id, err := uuid.NewV7()
+//	fmt.Println(id) // 01890a5d-ac96-774b-bcce-b302099a8057
+//
+// A UUID can be stored in SQL databases and encoded as text or JSON, in its
+// canonical form:
+//
+//	type User struct {
+//		ID   uuid.UUID `json:"id"`
+//		Name string    `json:"name"`
+//	}
+package uuid
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"git.sr.ht/~kvo/go-std/errors"
+	"git.sr.ht/~kvo/go-std/randx"
+)
+
+// UUID is a universally unique identifier. The zero value is the nil UUID.
+type UUID [16]byte
+
+var (
+	// Nil is the UUID whose bits are all zero.
+	Nil UUID
+	// Max is the UUID whose bits are all one.
+	Max = UUID{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}
+)
+
+// v7 holds the state used to keep UUIDs generated by NewV7 in order.
+var v7 struct {
+	mu  sync.Mutex
+	ms  int64
+	seq uint16
+}
+
+// Compare returns -1 if a sorts before b, 0 if they are equal, and +1 if a
+// sorts after b. UUIDs sort in the order of their bytes, so version 7 UUIDs
+// sort by their time of creation.
+func Compare(a, b UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// NewV4 returns a random, version 4 UUID. Returns error if the system's secure
+// random source fails.
+func NewV4() (UUID, error) {
+	var u UUID
+	b, err := randx.Bytes(16)
+	if err != nil {
+		return Nil, errors.New(err, "cannot generate UUID")
+	}
+	copy(u[:], b)
+	u.setVersion(4)
+	return u, nil
+}
+
+// NewV7 returns a version 7 UUID, which holds the current Unix time in
+// milliseconds followed by random bits. UUIDs returned by NewV7 within a
+// process are strictly increasing, even if generated within the same
+// millisecond or if the system clock moves backwards. Returns error if the
+// system's secure random source fails.
+func NewV7() (UUID, error) {
+	b, err := randx.Bytes(10)
+	if err != nil {
+		return Nil, errors.New(err, "cannot generate UUID")
+	}
+	v7.mu.Lock()
+	defer v7.mu.Unlock()
+	ms := time.Now().UnixMilli()
+	if ms > v7.ms {
+		v7.ms = ms
+		// Start the sequence in the lower half of its range to leave room
+		// for increments within the millisecond.
+		v7.seq = binary.BigEndian.Uint16(b[:2]) & 0x7ff
+	} else {
+		v7.seq++
+		if v7.seq > 0xfff {
+			v7.ms++
+			v7.seq = 0
+		}
+	}
+	var u UUID
+	binary.BigEndian.PutUint64(u[:8], uint64(v7.ms)<<16|uint64(v7.seq))
+	copy(u[8:], b[2:])
+	u.setVersion(7)
+	return u, nil
+}
+
+// Parse parses s as a UUID, in the canonical form
+// "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" of hexadecimal digits, in either
+// case. The forms "{xxxxxxxx-...}", "urn:uuid:xxxxxxxx-..." and 32 digits
+// without hyphens are also accepted. Returns error if s is not a UUID.
+func Parse(s string) (UUID, error) {
+	var u UUID
+	t := s
+	if len(t) == 38 && t[0] == '{' && t[37] == '}' {
+		t = t[1:37]
+	} else if len(t) == 45 && strings.EqualFold(t[:9], "urn:uuid:") {
+		t = t[9:]
+	}
+	switch len(t) {
+	case 36:
+		if t[8] != '-' || t[13] != '-' || t[18] != '-' || t[23] != '-' {
+			return Nil, errors.New(nil, "invalid UUID %q", s)
+		}
+		t = t[:8] + t[9:13] + t[14:18] + t[19:23] + t[24:]
+	case 32:
+	default:
+		return Nil, errors.New(nil, "invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(t)); err != nil {
+		return Nil, errors.New(nil, "invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// IsNil reports whether u is the nil UUID.
+func (u UUID) IsNil() bool {
+	return u == Nil
+}
+
+// MarshalText encodes u in its canonical form.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// Scan implements sql.Scanner. It accepts the canonical and other forms
+// accepted by Parse as strings or byte slices, 16-byte binary UUIDs, and
+// NULL, which gives the nil UUID.
+func (u *UUID) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*u = Nil
+		return nil
+	case string:
+		p, err := Parse(v)
+		if err != nil {
+			return errors.New(err, "cannot scan UUID")
+		}
+		*u = p
+		return nil
+	case []byte:
+		if len(v) == 16 {
+			copy(u[:], v)
+			return nil
+		}
+		p, err := Parse(string(v))
+		if err != nil {
+			return errors.New(err, "cannot scan UUID")
+		}
+		*u = p
+		return nil
+	}
+	return errors.New(nil, "cannot scan %T into UUID", src)
+}
+
+// String returns u in the canonical form, with lowercase hexadecimal digits.
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// Time returns the time of creation held in a version 7 UUID, to the
+// millisecond. Returns error if u is not a version 7 UUID.
+func (u UUID) Time() (time.Time, error) {
+	if u.Version() != 7 {
+		return time.Time{}, errors.New(nil, "UUID %s holds no time", u)
+	}
+	ms := int64(binary.BigEndian.Uint64(u[:8]) >> 16)
+	return time.UnixMilli(ms), nil
+}
+
+// UnmarshalText parses a UUID in any form accepted by Parse.
+func (u *UUID) UnmarshalText(text []byte) error {
+	p, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*u = p
+	return nil
+}
+
+// Value implements driver.Valuer, storing u in its canonical form.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// Version returns the version of u, such as 4 or 7.
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// setVersion sets the version of u, and its variant to that of RFC 9562.
+func (u *UUID) setVersion(v byte) {
+	u[6] = u[6]&0x0f | v<<4
+	u[8] = u[8]&0x3f | 0x80
+}
@@ -0,0 +1,230 @@
+package validate
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Rule checks a value, and returns error describing how the value is invalid,
+// such as "must be at least 1", or nil if it is valid. The value may be of any
+// type; a rule which does not apply to its type reports so as a violation.
+// Rules other than Required treat a pointer as the value it points to, and
+// accept a nil pointer.
+type Rule func(v any) error
+
+// Each returns a rule which checks each element of a slice, array or map with
+// rules. When used with a Validator, each violation is reported at the path of
+// the element, such as "tags[2]".
+func Each(rules ...Rule) Rule {
+	return func(v any) error {
+		rv, ok := indirect(v)
+		if !ok {
+			return nil
+		}
+		var errs elemErrors
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				errs.check(fmt.Sprintf("[%d]", i), rv.Index(i).Interface(), rules)
+			}
+		case reflect.Map:
+			for _, k := range sortedKeys(rv) {
+				errs.check(fmt.Sprintf("[%v]", k), rv.MapIndex(k).Interface(), rules)
+			}
+		default:
+			return errors.New(nil, "must be a list")
+		}
+		if len(errs) == 0 {
+			return nil
+		}
+		return errs
+	}
+}
+
+// Length returns a rule which requires a string, slice, array or map to have
+// at least min and at most max elements, where the length of a string is its
+// number of characters. If max is negative, there is no upper limit.
+func Length(min, max int) Rule {
+	return func(v any) error {
+		rv, ok := indirect(v)
+		if !ok {
+			return nil
+		}
+		var n int
+		switch rv.Kind() {
+		case reflect.String:
+			n = utf8.RuneCountInString(rv.String())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			n = rv.Len()
+		default:
+			return errors.New(nil, "must have a length")
+		}
+		switch {
+		case min == max && n != min:
+			return errors.New(nil, "must have length %d", min)
+		case n < min:
+			return errors.New(nil, "must have length at least %d", min)
+		case max >= 0 && n > max:
+			return errors.New(nil, "must have length at most %d", max)
+		}
+		return nil
+	}
+}
+
+// Match returns a rule which requires a string to match re.
+func Match(re *regexp.Regexp) Rule {
+	return func(v any) error {
+		rv, ok := indirect(v)
+		if !ok {
+			return nil
+		}
+		if rv.Kind() != reflect.String {
+			return errors.New(nil, "must be a string")
+		}
+		if !re.MatchString(rv.String()) {
+			return errors.New(nil, "must match %s", re)
+		}
+		return nil
+	}
+}
+
+// Max returns a rule which requires a number to be at most n.
+func Max(n float64) Rule {
+	return Range(negInf, n)
+}
+
+// Min returns a rule which requires a number to be at least n.
+func Min(n float64) Rule {
+	return Range(n, posInf)
+}
+
+// OneOf returns a rule which requires a value, formatted as by fmt.Sprint, to
+// be one of choices.
+func OneOf(choices ...string) Rule {
+	return func(v any) error {
+		rv, ok := indirect(v)
+		if !ok {
+			return nil
+		}
+		s := fmt.Sprint(rv.Interface())
+		for _, c := range choices {
+			if s == c {
+				return nil
+			}
+		}
+		return errors.New(nil, "must be one of %s", strings.Join(choices, ", "))
+	}
+}
+
+// Range returns a rule which requires a number to be at least min and at most
+// max.
+func Range(min, max float64) Rule {
+	return func(v any) error {
+		rv, ok := indirect(v)
+		if !ok {
+			return nil
+		}
+		var x float64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			x = float64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			x = float64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			x = rv.Float()
+		default:
+			return errors.New(nil, "must be a number")
+		}
+		switch {
+		case x < min && max == posInf:
+			return errors.New(nil, "must be at least %s", formatFloat(min))
+		case x > max && min == negInf:
+			return errors.New(nil, "must be at most %s", formatFloat(max))
+		case x < min || x > max:
+			return errors.New(nil, "must be between %s and %s", formatFloat(min), formatFloat(max))
+		}
+		return nil
+	}
+}
+
+// Required is a rule which requires a value to be present: a pointer or
+// interface must not be nil, a string, slice or map must not be empty, and
+// any other value must not be its type's zero value.
+func Required(v any) error {
+	rv := reflect.ValueOf(v)
+	switch {
+	case !rv.IsValid():
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map:
+		if rv.Len() > 0 {
+			return nil
+		}
+	case !rv.IsZero():
+		return nil
+	}
+	return errors.New(nil, "is required")
+}
+
+var (
+	negInf = math.Inf(-1)
+	posInf = math.Inf(1)
+)
+
+// elemErrors holds the violations of the elements of a value checked by Each,
+// with paths relative to the value.
+type elemErrors []Violation
+
+func (e elemErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Error()
+	}
+	return strings.Join(msgs, ", ")
+}
+
+func (e *elemErrors) check(path string, v any, rules []Rule) {
+	for _, rule := range rules {
+		err := rule(v)
+		if nested, ok := err.(elemErrors); ok {
+			for _, n := range nested {
+				*e = append(*e, Violation{path + n.Path, n.Message})
+			}
+		} else if err != nil {
+			*e = append(*e, Violation{path, err.Error()})
+		}
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of the map m, ordered by their formatted value,
+// so that violations are reported in a consistent order.
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+// indirect returns the value v holds, following pointers, and false if v or
+// any pointer is nil.
+func indirect(v any) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return rv, false
+		}
+		rv = rv.Elem()
+	}
+	return rv, rv.IsValid()
+}
@@ -0,0 +1,364 @@
+// Package validate implements the validation of values, such as the payloads
+// of API requests.
+//
+// Struct validates a struct by the rules given in its fields' validate tags:
+//
+//	type Signup struct {
+//		Name    string   `json:"name" validate:"required,maxlen=64"`
+//		Email   string   `json:"email" validate:"required,regexp=^[^@]+@[^@]+$"`
+//		Age     int      `json:"age" validate:"min=13,max=150"`
+//		Plan    string   `json:"plan" validate:"oneof=free pro"`
+//		Tags    []string `json:"tags" validate:"maxlen=8,dive,minlen=1"`
+//		Address Address  `json:"address"`
+//	}
+//	if err := validate.Struct(&req); err != nil {
+//		// name: is required, tags[2]: must have length at least 1
+//	}
+//
+// A Validator validates values by rules given in code, and collects the
+// violations of both:
+//
+//	var v validate.Validator
+//	v.Check("name", req.Name, validate.Required, validate.Length(1, 64))
+//	v.Check("port", req.Port, validate.Range(1, 65535))
+//	v.Struct("address", req.Address)
+//	return v.Err()
+//
+// Validation does not stop at the first violation, but reports every violating
+// field, each with its path, such as "items[2].name".
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"git.sr.ht/~kvo/go-std/errors"
+)
+
+// Violation describes a value which is invalid.
+type Violation struct {
+	// Path locates the value, such as "address.city" or "items[2]".
+	Path string
+	// Message describes how the value is invalid, such as "is required".
+	Message string
+}
+
+// Error returns the path and message of v, such as "name: is required".
+func (v Violation) Error() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// Validator collects violations. The zero value of a Validator is ready to use.
+type Validator struct {
+	violations []Violation
+}
+
+// Add records a violation at path, with a message formatted as by fmt.Sprintf,
+// for checks which are not expressed as rules.
+func (v *Validator) Add(path, format string, args ...any) {
+	v.violations = append(v.violations, Violation{path, fmt.Sprintf(format, args...)})
+}
+
+// Check checks value, found at path, with rules, and records the violation of
+// each rule which fails.
+func (v *Validator) Check(path string, value any, rules ...Rule) {
+	for _, rule := range rules {
+		err := rule(value)
+		if elems, ok := err.(elemErrors); ok {
+			for _, e := range elems {
+				v.violations = append(v.violations, Violation{path + e.Path, e.Message})
+			}
+		} else if err != nil {
+			v.violations = append(v.violations, Violation{path, err.Error()})
+		}
+	}
+}
+
+// Err returns error listing every violation recorded by v, or nil if there are
+// none.
+func (v *Validator) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	errs := make([]error, len(v.violations))
+	for i, violation := range v.violations {
+		errs[i] = violation
+	}
+	return errors.New(errors.Join(errs...), "validation failed")
+}
+
+// Struct checks the struct s, or the struct to which s points, found at path,
+// and records its violations, as described by the package-level function
+// Struct. A nil pointer is valid.
+func (v *Validator) Struct(path string, s any) {
+	rv := reflect.ValueOf(s)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		v.Add(path, "cannot validate %T: not a struct", s)
+		return
+	}
+	if !rv.CanAddr() {
+		// Make rv addressable, so that Validate methods with pointer
+		// receivers may be called.
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		rv = p.Elem()
+	}
+	v.value(path, rv)
+}
+
+// Violations returns the violations recorded by v, in the order found.
+func (v *Validator) Violations() []Violation {
+	return v.violations
+}
+
+// Struct validates the struct s, or the struct to which s points, by the
+// validate tags of its fields, which hold a comma-separated list of rules:
+//
+//	required     Required
+//	omitempty    the other rules are skipped if the field is its zero value
+//	min=N        Min(N)
+//	max=N        Max(N)
+//	len=N        Length(N, N)
+//	minlen=N     Length(N, -1)
+//	maxlen=N     Length(0, N)
+//	oneof=A B C  OneOf("A", "B", "C")
+//	regexp=RE    Match(regexp.MustCompile(RE)); RE extends to the end of the tag,
+//	             so must be the last rule, and may contain commas
+//	dive         the rules which follow apply to each element of the field,
+//	             as by Each
+//
+// Fields of struct types, pointers to structs, and slices, arrays and maps of
+// them, are validated in turn, whether or not they have a validate tag, and
+// fields of embedded structs are validated as though they belonged to s. A
+// field tagged validate:"-" is skipped. Any value which has a method
+// Validate() error, such as a struct type checking the relation between its
+// fields, is also checked by that method.
+//
+// The path of a field is its name in its json tag, if any, or else its Go
+// name, joined to the path of the struct containing it by a dot, so that
+// violations are reported in terms of the payload a client sent.
+//
+// Returns error listing every violation, or a malformed tag, found.
+func Struct(s any) error {
+	var v Validator
+	v.Struct("", s)
+	return v.Err()
+}
+
+// tag holds the rules of a validate tag.
+type tag struct {
+	required  bool
+	omitempty bool
+	rules     []Rule
+	each      []Rule
+	err       error
+}
+
+// tags caches parsed validate tags by their text.
+var tags sync.Map
+
+func parseTag(text string) *tag {
+	if t, ok := tags.Load(text); ok {
+		return t.(*tag)
+	}
+	t := &tag{}
+	rules := &t.rules
+	for rest := text; rest != ""; {
+		var item string
+		if strings.HasPrefix(rest, "regexp=") {
+			item, rest = rest, ""
+		} else {
+			item, rest, _ = strings.Cut(rest, ",")
+		}
+		name, arg, _ := strings.Cut(item, "=")
+		var err error
+		switch name {
+		case "required":
+			t.required = true
+			*rules = append(*rules, Required)
+		case "omitempty":
+			t.omitempty = true
+		case "dive":
+			rules = &t.each
+		case "min", "max":
+			n, perr := strconv.ParseFloat(arg, 64)
+			switch {
+			case perr != nil:
+				err = errors.New(nil, "invalid number %q for %s", arg, name)
+			case name == "min":
+				*rules = append(*rules, Min(n))
+			default:
+				*rules = append(*rules, Max(n))
+			}
+		case "len", "minlen", "maxlen":
+			n, perr := strconv.Atoi(arg)
+			switch {
+			case perr != nil || n < 0:
+				err = errors.New(nil, "invalid length %q for %s", arg, name)
+			case name == "len":
+				*rules = append(*rules, Length(n, n))
+			case name == "minlen":
+				*rules = append(*rules, Length(n, -1))
+			default:
+				*rules = append(*rules, Length(0, n))
+			}
+		case "oneof":
+			*rules = append(*rules, OneOf(strings.Fields(arg)...))
+		case "regexp":
+			re, rerr := regexp.Compile(arg)
+			if rerr != nil {
+				err = errors.New(nil, "invalid regular expression %q for regexp", arg)
+			} else {
+				*rules = append(*rules, Match(re))
+			}
+		case "":
+		default:
+			err = errors.New(nil, "unknown rule %s", name)
+		}
+		if err != nil {
+			t.err = errors.New(err, "invalid validate tag %q", text)
+			break
+		}
+	}
+	if len(t.each) > 0 {
+		t.rules = append(t.rules, Each(t.each...))
+	}
+	tags.Store(text, t)
+	return t
+}
+
+// validatable is implemented by values which check themselves.
+type validatable interface {
+	Validate() error
+}
+
+// walk records the violations of the fields of the struct s.
+func (v *Validator) walk(path string, s reflect.Value) {
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		text := f.Tag.Get("validate")
+		if !f.IsExported() || text == "-" {
+			continue
+		}
+		fv := s.Field(i)
+		fpath := path
+		if !f.Anonymous {
+			fpath = join(path, fieldName(f))
+		}
+		tag := parseTag(text)
+		if tag.err != nil {
+			v.Add(fpath, "%s", tag.err)
+			continue
+		}
+		if tag.omitempty && !tag.required && fv.IsZero() {
+			continue
+		}
+		v.Check(fpath, fv.Interface(), tag.rules...)
+		if f.Anonymous {
+			// The Validate method of an embedded struct, if any, is promoted
+			// to s, so is not called again.
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				v.walk(fpath, fv)
+				continue
+			}
+		}
+		v.value(fpath, fv)
+	}
+}
+
+// value records the violations of the value rv, of the structs it holds, and
+// of its Validate method, if any.
+func (v *Validator) value(path string, rv reflect.Value) {
+	if !deep(rv.Type()) {
+		return
+	}
+	val, ok := rv.Interface().(validatable)
+	if !ok && rv.CanAddr() {
+		val, ok = rv.Addr().Interface().(validatable)
+	}
+	if ok && !(rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		if err := val.Validate(); err != nil {
+			v.Add(path, "%s", err)
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return
+		}
+		e := rv.Elem()
+		if !ok {
+			v.value(path, e)
+			return
+		}
+		// The method has been called through rv, so is not called again for
+		// the value to which it refers.
+		for e.Kind() == reflect.Pointer && !e.IsNil() {
+			e = e.Elem()
+		}
+		if e.Kind() == reflect.Struct {
+			v.walk(path, e)
+		}
+	case reflect.Struct:
+		v.walk(path, rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			v.value(fmt.Sprintf("%s[%d]", path, i), rv.Index(i))
+		}
+	case reflect.Map:
+		for _, k := range sortedKeys(rv) {
+			v.value(fmt.Sprintf("%s[%v]", path, k), rv.MapIndex(k))
+		}
+	}
+}
+
+// deep reports whether values of type t may hold structs or values with a
+// Validate method, and so must be examined by Validator.value.
+func deep(t reflect.Type) bool {
+	if t.Implements(validatableType) || reflect.PointerTo(t).Implements(validatableType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return deep(t.Elem())
+	}
+	return false
+}
+
+var validatableType = reflect.TypeOf((*validatable)(nil)).Elem()
+
+// fieldName returns the name of the field f in its json tag, or else its Go
+// name.
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}